@@ -3,18 +3,57 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/alerts"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/audit"
 	"github.com/mugayoshi/k8s-visualizer/server/internal/handlers"
 	"github.com/mugayoshi/k8s-visualizer/server/internal/middleware"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/pkg/certs"
 	"github.com/mugayoshi/k8s-visualizer/server/internal/pkg/config"
 	"github.com/mugayoshi/k8s-visualizer/server/internal/services"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/snapshots"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/telemetry"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"k8s.io/client-go/informers"
 )
 
 func main() {
+	snapshotFile := flag.String("snapshot", "", "serve the API from a recorded snapshot file instead of a live cluster")
+	demo := flag.Bool("demo", false, "serve a simulated cluster with synthetic churn instead of a live cluster")
+	demoOpts := services.DefaultDemoOptions()
+	flag.IntVar(&demoOpts.Nodes, "demo-nodes", demoOpts.Nodes, "number of simulated nodes in demo mode")
+	flag.IntVar(&demoOpts.Namespaces, "demo-namespaces", demoOpts.Namespaces, "number of simulated namespaces in demo mode")
+	flag.IntVar(&demoOpts.Deployments, "demo-deployments", demoOpts.Deployments, "simulated deployments per namespace in demo mode")
+	flag.Float64Var(&demoOpts.EventsPerSec, "demo-rate", demoOpts.EventsPerSec, "simulated changes per second in demo mode")
+	flag.Parse()
+
+	// Background work stops and the server drains on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Initialize Kubernetes client
-	k8sClient, err := services.NewK8sClient()
+	var k8sClient services.K8sClientInterface
+	var err error
+	if *demo {
+		demoClient := services.NewDemoClient(demoOpts)
+		go demoClient.Run(ctx)
+		log.Printf("Demo mode: %d nodes, %d namespaces, %d deployments per namespace", demoOpts.Nodes, demoOpts.Namespaces, demoOpts.Deployments)
+		k8sClient = demoClient
+	} else {
+		k8sClient, err = newK8sClient(*snapshotFile)
+	}
 	if err != nil {
 		log.Fatalf("Failed to create K8s client: %v", err)
 	}
@@ -22,16 +61,36 @@ func main() {
 	cfg := config.Load()
 	cfg.Print()
 
+	// Tracing
+	shutdownTracing, err := telemetry.SetupTracing(ctx, cfg.Tracing)
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+
 	// Set Gin mode
 	gin.SetMode(cfg.Server.Mode)
 
 	// Create Gin router
 	r := gin.Default()
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatalf("Invalid SERVER_TRUSTED_PROXIES: %v", err)
+	}
+
+	// Audit trail
+	auditLogger, err := newAuditLogger(cfg.Audit)
+	if err != nil {
+		log.Fatalf("Failed to set up audit log: %v", err)
+	}
 
 	// Middleware
+	r.Use(otelgin.Middleware(cfg.Tracing.ServiceName))
 	r.Use(middleware.CORS())
+	// Identity must be resolved before the logger records the audit event
+	r.Use(middleware.ClientCertIdentity())
+	r.Use(telemetry.HTTPMetrics())
 	r.Use(middleware.LoggerWithConfig(middleware.LoggerConfig{
-		SkipPaths: []string{"/health"},
+		SkipPaths: []string{"/health", cfg.Server.MetricsPath},
+		Audit:     auditLogger,
 	}))
 	r.Use(gin.Recovery())
 
@@ -43,12 +102,32 @@ func main() {
 		})
 	})
 
+	// Limits
+	passThrough := func(c *gin.Context) { c.Next() }
+	rateLimit, expensive, wsLimit := passThrough, passThrough, passThrough
+	if cfg.RateLimit.RequestsPerSecond > 0 {
+		rateLimit = middleware.RateLimit(cfg.RateLimit.RequestsPerSecond, cfg.RateLimit.Burst)
+	}
+	if cfg.RateLimit.MaxConcurrentExpensive > 0 {
+		expensive = middleware.ConcurrencyLimit(cfg.RateLimit.MaxConcurrentExpensive)
+	}
+	if cfg.RateLimit.MaxWebSocketsPerClient > 0 {
+		wsLimit = middleware.ConnectionLimit(cfg.RateLimit.MaxWebSocketsPerClient)
+	}
+
+	// One informer factory serves the timeline and the alert engine so each
+	// resource is watched and cached once
+	informerFactory := informers.NewSharedInformerFactory(k8sClient.GetClientset(), 0)
+
+	// Prometheus metrics for the server itself (cluster metrics live under /api/metrics)
+	r.GET(cfg.Server.MetricsPath, gin.WrapH(telemetry.Handler()))
+
 	// API routes
-	api := r.Group("/api")
+	api := r.Group("/api", rateLimit)
 	{
 		// Metrics endpoint
-		api.GET("/metrics", func(c *gin.Context) {
-			ctx := context.Background()
+		api.GET("/metrics", expensive, func(c *gin.Context) {
+			ctx := c.Request.Context()
 			metrics, err := k8sClient.GetClusterMetrics(ctx)
 			if err != nil {
 				c.JSON(500, gin.H{"error": err.Error()})
@@ -56,34 +135,272 @@ func main() {
 			}
 			c.JSON(200, metrics)
 		})
+
+		// Metrics history endpoint
+		if cfg.History.Enabled {
+			tiers, err := services.ParseHistoryTiers(cfg.History.Tiers)
+			if err != nil {
+				log.Fatalf("Invalid metrics history tiers: %v", err)
+			}
+			history, err := services.NewMetricsHistory(tiers, cfg.History.Interval)
+			if err != nil {
+				log.Fatalf("Invalid metrics history interval: %v", err)
+			}
+			go history.Run(ctx, k8sClient.GetClusterMetrics)
+
+			historyHandler := handlers.NewHistoryHandler(history)
+			api.GET("/metrics/history", historyHandler.GetMetricsHistory)
+		}
+
 		// Node endpoints
 		nodeHandler := handlers.NewNodeHandler(k8sClient)
 		api.GET("/nodes", nodeHandler.ListNodes)
 		api.GET("/nodes/:name", nodeHandler.GetNode)
+		api.GET("/allocation", expensive, nodeHandler.GetAllocation)
 
 		// Pod endpoints
 		podHandler := handlers.NewPodHandler(k8sClient)
-		api.GET("/pods", podHandler.ListPods)
+		api.GET("/pods", expensive, podHandler.ListPods)
 		api.GET("/pods/:namespace/:name", podHandler.GetPod)
 		api.GET("/pods/:namespace/:name/logs", podHandler.GetPodLogs)
+		api.GET("/pods/:namespace/:name/diagnosis", podHandler.GetPodDiagnosis)
+		api.GET("/pods/:namespace/:name/scheduling", expensive, podHandler.GetPodScheduling)
+		api.GET("/security", expensive, podHandler.GetSecurityReport)
 
 		// Namespace endpoints
 		namespaceHandler := handlers.NewNamespaceHandler(k8sClient)
 		api.GET("/namespaces", namespaceHandler.ListNamespaces)
 
+		// Quota endpoint
+		quotaHandler := handlers.NewQuotaHandler(k8sClient)
+		api.GET("/quotas", quotaHandler.ListQuotas)
+
 		// Deployment endpoints
 		deploymentHandler := handlers.NewDeploymentHandler(k8sClient)
 		api.GET("/deployments", deploymentHandler.ListDeployments)
 
+		// ConfigMap and Secret endpoints
+		configHandler := handlers.NewConfigHandler(k8sClient, handlers.SecretPolicy{
+			RevealGroups:   cfg.Secrets.RevealGroups,
+			RevealPatterns: cfg.Secrets.RevealPatterns,
+		})
+		api.GET("/configmaps", expensive, configHandler.ListConfigMaps)
+		api.GET("/configmaps/:namespace/:name", configHandler.GetConfigMap)
+		api.GET("/secrets", expensive, configHandler.ListSecrets)
+		api.GET("/secrets/:namespace/:name", configHandler.GetSecret)
+
+		// Storage endpoints
+		storageHandler := handlers.NewStorageHandler(k8sClient)
+		api.GET("/persistentvolumeclaims", expensive, storageHandler.ListClaims)
+		api.GET("/persistentvolumes", expensive, storageHandler.ListVolumes)
+		api.GET("/storageclasses", storageHandler.ListStorageClasses)
+
+		// Routing endpoints
+		routingHandler := handlers.NewRoutingHandler(k8sClient)
+		api.GET("/ingresses", expensive, routingHandler.ListIngresses)
+		api.GET("/httproutes", expensive, routingHandler.ListHTTPRoutes)
+
+		// NetworkPolicy endpoints
+		netpolHandler := handlers.NewNetworkPolicyHandler(k8sClient)
+		api.GET("/networkpolicies", expensive, netpolHandler.ListNetworkPolicies)
+		api.GET("/networkpolicies/reachability", expensive, netpolHandler.CheckReachability)
+		api.GET("/networkpolicies/graph", expensive, netpolHandler.GetTrafficGraph)
+
+		// Image inventory endpoint
+		imageHandler := handlers.NewImageHandler(k8sClient)
+		api.GET("/images", expensive, imageHandler.ListImages)
+
+		// Vulnerability endpoint
+		if cfg.Vulns.DBFile != "" {
+			vulnDB, err := services.LoadVulnDB(cfg.Vulns.DBFile)
+			if err != nil {
+				log.Fatalf("Failed to load vulnerability database: %v", err)
+			}
+			log.Printf("Loaded %d advisories from %s", vulnDB.Size(), cfg.Vulns.DBFile)
+
+			vulnHandler := handlers.NewVulnerabilityHandler(k8sClient, vulnDB)
+			api.GET("/vulnerabilities", expensive, vulnHandler.ListVulnerabilities)
+		}
+
+		// Lint endpoint
+		lintHandler := handlers.NewLintHandler(k8sClient)
+		api.GET("/lint", expensive, lintHandler.GetLintReport)
+
+		// Audit endpoints
+		if auditLogger != nil {
+			auditHandler := handlers.NewAuditHandler(auditLogger, cfg.Audit.AdminGroups)
+			api.GET("/audit", auditHandler.QueryEvents)
+		}
+
+		// Cluster state export and diff endpoints
+		stateHandler := handlers.NewStateHandler(k8sClient)
+		api.GET("/state/export", expensive, stateHandler.ExportState)
+		api.POST("/state/diff", expensive, stateHandler.DiffState)
+
+		// Snapshot endpoints
+		if cfg.Snapshots.Path != "" {
+			store, err := snapshots.Open(cfg.Snapshots.Path, snapshots.Retention{
+				MaxCount: cfg.Snapshots.MaxCount,
+				MaxAge:   cfg.Snapshots.MaxAge,
+			})
+			if err != nil {
+				log.Fatalf("Failed to open snapshot store: %v", err)
+			}
+			defer store.Close()
+
+			if cfg.Snapshots.Interval > 0 {
+				go store.Schedule(ctx, cfg.Snapshots.Interval, func(ctx context.Context) (*services.ClusterSnapshot, error) {
+					return services.CaptureSnapshot(ctx, k8sClient.GetClientset())
+				})
+			}
+
+			snapshotHandler := handlers.NewSnapshotHandler(k8sClient, store)
+			api.GET("/snapshots", snapshotHandler.ListSnapshots)
+			api.POST("/snapshots", expensive, snapshotHandler.CreateSnapshot)
+			api.GET("/snapshots/:id", snapshotHandler.GetSnapshot)
+			api.DELETE("/snapshots/:id", snapshotHandler.DeleteSnapshot)
+		}
+
+		// Timeline endpoint
+		if cfg.Timeline.Enabled {
+			timeline := services.NewTimeline(k8sClient.GetClientset(), informerFactory, cfg.Timeline.MaxEntries, cfg.Timeline.MaxObjects)
+			for informer, size := range timeline.CacheSizes() {
+				telemetry.RegisterCacheSize(informer, size)
+			}
+			go timeline.Run(ctx)
+
+			timelineHandler := handlers.NewTimelineHandler(timeline)
+			api.GET("/timeline/:kind/:namespace/:name", timelineHandler.GetTimeline)
+		}
+
+		// Right-sizing endpoint
+		if cfg.Rightsizing.Enabled {
+			sampler := services.NewRightsizingSampler(k8sClient.GetClientset(), cfg.Rightsizing.Interval, cfg.Rightsizing.Window)
+			go sampler.Run(ctx)
+
+			rightsizingHandler := handlers.NewRightsizingHandler(sampler)
+			api.GET("/rightsizing", rightsizingHandler.GetRecommendations)
+		}
+
 		// TODO
 		// WebSocket endpoint
 		wsHandler := handlers.NewWebSocketHandler(k8sClient)
-		api.GET("/ws", wsHandler.HandleWebSocket)
+
+		// Alerting endpoints
+		if cfg.Alerts.RulesFile != "" {
+			alertConfig, err := alerts.LoadConfig(cfg.Alerts.RulesFile)
+			if err != nil {
+				log.Fatalf("Failed to load alert rules: %v", err)
+			}
+			engine := alerts.NewEngine(informerFactory, alertConfig)
+			go engine.Run(ctx)
+			wsHandler.WithAlerts(engine)
+
+			alertsHandler := handlers.NewAlertsHandler(engine)
+			api.GET("/alerts", alertsHandler.ListAlerts)
+			api.GET("/alerts/rules", alertsHandler.ListRules)
+			api.GET("/alerts/silences", alertsHandler.ListSilences)
+			api.POST("/alerts/silences", alertsHandler.CreateSilence)
+			api.DELETE("/alerts/silences/:id", alertsHandler.DeleteSilence)
+		}
+
+		api.GET("/ws", wsLimit, wsHandler.HandleWebSocket)
 	}
 
 	// Start server
-	log.Println("Starting server on :8080")
-	if err := r.Run(":8080"); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	srv := &http.Server{
+		Addr:         net.JoinHostPort(cfg.Server.Host, cfg.Server.Port),
+		Handler:      r,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+	}
+
+	serveErr := make(chan error, 1)
+	if !cfg.TLS.Enabled() {
+		log.Printf("Starting server on %s", srv.Addr)
+		go func() { serveErr <- srv.ListenAndServe() }()
+	} else {
+		clientAuth, err := certs.ParseClientAuth(cfg.TLS.ClientAuthMode())
+		if err != nil {
+			log.Fatalf("Invalid TLS client auth: %v", err)
+		}
+		// Client certificates can only be verified against a CA bundle
+		if clientAuth != tls.NoClientCert && cfg.TLS.ClientCAFile == "" {
+			log.Fatalf("TLS_CLIENT_AUTH=%s requires TLS_CLIENT_CA_FILE", cfg.TLS.ClientAuthMode())
+		}
+		reloader, err := certs.NewReloader(cfg.TLS.CertFile, cfg.TLS.KeyFile, cfg.TLS.ClientCAFile)
+		if err != nil {
+			log.Fatalf("Failed to load TLS certificates: %v", err)
+		}
+		go reloader.Watch(ctx, cfg.TLS.ReloadInterval)
+		srv.TLSConfig = reloader.TLSConfig(clientAuth)
+
+		log.Printf("Starting HTTPS server on %s", srv.Addr)
+		// Certificates come from srv.TLSConfig, so no file paths are passed here
+		go func() { serveErr <- srv.ListenAndServeTLS("", "") }()
+	}
+
+	failed := false
+	select {
+	case err := <-serveErr:
+		log.Printf("Failed to start server: %v", err)
+		failed = true
+	case <-ctx.Done():
+		log.Printf("Shutting down")
+	}
+	stop()
+	informerFactory.Shutdown()
+
+	// Drain in-flight requests, then flush the audit log and buffered spans
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("Failed to shut down server: %v", err)
+	}
+	if auditLogger != nil {
+		if err := auditLogger.Close(); err != nil {
+			log.Printf("Failed to close audit log: %v", err)
+		}
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Printf("Failed to flush traces: %v", err)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// newK8sClient connects to the cluster, or replays snapshotFile when set
+func newK8sClient(snapshotFile string) (services.K8sClientInterface, error) {
+	if snapshotFile == "" {
+		return services.NewK8sClient()
+	}
+
+	replay, err := services.NewReplayClient(snapshotFile)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Replaying snapshot %s taken at %s", snapshotFile, replay.Snapshot().Time)
+	return replay, nil
+}
+
+// newAuditLogger creates the audit logger for the configured sink, or nil
+// when auditing is disabled
+func newAuditLogger(cfg config.AuditConfig) (*audit.Logger, error) {
+	var sink audit.Sink
+	switch cfg.Sink {
+	case "":
+		return nil, nil
+	case "file":
+		fileSink, err := audit.NewFileSink(cfg.FilePath, int64(cfg.MaxSizeMB)*1024*1024, cfg.MaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		sink = fileSink
+	case "webhook":
+		sink = audit.NewWebhookSink(cfg.WebhookURL, 5*time.Second)
+	default:
+		return nil, fmt.Errorf("unknown audit sink: %s", cfg.Sink)
 	}
+	return audit.NewLogger(sink, cfg.Cluster, cfg.Retain), nil
 }
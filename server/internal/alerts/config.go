@@ -0,0 +1,119 @@
+// internal/alerts/config.go
+package alerts
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Rule types
+const (
+	// RulePodRestarts fires when a pod's restart count grew by more than
+	// Threshold within Window
+	RulePodRestarts = "pod_restarts"
+	// RuleNodeNotReady fires when a node has not been Ready for For
+	RuleNodeNotReady = "node_not_ready"
+	// RuleDeploymentUnavailable fires when ready replicas stay below the
+	// desired count for For
+	RuleDeploymentUnavailable = "deployment_unavailable"
+	// RuleNamespacePodCount fires when a namespace has more than Threshold pods
+	RuleNamespacePodCount = "namespace_pod_count"
+)
+
+// Config is the alerting rules file
+type Config struct {
+	Rules              []Rule          `json:"rules"`
+	Webhooks           []WebhookConfig `json:"webhooks"`
+	Silences           []Silence       `json:"silences"`
+	EvaluationInterval metav1.Duration `json:"evaluation_interval"`
+	RepeatInterval     metav1.Duration `json:"repeat_interval"` // Re-notify a still-firing alert after this
+}
+
+// Rule is one alerting rule
+type Rule struct {
+	Name      string          `json:"name"`
+	Type      string          `json:"type"`
+	Severity  string          `json:"severity"`
+	Namespace string          `json:"namespace,omitempty"` // Limit to one namespace; empty means all
+	Threshold int             `json:"threshold,omitempty"`
+	Window    metav1.Duration `json:"window,omitempty"`
+	For       metav1.Duration `json:"for,omitempty"` // How long the condition must hold before firing
+}
+
+// WebhookConfig is a notification endpoint. The payload is Slack-compatible.
+type WebhookConfig struct {
+	URL          string `json:"url"`
+	SendResolved bool   `json:"send_resolved"`
+}
+
+// LoadConfig reads and validates a YAML or JSON rules file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read alert rules: %w", err)
+	}
+
+	var cfg Config
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse alert rules: %w", err)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// validate checks rules and fills defaults
+func (c *Config) validate() error {
+	if c.EvaluationInterval.Duration == 0 {
+		c.EvaluationInterval.Duration = 15 * time.Second
+	}
+	if c.RepeatInterval.Duration == 0 {
+		c.RepeatInterval.Duration = 4 * time.Hour
+	}
+
+	names := make(map[string]bool, len(c.Rules))
+	for i := range c.Rules {
+		rule := &c.Rules[i]
+		if rule.Name == "" {
+			return fmt.Errorf("rule %d has no name", i)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("duplicate rule name %q", rule.Name)
+		}
+		names[rule.Name] = true
+		if rule.Severity == "" {
+			rule.Severity = "warning"
+		}
+
+		switch rule.Type {
+		case RulePodRestarts:
+			if rule.Threshold <= 0 || rule.Window.Duration <= 0 {
+				return fmt.Errorf("rule %q: %s needs a positive threshold and window", rule.Name, rule.Type)
+			}
+		case RuleNamespacePodCount:
+			if rule.Threshold <= 0 {
+				return fmt.Errorf("rule %q: %s needs a positive threshold", rule.Name, rule.Type)
+			}
+		case RuleNodeNotReady, RuleDeploymentUnavailable:
+		default:
+			return fmt.Errorf("rule %q: unknown type %q", rule.Name, rule.Type)
+		}
+	}
+
+	for i, webhook := range c.Webhooks {
+		if webhook.URL == "" {
+			return fmt.Errorf("webhook %d has no url", i)
+		}
+	}
+	for i := range c.Silences {
+		if c.Silences[i].ID == "" {
+			c.Silences[i].ID = fmt.Sprintf("config-%d", i)
+		}
+	}
+	return nil
+}
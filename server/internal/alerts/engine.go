@@ -0,0 +1,592 @@
+// internal/alerts/engine.go
+package alerts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Alert states
+const (
+	StatePending  = "pending"  // Condition holds but not yet for the rule's For
+	StateFiring   = "firing"   // Condition has held for For
+	StateResolved = "resolved" // Condition cleared after firing
+)
+
+// ErrSilenceNotFound is returned when deleting an unknown silence
+var ErrSilenceNotFound = errors.New("silence not found")
+
+// Alert is one rule matching one object
+type Alert struct {
+	Fingerprint string            `json:"fingerprint"`
+	Rule        string            `json:"rule"`
+	Type        string            `json:"type"`
+	Severity    string            `json:"severity"`
+	State       string            `json:"state"`
+	Labels      map[string]string `json:"labels"`
+	Summary     string            `json:"summary"`
+	Value       int               `json:"value"`
+	ActiveSince time.Time         `json:"active_since"` // When the condition was first seen
+	FiredAt     *time.Time        `json:"fired_at,omitempty"`
+	ResolvedAt  *time.Time        `json:"resolved_at,omitempty"`
+	Silenced    bool              `json:"silenced"`
+
+	lastNotified time.Time
+}
+
+// Silence suppresses notifications for alerts of a rule and/or with
+// matching labels. A zero EndsAt never expires.
+type Silence struct {
+	ID       string            `json:"id"`
+	Rule     string            `json:"rule,omitempty"`
+	Matchers map[string]string `json:"matchers,omitempty"`
+	EndsAt   time.Time         `json:"ends_at,omitempty"`
+	Comment  string            `json:"comment,omitempty"`
+}
+
+// matches reports whether the silence applies to the alert at now
+func (s Silence) matches(alert *Alert, now time.Time) bool {
+	if !s.EndsAt.IsZero() && !now.Before(s.EndsAt) {
+		return false
+	}
+	if s.Rule != "" && s.Rule != alert.Rule {
+		return false
+	}
+	for key, value := range s.Matchers {
+		if alert.Labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// restartSample is a pod's total restart count when it was observed
+type restartSample struct {
+	time  time.Time
+	count int32
+}
+
+type podState struct {
+	namespace string
+	name      string
+	terminal  bool
+	restarts  []restartSample
+}
+
+type deploymentState struct {
+	namespace string
+	name      string
+	desired   int32
+	ready     int32
+}
+
+// candidate is a rule condition that currently holds for one object
+type candidate struct {
+	rule    *Rule
+	labels  map[string]string
+	summary string
+	value   int
+}
+
+// Engine evaluates alerting rules against pods, nodes and deployments
+// observed through informers
+type Engine struct {
+	cfg      *Config
+	factory  informers.SharedInformerFactory
+	notifier *Notifier
+	now      func() time.Time
+	dirty    chan struct{}
+
+	mu          sync.Mutex
+	pods        map[string]*podState
+	nodes       map[string]bool // Node name to Ready
+	deployments map[string]*deploymentState
+	alerts      map[string]*Alert
+	silences    []Silence
+	silenceSeq  int
+	subscribers map[int]chan Alert
+	subSeq      int
+}
+
+// NewEngine creates an engine for the given rules. Its informers come from
+// factory, which may be shared with other watchers; the owner of factory
+// shuts it down. Call Run to start watching.
+func NewEngine(factory informers.SharedInformerFactory, cfg *Config) *Engine {
+	e := &Engine{
+		cfg:         cfg,
+		factory:     factory,
+		now:         time.Now,
+		dirty:       make(chan struct{}, 1),
+		pods:        make(map[string]*podState),
+		nodes:       make(map[string]bool),
+		deployments: make(map[string]*deploymentState),
+		alerts:      make(map[string]*Alert),
+		silences:    append([]Silence(nil), cfg.Silences...),
+		subscribers: make(map[int]chan Alert),
+	}
+	if len(cfg.Webhooks) > 0 {
+		e.notifier = NewNotifier(cfg.Webhooks)
+	}
+
+	e.factory.Core().V1().Pods().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { e.onPod(obj) },
+		UpdateFunc: func(_, obj interface{}) { e.onPod(obj) },
+		DeleteFunc: func(obj interface{}) { e.onDelete("pod", obj) },
+	})
+	e.factory.Core().V1().Nodes().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { e.onNode(obj) },
+		UpdateFunc: func(_, obj interface{}) { e.onNode(obj) },
+		DeleteFunc: func(obj interface{}) { e.onDelete("node", obj) },
+	})
+	e.factory.Apps().V1().Deployments().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { e.onDeployment(obj) },
+		UpdateFunc: func(_, obj interface{}) { e.onDeployment(obj) },
+		DeleteFunc: func(obj interface{}) { e.onDelete("deployment", obj) },
+	})
+	return e
+}
+
+// Run starts the informers and evaluates rules after changes and on the
+// evaluation interval until ctx is cancelled
+func (e *Engine) Run(ctx context.Context) {
+	if e.notifier != nil {
+		go e.notifier.Run()
+		defer e.notifier.Close()
+	}
+
+	e.factory.Start(ctx.Done())
+	e.factory.WaitForCacheSync(ctx.Done())
+
+	ticker := time.NewTicker(e.cfg.EvaluationInterval.Duration)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.evaluate(e.now())
+		case <-e.dirty:
+			e.evaluate(e.now())
+		}
+	}
+}
+
+// markDirty requests an evaluation; bursts of events coalesce into one
+func (e *Engine) markDirty() {
+	select {
+	case e.dirty <- struct{}{}:
+	default:
+	}
+}
+
+func (e *Engine) onPod(obj interface{}) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return
+	}
+
+	var restarts int32
+	for _, status := range pod.Status.InitContainerStatuses {
+		restarts += status.RestartCount
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		restarts += status.RestartCount
+	}
+
+	e.mu.Lock()
+	key := pod.Namespace + "/" + pod.Name
+	state, ok := e.pods[key]
+	if !ok {
+		state = &podState{namespace: pod.Namespace, name: pod.Name}
+		e.pods[key] = state
+	}
+	state.terminal = pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+	if n := len(state.restarts); n == 0 || state.restarts[n-1].count != restarts {
+		state.restarts = append(state.restarts, restartSample{time: e.now(), count: restarts})
+	}
+	e.mu.Unlock()
+	e.markDirty()
+}
+
+func (e *Engine) onNode(obj interface{}) {
+	node, ok := obj.(*corev1.Node)
+	if !ok {
+		return
+	}
+
+	ready := false
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			ready = condition.Status == corev1.ConditionTrue
+		}
+	}
+
+	e.mu.Lock()
+	e.nodes[node.Name] = ready
+	e.mu.Unlock()
+	e.markDirty()
+}
+
+func (e *Engine) onDeployment(obj interface{}) {
+	deployment, ok := obj.(*appsv1.Deployment)
+	if !ok {
+		return
+	}
+
+	desired := int32(1)
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
+	}
+
+	e.mu.Lock()
+	e.deployments[deployment.Namespace+"/"+deployment.Name] = &deploymentState{
+		namespace: deployment.Namespace,
+		name:      deployment.Name,
+		desired:   desired,
+		ready:     deployment.Status.ReadyReplicas,
+	}
+	e.mu.Unlock()
+	e.markDirty()
+}
+
+func (e *Engine) onDelete(kind string, obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
+	e.mu.Lock()
+	switch o := obj.(type) {
+	case *corev1.Pod:
+		delete(e.pods, o.Namespace+"/"+o.Name)
+	case *corev1.Node:
+		delete(e.nodes, o.Name)
+	case *appsv1.Deployment:
+		delete(e.deployments, o.Namespace+"/"+o.Name)
+	}
+	e.mu.Unlock()
+	e.markDirty()
+}
+
+// evaluate checks every rule and moves alerts between states
+func (e *Engine) evaluate(now time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.pruneRestarts(now)
+	e.pruneSilences(now)
+
+	candidates := make(map[string]candidate)
+	for i := range e.cfg.Rules {
+		rule := &e.cfg.Rules[i]
+		for _, c := range e.check(rule, now) {
+			candidates[fingerprint(rule.Name, c.labels)] = c
+		}
+	}
+
+	for fp, c := range candidates {
+		alert, ok := e.alerts[fp]
+		if !ok {
+			alert = &Alert{
+				Fingerprint: fp,
+				Rule:        c.rule.Name,
+				Type:        c.rule.Type,
+				Severity:    c.rule.Severity,
+				State:       StatePending,
+				Labels:      c.labels,
+				ActiveSince: now,
+			}
+			e.alerts[fp] = alert
+		}
+		alert.Summary = c.summary
+		alert.Value = c.value
+		alert.Silenced = e.silenced(alert, now)
+
+		if alert.State == StatePending && now.Sub(alert.ActiveSince) >= c.rule.For.Duration {
+			firedAt := now
+			alert.State = StateFiring
+			alert.FiredAt = &firedAt
+			e.publish(alert)
+		}
+		if alert.State == StateFiring && !alert.Silenced &&
+			(alert.lastNotified.IsZero() || now.Sub(alert.lastNotified) >= e.cfg.RepeatInterval.Duration) {
+			alert.lastNotified = now
+			e.notify(alert)
+		}
+	}
+
+	for fp, alert := range e.alerts {
+		if _, ok := candidates[fp]; ok {
+			continue
+		}
+		delete(e.alerts, fp)
+		if alert.State != StateFiring {
+			continue
+		}
+
+		resolvedAt := now
+		alert.State = StateResolved
+		alert.ResolvedAt = &resolvedAt
+		e.publish(alert)
+		// Only report resolution for alerts whose firing was reported
+		if !alert.lastNotified.IsZero() {
+			e.notify(alert)
+		}
+	}
+}
+
+// check returns the objects for which the rule's condition holds
+func (e *Engine) check(rule *Rule, now time.Time) []candidate {
+	var found []candidate
+	inScope := func(namespace string) bool {
+		return rule.Namespace == "" || rule.Namespace == namespace
+	}
+
+	switch rule.Type {
+	case RulePodRestarts:
+		for _, pod := range e.pods {
+			if !inScope(pod.namespace) || len(pod.restarts) == 0 {
+				continue
+			}
+			increase := int(pod.restarts[len(pod.restarts)-1].count - restartBaseline(pod.restarts, now.Add(-rule.Window.Duration)))
+			if increase > rule.Threshold {
+				found = append(found, candidate{
+					rule:    rule,
+					labels:  map[string]string{"namespace": pod.namespace, "pod": pod.name},
+					summary: fmt.Sprintf("Pod %s/%s restarted %d times in %s", pod.namespace, pod.name, increase, rule.Window.Duration),
+					value:   increase,
+				})
+			}
+		}
+
+	case RuleNodeNotReady:
+		for name, ready := range e.nodes {
+			if !ready {
+				found = append(found, candidate{
+					rule:    rule,
+					labels:  map[string]string{"node": name},
+					summary: fmt.Sprintf("Node %s is NotReady", name),
+				})
+			}
+		}
+
+	case RuleDeploymentUnavailable:
+		for _, deployment := range e.deployments {
+			if !inScope(deployment.namespace) || deployment.ready >= deployment.desired {
+				continue
+			}
+			found = append(found, candidate{
+				rule:    rule,
+				labels:  map[string]string{"namespace": deployment.namespace, "deployment": deployment.name},
+				summary: fmt.Sprintf("Deployment %s/%s has %d/%d ready replicas", deployment.namespace, deployment.name, deployment.ready, deployment.desired),
+				value:   int(deployment.desired - deployment.ready),
+			})
+		}
+
+	case RuleNamespacePodCount:
+		counts := make(map[string]int)
+		for _, pod := range e.pods {
+			if inScope(pod.namespace) && !pod.terminal {
+				counts[pod.namespace]++
+			}
+		}
+		for namespace, count := range counts {
+			if count > rule.Threshold {
+				found = append(found, candidate{
+					rule:    rule,
+					labels:  map[string]string{"namespace": namespace},
+					summary: fmt.Sprintf("Namespace %s has %d pods (limit %d)", namespace, count, rule.Threshold),
+					value:   count,
+				})
+			}
+		}
+	}
+	return found
+}
+
+// restartBaseline is the restart count at since, or the earliest known
+// count if the pod was first seen later
+func restartBaseline(samples []restartSample, since time.Time) int32 {
+	baseline := samples[0].count
+	for _, sample := range samples {
+		if sample.time.After(since) {
+			break
+		}
+		baseline = sample.count
+	}
+	return baseline
+}
+
+// pruneRestarts drops samples no restart rule can look back to, keeping
+// the last one before the longest window as the baseline
+func (e *Engine) pruneRestarts(now time.Time) {
+	var window time.Duration
+	for _, rule := range e.cfg.Rules {
+		if rule.Type == RulePodRestarts && rule.Window.Duration > window {
+			window = rule.Window.Duration
+		}
+	}
+	since := now.Add(-window)
+
+	for _, pod := range e.pods {
+		keep := 0
+		for keep+1 < len(pod.restarts) && !pod.restarts[keep+1].time.After(since) {
+			keep++
+		}
+		pod.restarts = pod.restarts[keep:]
+	}
+}
+
+// pruneSilences drops silences that have expired. Callers hold e.mu.
+func (e *Engine) pruneSilences(now time.Time) {
+	kept := e.silences[:0]
+	for _, silence := range e.silences {
+		if silence.EndsAt.IsZero() || now.Before(silence.EndsAt) {
+			kept = append(kept, silence)
+		}
+	}
+	e.silences = kept
+}
+
+func (e *Engine) silenced(alert *Alert, now time.Time) bool {
+	for _, silence := range e.silences {
+		if silence.matches(alert, now) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Engine) notify(alert *Alert) {
+	if e.notifier != nil {
+		e.notifier.Notify(*alert)
+	}
+}
+
+// publish sends a copy of the alert to subscribers without blocking
+func (e *Engine) publish(alert *Alert) {
+	for _, ch := range e.subscribers {
+		select {
+		case ch <- *alert:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel receiving alerts as they fire or resolve and
+// a function to unsubscribe
+func (e *Engine) Subscribe() (<-chan Alert, func()) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.subSeq++
+	id := e.subSeq
+	ch := make(chan Alert, 64)
+	e.subscribers[id] = ch
+
+	return ch, func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		delete(e.subscribers, id)
+	}
+}
+
+// Active returns pending and firing alerts, firing first
+func (e *Engine) Active() []Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	active := make([]Alert, 0, len(e.alerts))
+	for _, alert := range e.alerts {
+		active = append(active, *alert)
+	}
+	sort.Slice(active, func(i, j int) bool {
+		if active[i].State != active[j].State {
+			return active[i].State == StateFiring
+		}
+		if active[i].Rule != active[j].Rule {
+			return active[i].Rule < active[j].Rule
+		}
+		return active[i].Fingerprint < active[j].Fingerprint
+	})
+	return active
+}
+
+// Rules returns the configured rules
+func (e *Engine) Rules() []Rule {
+	return e.cfg.Rules
+}
+
+// Silences returns silences that have not expired
+func (e *Engine) Silences() []Silence {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.now()
+	silences := make([]Silence, 0, len(e.silences))
+	for _, silence := range e.silences {
+		if silence.EndsAt.IsZero() || now.Before(silence.EndsAt) {
+			silences = append(silences, silence)
+		}
+	}
+	return silences
+}
+
+// AddSilence stores a silence and applies it to current alerts
+func (e *Engine) AddSilence(silence Silence) (Silence, error) {
+	if silence.Rule == "" && len(silence.Matchers) == 0 {
+		return Silence{}, errors.New("silence needs a rule or matchers")
+	}
+	if !silence.EndsAt.IsZero() && !e.now().Before(silence.EndsAt) {
+		return Silence{}, errors.New("silence ends_at is in the past")
+	}
+
+	e.mu.Lock()
+	e.silenceSeq++
+	silence.ID = fmt.Sprintf("silence-%d", e.silenceSeq)
+	e.silences = append(e.silences, silence)
+	e.mu.Unlock()
+
+	e.markDirty()
+	return silence, nil
+}
+
+// DeleteSilence removes a silence by ID and returns it
+func (e *Engine) DeleteSilence(id string) (Silence, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for i, silence := range e.silences {
+		if silence.ID == id {
+			e.silences = append(e.silences[:i], e.silences[i+1:]...)
+			e.markDirty()
+			return silence, nil
+		}
+	}
+	return Silence{}, ErrSilenceNotFound
+}
+
+// fingerprint identifies an alert by rule and labels
+func fingerprint(rule string, labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, key+"="+labels[key])
+	}
+	return rule + "{" + strings.Join(parts, ",") + "}"
+}
@@ -0,0 +1,295 @@
+package alerts
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestEngine(t *testing.T, rules ...Rule) (*Engine, *time.Time) {
+	t.Helper()
+	cfg := &Config{Rules: rules}
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	e := NewEngine(informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0), cfg)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	e.now = func() time.Time { return now }
+	return e, &now
+}
+
+func restartingPod(restarts int32) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Status: corev1.PodStatus{
+			Phase:             corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "app", RestartCount: restarts}},
+		},
+	}
+}
+
+func notReadyNode(ready bool) *corev1.Node {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status:     corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: status}}},
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	data := `
+rules:
+  - name: node-down
+    type: node_not_ready
+    for: 2m
+    severity: critical
+  - name: crashloop
+    type: pod_restarts
+    threshold: 3
+    window: 10m
+webhooks:
+  - url: http://example.invalid/hook
+`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if len(cfg.Rules) != 2 || cfg.Rules[0].For.Duration != 2*time.Minute {
+		t.Errorf("unexpected rules: %+v", cfg.Rules)
+	}
+	if cfg.Rules[1].Severity != "warning" {
+		t.Errorf("expected default severity warning, got %q", cfg.Rules[1].Severity)
+	}
+	if cfg.RepeatInterval.Duration != 4*time.Hour {
+		t.Errorf("expected default repeat interval, got %s", cfg.RepeatInterval.Duration)
+	}
+
+	bad := &Config{Rules: []Rule{{Name: "x", Type: RulePodRestarts}}}
+	if err := bad.validate(); err == nil {
+		t.Error("expected error for pod_restarts rule without threshold")
+	}
+	bad = &Config{Rules: []Rule{{Name: "x", Type: "cpu"}}}
+	if err := bad.validate(); err == nil {
+		t.Error("expected error for unknown rule type")
+	}
+}
+
+func TestPodRestartsWithinWindow(t *testing.T) {
+	e, now := newTestEngine(t, Rule{Name: "crashloop", Type: RulePodRestarts, Threshold: 3, Window: metav1.Duration{Duration: 10 * time.Minute}})
+
+	e.onPod(restartingPod(1))
+	*now = now.Add(5 * time.Minute)
+	e.onPod(restartingPod(4))
+	e.evaluate(*now)
+	if active := e.Active(); len(active) != 0 {
+		t.Fatalf("3 restarts should not exceed threshold 3, got %+v", active)
+	}
+
+	*now = now.Add(time.Minute)
+	e.onPod(restartingPod(5))
+	e.evaluate(*now)
+	active := e.Active()
+	if len(active) != 1 || active[0].State != StateFiring || active[0].Value != 4 {
+		t.Fatalf("expected firing alert with 4 restarts, got %+v", active)
+	}
+	if active[0].Labels["pod"] != "web" {
+		t.Errorf("unexpected labels %v", active[0].Labels)
+	}
+
+	// Once the restarts age out of the window the alert resolves
+	*now = now.Add(20 * time.Minute)
+	e.evaluate(*now)
+	if active := e.Active(); len(active) != 0 {
+		t.Fatalf("expected alert to resolve, got %+v", active)
+	}
+}
+
+func TestNodeNotReadyPendingThenFiringThenResolved(t *testing.T) {
+	e, now := newTestEngine(t, Rule{Name: "node-down", Type: RuleNodeNotReady, For: metav1.Duration{Duration: 2 * time.Minute}})
+	updates, unsubscribe := e.Subscribe()
+	defer unsubscribe()
+
+	e.onNode(notReadyNode(false))
+	e.evaluate(*now)
+	if active := e.Active(); len(active) != 1 || active[0].State != StatePending {
+		t.Fatalf("expected pending alert, got %+v", active)
+	}
+
+	*now = now.Add(2 * time.Minute)
+	e.evaluate(*now)
+	if active := e.Active(); len(active) != 1 || active[0].State != StateFiring {
+		t.Fatalf("expected firing alert, got %+v", active)
+	}
+	if alert := <-updates; alert.State != StateFiring {
+		t.Errorf("expected firing update, got %s", alert.State)
+	}
+
+	e.onNode(notReadyNode(true))
+	*now = now.Add(time.Minute)
+	e.evaluate(*now)
+	if active := e.Active(); len(active) != 0 {
+		t.Fatalf("expected no active alerts, got %+v", active)
+	}
+	alert := <-updates
+	if alert.State != StateResolved || alert.ResolvedAt == nil {
+		t.Errorf("expected resolved update, got %+v", alert)
+	}
+}
+
+func TestDeploymentUnavailableAndNamespacePodCount(t *testing.T) {
+	e, now := newTestEngine(t,
+		Rule{Name: "deploy-unavailable", Type: RuleDeploymentUnavailable},
+		Rule{Name: "too-many-pods", Type: RuleNamespacePodCount, Threshold: 1, Namespace: "default"},
+	)
+
+	replicas := int32(3)
+	e.onDeployment(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		Status:     appsv1.DeploymentStatus{ReadyReplicas: 1},
+	})
+	e.onPod(restartingPod(0))
+	other := restartingPod(0)
+	other.Name = "worker"
+	e.onPod(other)
+	elsewhere := restartingPod(0)
+	elsewhere.Namespace = "kube-system"
+	e.onPod(elsewhere)
+
+	e.evaluate(*now)
+	active := e.Active()
+	if len(active) != 2 {
+		t.Fatalf("expected 2 alerts, got %+v", active)
+	}
+	for _, alert := range active {
+		switch alert.Rule {
+		case "deploy-unavailable":
+			if alert.Value != 2 || !strings.Contains(alert.Summary, "1/3") {
+				t.Errorf("unexpected deployment alert %+v", alert)
+			}
+		case "too-many-pods":
+			if alert.Value != 2 || alert.Labels["namespace"] != "default" {
+				t.Errorf("unexpected namespace alert %+v", alert)
+			}
+		}
+	}
+}
+
+func TestWebhookDedupAndSilence(t *testing.T) {
+	received := make(chan payload, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p payload
+		json.NewDecoder(r.Body).Decode(&p)
+		received <- p
+	}))
+	defer server.Close()
+
+	e, now := newTestEngine(t, Rule{Name: "node-down", Type: RuleNodeNotReady, Severity: "critical"})
+	e.cfg.Webhooks = []WebhookConfig{{URL: server.URL, SendResolved: true}}
+	e.notifier = NewNotifier(e.cfg.Webhooks)
+	go e.notifier.Run()
+	defer e.notifier.Close()
+
+	e.onNode(notReadyNode(false))
+	e.evaluate(*now)
+	select {
+	case p := <-received:
+		if !strings.Contains(p.Text, "[FIRING] node-down (critical)") || p.Status != StateFiring {
+			t.Errorf("unexpected payload %+v", p)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for firing notification")
+	}
+
+	// Still firing within the repeat interval: no duplicate
+	*now = now.Add(time.Minute)
+	e.evaluate(*now)
+
+	// Silenced alerts are not re-notified after the repeat interval
+	if _, err := e.AddSilence(Silence{Matchers: map[string]string{"node": "node-1"}}); err != nil {
+		t.Fatalf("AddSilence: %v", err)
+	}
+	*now = now.Add(5 * time.Hour)
+	e.evaluate(*now)
+	if active := e.Active(); len(active) != 1 || !active[0].Silenced {
+		t.Fatalf("expected silenced alert, got %+v", active)
+	}
+
+	e.onNode(notReadyNode(true))
+	e.evaluate(*now)
+	select {
+	case p := <-received:
+		if p.Status != StateResolved {
+			t.Errorf("expected resolved notification, got %+v", p)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for resolved notification")
+	}
+	select {
+	case p := <-received:
+		t.Errorf("unexpected extra notification %+v", p)
+	default:
+	}
+}
+
+func TestDeleteSilence(t *testing.T) {
+	e, _ := newTestEngine(t)
+	if _, err := e.AddSilence(Silence{}); err == nil {
+		t.Error("expected error for silence without rule or matchers")
+	}
+	silence, err := e.AddSilence(Silence{Rule: "node-down"})
+	if err != nil {
+		t.Fatalf("AddSilence: %v", err)
+	}
+	if len(e.Silences()) != 1 {
+		t.Fatalf("expected one silence")
+	}
+	removed, err := e.DeleteSilence(silence.ID)
+	if err != nil {
+		t.Fatalf("DeleteSilence: %v", err)
+	}
+	if removed.ID != silence.ID || removed.Rule != "node-down" {
+		t.Errorf("expected the removed silence, got %+v", removed)
+	}
+	if _, err := e.DeleteSilence(silence.ID); err != ErrSilenceNotFound {
+		t.Errorf("expected ErrSilenceNotFound, got %v", err)
+	}
+}
+
+func TestSilenceExpiry(t *testing.T) {
+	e, now := newTestEngine(t)
+	if _, err := e.AddSilence(Silence{Rule: "node-down", EndsAt: now.Add(-time.Minute)}); err == nil {
+		t.Error("expected error for silence ending in the past")
+	}
+	if _, err := e.AddSilence(Silence{Rule: "node-down", EndsAt: now.Add(time.Hour)}); err != nil {
+		t.Fatalf("AddSilence: %v", err)
+	}
+	if _, err := e.AddSilence(Silence{Rule: "pod-restarts"}); err != nil {
+		t.Fatalf("AddSilence: %v", err)
+	}
+
+	*now = now.Add(2 * time.Hour)
+	e.evaluate(*now)
+	if len(e.silences) != 1 || e.silences[0].Rule != "pod-restarts" {
+		t.Fatalf("expected only the open-ended silence to remain, got %+v", e.silences)
+	}
+}
@@ -0,0 +1,91 @@
+// internal/alerts/notifier.go
+package alerts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// payload is Slack-compatible: Slack reads "text" and ignores the rest,
+// generic receivers can use "status" and "alert"
+type payload struct {
+	Text   string `json:"text"`
+	Status string `json:"status"`
+	Alert  Alert  `json:"alert"`
+}
+
+// Notifier posts alert transitions to webhooks from a background worker
+type Notifier struct {
+	webhooks []WebhookConfig
+	client   *http.Client
+	queue    chan Alert
+}
+
+// NewNotifier creates a notifier. Call Run to start delivering.
+func NewNotifier(webhooks []WebhookConfig) *Notifier {
+	return &Notifier{
+		webhooks: webhooks,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		queue:    make(chan Alert, 100),
+	}
+}
+
+// Notify queues an alert without blocking; it is dropped if the queue is full
+func (n *Notifier) Notify(alert Alert) {
+	select {
+	case n.queue <- alert:
+	default:
+		log.Printf("Dropped alert notification for %s: queue full", alert.Rule)
+	}
+}
+
+// Run delivers queued notifications until the queue is closed
+func (n *Notifier) Run() {
+	for alert := range n.queue {
+		for _, webhook := range n.webhooks {
+			if alert.State == StateResolved && !webhook.SendResolved {
+				continue
+			}
+			if err := n.post(webhook.URL, alert); err != nil {
+				log.Printf("Failed to send alert %s: %v", alert.Rule, err)
+			}
+		}
+	}
+}
+
+// Close stops Run once the queue drains
+func (n *Notifier) Close() {
+	close(n.queue)
+}
+
+func (n *Notifier) post(url string, alert Alert) error {
+	body, err := json.Marshal(payload{Text: formatText(alert), Status: alert.State, Alert: alert})
+	if err != nil {
+		return err
+	}
+	resp, err := n.client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// formatText renders a one-line message such as
+// "[FIRING] node-not-ready (critical): Node node-1 is NotReady"
+func formatText(alert Alert) string {
+	icon := ":rotating_light:"
+	if alert.State == StateResolved {
+		icon = ":white_check_mark:"
+	}
+	return fmt.Sprintf("%s [%s] %s (%s): %s", icon, strings.ToUpper(alert.State), alert.Rule, alert.Severity, alert.Summary)
+}
@@ -0,0 +1,111 @@
+// internal/audit/diff.go
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// changesKey is the gin context key holding the changes of a write action
+const changesKey = "audit_changes"
+
+// Change is a single field difference between the before and after object
+type Change struct {
+	Path   string      `json:"path"`
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// RecordChange attaches the before/after diff of a write action to the
+// request so that the audit middleware stores it with the event.
+// Either object may be nil for creations and deletions.
+func RecordChange(c *gin.Context, before, after interface{}) error {
+	changes, err := Diff(before, after)
+	if err != nil {
+		return err
+	}
+	c.Set(changesKey, changes)
+	return nil
+}
+
+// Diff compares two objects by their JSON representation and returns the
+// changed leaf paths in sorted order
+func Diff(before, after interface{}) ([]Change, error) {
+	beforeMap, err := flatten(before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to flatten before object: %w", err)
+	}
+	afterMap, err := flatten(after)
+	if err != nil {
+		return nil, fmt.Errorf("failed to flatten after object: %w", err)
+	}
+
+	paths := make(map[string]bool)
+	for path := range beforeMap {
+		paths[path] = true
+	}
+	for path := range afterMap {
+		paths[path] = true
+	}
+
+	changes := make([]Change, 0)
+	for path := range paths {
+		b, inBefore := beforeMap[path]
+		a, inAfter := afterMap[path]
+		if inBefore && inAfter && reflect.DeepEqual(a, b) {
+			continue
+		}
+		changes = append(changes, Change{Path: path, Before: b, After: a})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// flatten converts an object into a map of JSON paths to leaf values
+func flatten(obj interface{}) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	if obj == nil {
+		return result, nil
+	}
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+
+	var walk func(prefix string, value interface{})
+	walk = func(prefix string, value interface{}) {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			for key, child := range v {
+				walk(prefix+"/"+key, child)
+			}
+		case []interface{}:
+			for i, child := range v {
+				walk(fmt.Sprintf("%s/%d", prefix, i), child)
+			}
+		default:
+			result[prefix] = v
+		}
+	}
+	walk("", generic)
+	return result, nil
+}
+
+// changesFrom returns the changes recorded on the request, if any
+func changesFrom(c *gin.Context) []Change {
+	value, ok := c.Get(changesKey)
+	if !ok {
+		return nil
+	}
+	changes, _ := value.([]Change)
+	return changes
+}
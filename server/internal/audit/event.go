@@ -0,0 +1,72 @@
+// internal/audit/event.go
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/mugayoshi/k8s-visualizer/server/internal/models"
+)
+
+// Event is a single audit record. Every event carries the hash of the
+// previous one, so removing or editing a record breaks the chain.
+type Event struct {
+	Time      time.Time        `json:"time"`
+	Identity  *models.Identity `json:"identity,omitempty"`
+	ClientIP  string           `json:"client_ip"`
+	Method    string           `json:"method"`
+	Path      string           `json:"path"`
+	Route     string           `json:"route,omitempty"`
+	Cluster   string           `json:"cluster,omitempty"`
+	Namespace string           `json:"namespace,omitempty"`
+	Resource  string           `json:"resource,omitempty"`
+	Name      string           `json:"name,omitempty"`
+	Status    int              `json:"status"`
+	Outcome   string           `json:"outcome"` // "success", "denied", "error" or "gap"
+	LatencyMS float64          `json:"latency_ms"`
+	Error     string           `json:"error,omitempty"`
+	Changes   []Change         `json:"changes,omitempty"`
+	Dropped   int              `json:"dropped,omitempty"` // events lost before a gap event
+	PrevHash  string           `json:"prev_hash"`
+	Hash      string           `json:"hash"`
+}
+
+// computeHash hashes the event with its Hash field cleared
+func (e Event) computeHash() (string, error) {
+	e.Hash = ""
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Verify checks that events form an unbroken hash chain. It returns the
+// index of the first event that does not match, or -1 if the chain is intact.
+func Verify(events []Event) int {
+	for i, e := range events {
+		hash, err := e.computeHash()
+		if err != nil || hash != e.Hash {
+			return i
+		}
+		if i > 0 && e.PrevHash != events[i-1].Hash {
+			return i
+		}
+	}
+	return -1
+}
+
+// outcomeFor classifies an HTTP status code
+func outcomeFor(status int) string {
+	switch {
+	case status == 401 || status == 403 || status == 429:
+		return "denied"
+	case status >= 400:
+		return "error"
+	default:
+		return "success"
+	}
+}
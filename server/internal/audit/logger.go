@@ -0,0 +1,252 @@
+// internal/audit/logger.go
+package audit
+
+import (
+	"encoding/json"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/models"
+)
+
+// Logger chains audit events together, writes them to a sink and keeps the
+// most recent ones in memory for queries
+type Logger struct {
+	sink    Sink
+	cluster string
+
+	mu       sync.Mutex
+	prevHash string
+	recent   []Event
+	next     int
+	full     bool
+
+	lines   chan []byte
+	done    chan struct{}
+	dropped atomic.Uint64
+	gap     int // events dropped since the last queued line
+}
+
+// NewLogger creates a Logger that writes to sink and retains up to
+// retain events in memory. cluster is stamped on every event.
+func NewLogger(sink Sink, cluster string, retain int) *Logger {
+	if retain <= 0 {
+		retain = 1000
+	}
+	l := &Logger{
+		sink:    sink,
+		cluster: cluster,
+		recent:  make([]Event, retain),
+		lines:   make(chan []byte, 1024),
+		done:    make(chan struct{}),
+	}
+
+	// Continue the chain of an existing audit file
+	if chained, ok := sink.(interface{ LastHash() string }); ok {
+		l.prevHash = chained.LastHash()
+	}
+
+	go l.run()
+	return l
+}
+
+// run writes lines to the sink in order
+func (l *Logger) run() {
+	defer close(l.done)
+	for line := range l.lines {
+		if err := l.sink.Write(line); err != nil {
+			log.Printf("Failed to write audit event: %v", err)
+		}
+	}
+}
+
+// Record completes the event's chain fields and stores it. The event is
+// dropped rather than blocking the request when the sink falls behind.
+// Dropped events never join the chain; the next queued event is preceded
+// by a chained gap event carrying the count, so losses under load cannot
+// be mistaken for deleted records.
+func (l *Logger) Record(event Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Only Record sends, so under the lock the free space can only grow
+	need := 1
+	if l.gap > 0 {
+		need = 2
+	}
+	if cap(l.lines)-len(l.lines) < need {
+		l.gap++
+		if n := l.dropped.Add(1); n == 1 || n%1000 == 0 {
+			log.Printf("Audit sink is falling behind, %d events dropped", n)
+		}
+		return
+	}
+
+	if l.gap > 0 && !l.chainGap() {
+		return
+	}
+	l.chain(event)
+}
+
+// chainGap chains a gap event for the events dropped since the last queued
+// line. Caller must hold l.mu.
+func (l *Logger) chainGap() bool {
+	if !l.chain(Event{Time: time.Now(), Outcome: "gap", Dropped: l.gap}) {
+		return false
+	}
+	l.gap = 0
+	return true
+}
+
+// chain links event to the previous one, retains it and queues its line.
+// The chain only advances once the line is ready to queue. Caller must
+// hold l.mu; the send blocks unless the caller checked for room.
+func (l *Logger) chain(event Event) bool {
+	event.Cluster = l.cluster
+	event.PrevHash = l.prevHash
+	hash, err := event.computeHash()
+	if err != nil {
+		log.Printf("Failed to hash audit event: %v", err)
+		return false
+	}
+	event.Hash = hash
+	line, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Failed to marshal audit event: %v", err)
+		return false
+	}
+
+	l.prevHash = hash
+	l.recent[l.next] = event
+	l.next = (l.next + 1) % len(l.recent)
+	if l.next == 0 {
+		l.full = true
+	}
+	l.lines <- line
+	return true
+}
+
+// Dropped returns how many events were not recorded because the sink's
+// queue was full
+func (l *Logger) Dropped() uint64 {
+	return l.dropped.Load()
+}
+
+// RecordRequest builds an event from a finished gin request
+func (l *Logger) RecordRequest(c *gin.Context, identity *models.Identity, start time.Time) {
+	status := c.Writer.Status()
+	l.Record(Event{
+		Time:      start,
+		Identity:  identity,
+		ClientIP:  c.ClientIP(),
+		Method:    c.Request.Method,
+		Path:      c.Request.URL.Path,
+		Route:     c.FullPath(),
+		Namespace: namespaceOf(c),
+		Resource:  resourceOf(c.FullPath()),
+		Name:      c.Param("name"),
+		Status:    status,
+		Outcome:   outcomeFor(status),
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+		Error:     c.Errors.ByType(gin.ErrorTypePrivate).String(),
+		Changes:   changesFrom(c),
+	})
+}
+
+// Filter selects events in Query. Zero values match everything.
+type Filter struct {
+	Identity  string
+	Namespace string
+	Resource  string
+	Method    string
+	Outcome   string
+	Since     time.Time
+	Limit     int
+}
+
+func (f Filter) matches(e Event) bool {
+	if f.Identity != "" && (e.Identity == nil || e.Identity.Name != f.Identity) {
+		return false
+	}
+	if f.Namespace != "" && e.Namespace != f.Namespace {
+		return false
+	}
+	if f.Resource != "" && e.Resource != f.Resource {
+		return false
+	}
+	if f.Method != "" && !strings.EqualFold(e.Method, f.Method) {
+		return false
+	}
+	if f.Outcome != "" && e.Outcome != f.Outcome {
+		return false
+	}
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	return true
+}
+
+// Query returns retained events matching the filter, newest first, and
+// whether the retained chain is intact
+func (l *Logger) Query(filter Filter) ([]Event, bool) {
+	l.mu.Lock()
+	ordered := l.ordered()
+	l.mu.Unlock()
+
+	intact := Verify(ordered) == -1
+
+	result := make([]Event, 0)
+	for i := len(ordered) - 1; i >= 0; i-- {
+		if !filter.matches(ordered[i]) {
+			continue
+		}
+		result = append(result, ordered[i])
+		if filter.Limit > 0 && len(result) >= filter.Limit {
+			break
+		}
+	}
+	return result, intact
+}
+
+// ordered returns retained events oldest first. Caller must hold l.mu.
+func (l *Logger) ordered() []Event {
+	if !l.full {
+		return append([]Event(nil), l.recent[:l.next]...)
+	}
+	return append(append([]Event(nil), l.recent[l.next:]...), l.recent[:l.next]...)
+}
+
+// Close records any trailing drops, flushes pending events and closes the
+// sink
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	if l.gap > 0 {
+		l.chainGap()
+	}
+	close(l.lines)
+	l.mu.Unlock()
+	<-l.done
+	return l.sink.Close()
+}
+
+// namespaceOf reads the namespace from the route or query string
+func namespaceOf(c *gin.Context) string {
+	if ns := c.Param("namespace"); ns != "" {
+		return ns
+	}
+	return c.Query("namespace")
+}
+
+// resourceOf derives the resource name from a route such as
+// "/api/pods/:namespace/:name" -> "pods"
+func resourceOf(route string) string {
+	parts := strings.Split(strings.TrimPrefix(route, "/api/"), "/")
+	if len(parts) == 0 || strings.HasPrefix(parts[0], ":") {
+		return ""
+	}
+	return parts[0]
+}
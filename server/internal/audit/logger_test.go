@@ -0,0 +1,185 @@
+package audit
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memorySink collects lines in memory
+type memorySink struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (m *memorySink) Write(line []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lines = append(m.lines, string(line))
+	return nil
+}
+
+func (m *memorySink) Close() error { return nil }
+
+func TestLogger_ChainDetectsTampering(t *testing.T) {
+	sink := &memorySink{}
+	l := NewLogger(sink, "test", 10)
+	l.Record(Event{Method: "GET", Path: "/api/pods", Status: 200})
+	l.Record(Event{Method: "DELETE", Path: "/api/pods/default/web", Status: 200})
+	l.Record(Event{Method: "GET", Path: "/api/nodes", Status: 500})
+	if err := l.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	if len(sink.lines) != 3 {
+		t.Fatalf("expected 3 lines written, got %d", len(sink.lines))
+	}
+
+	events, intact := l.Query(Filter{})
+	if !intact {
+		t.Fatalf("expected untouched chain to be intact")
+	}
+	if len(events) != 3 || events[0].Path != "/api/nodes" {
+		t.Fatalf("expected newest event first, got %+v", events)
+	}
+
+	// Reverse to oldest-first and alter the middle record
+	chain := []Event{events[2], events[1], events[0]}
+	chain[1].Status = 403
+	if got := Verify(chain); got != 1 {
+		t.Fatalf("expected tampering detected at index 1, got %d", got)
+	}
+}
+
+// blockingSink blocks every write until release is closed, then passes
+// lines to collect
+type blockingSink struct {
+	release chan struct{}
+	collect *memorySink
+}
+
+func (b *blockingSink) Write(line []byte) error {
+	<-b.release
+	return b.collect.Write(line)
+}
+
+func (b *blockingSink) Close() error { return nil }
+
+func TestLogger_DropsWhenSinkBlocks(t *testing.T) {
+	collected := &memorySink{}
+	sink := &blockingSink{release: make(chan struct{}), collect: collected}
+	l := NewLogger(sink, "test", 10)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 2000; i++ {
+			l.Record(Event{Method: "GET", Path: "/api/pods", Status: 200})
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Record blocked on a stalled sink")
+	}
+
+	if l.Dropped() == 0 {
+		t.Error("expected dropped events")
+	}
+	if events, intact := l.Query(Filter{}); len(events) != 10 || !intact {
+		t.Errorf("expected retained events to stay intact, got %d, %v", len(events), intact)
+	}
+	close(sink.release)
+	l.Close()
+
+	// Drops are recorded as a chained gap event rather than a hole
+	events := make([]Event, 0, len(collected.lines))
+	for _, line := range collected.lines {
+		var event Event
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("invalid line %q: %v", line, err)
+		}
+		events = append(events, event)
+	}
+	if got := Verify(events); got != -1 {
+		t.Fatalf("expected the written chain to be intact, broken at %d", got)
+	}
+	if gap := events[len(events)-1]; gap.Outcome != "gap" || uint64(gap.Dropped) != l.Dropped() {
+		t.Errorf("expected a trailing gap event, got %+v", gap)
+	}
+	if len(events) != 2000-int(l.Dropped())+1 {
+		t.Errorf("expected every queued event written, got %d with %d dropped", len(events), l.Dropped())
+	}
+}
+
+func TestLogger_QueryFilters(t *testing.T) {
+	l := NewLogger(&memorySink{}, "test", 10)
+	defer l.Close()
+
+	l.Record(Event{Method: "GET", Resource: "pods", Namespace: "default", Status: 200, Outcome: "success"})
+	l.Record(Event{Method: "GET", Resource: "nodes", Status: 500, Outcome: "error"})
+	l.Record(Event{Method: "GET", Resource: "pods", Namespace: "kube-system", Status: 200, Outcome: "success"})
+
+	events, _ := l.Query(Filter{Resource: "pods", Namespace: "kube-system"})
+	if len(events) != 1 || events[0].Namespace != "kube-system" {
+		t.Fatalf("unexpected filtered events: %+v", events)
+	}
+
+	events, _ = l.Query(Filter{Outcome: "error"})
+	if len(events) != 1 || events[0].Resource != "nodes" {
+		t.Fatalf("unexpected error events: %+v", events)
+	}
+}
+
+func TestDiff_ReportsChangedPaths(t *testing.T) {
+	before := map[string]interface{}{"spec": map[string]interface{}{"replicas": 2, "image": "nginx:1.25"}}
+	after := map[string]interface{}{"spec": map[string]interface{}{"replicas": 3, "image": "nginx:1.25"}}
+
+	changes, err := Diff(before, after)
+	if err != nil {
+		t.Fatalf("diff failed: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Path != "/spec/replicas" {
+		t.Fatalf("unexpected changes: %+v", changes)
+	}
+	if changes[0].Before.(float64) != 2 || changes[0].After.(float64) != 3 {
+		t.Fatalf("unexpected values: %+v", changes[0])
+	}
+}
+
+func TestFileSink_RotatesAndResumesChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := NewFileSink(path, 200, 2)
+	if err != nil {
+		t.Fatalf("NewFileSink failed: %v", err)
+	}
+
+	l := NewLogger(sink, "test", 10)
+	for i := 0; i < 5; i++ {
+		l.Record(Event{Method: "GET", Path: "/api/pods", Status: 200})
+	}
+	l.Close()
+
+	if _, err := os.Stat(path + ".1"); err != nil {
+		t.Fatalf("expected rotated file: %v", err)
+	}
+
+	events, _ := l.Query(Filter{})
+	reopened, err := NewFileSink(path, 200, 2)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer reopened.Close()
+	if got := reopened.LastHash(); got != events[0].Hash {
+		t.Fatalf("expected last hash %s, got %s", events[0].Hash, got)
+	}
+
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), events[0].Hash) {
+		t.Fatalf("expected newest event in current file")
+	}
+}
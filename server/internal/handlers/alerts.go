@@ -0,0 +1,73 @@
+// internal/handlers/alerts.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/alerts"
+)
+
+type AlertsHandler struct {
+	engine *alerts.Engine
+}
+
+func NewAlertsHandler(engine *alerts.Engine) *AlertsHandler {
+	return &AlertsHandler{engine: engine}
+}
+
+// ListAlerts returns pending and firing alerts
+func (h *AlertsHandler) ListAlerts(c *gin.Context) {
+	active := h.engine.Active()
+	c.JSON(http.StatusOK, gin.H{
+		"alerts": active,
+		"count":  len(active),
+	})
+}
+
+// ListRules returns the configured alerting rules
+func (h *AlertsHandler) ListRules(c *gin.Context) {
+	rules := h.engine.Rules()
+	c.JSON(http.StatusOK, gin.H{
+		"rules": rules,
+		"count": len(rules),
+	})
+}
+
+// ListSilences returns silences that have not expired
+func (h *AlertsHandler) ListSilences(c *gin.Context) {
+	silences := h.engine.Silences()
+	c.JSON(http.StatusOK, gin.H{
+		"silences": silences,
+		"count":    len(silences),
+	})
+}
+
+// CreateSilence adds a silence from the request body
+func (h *AlertsHandler) CreateSilence(c *gin.Context) {
+	var silence alerts.Silence
+	if err := c.ShouldBindJSON(&silence); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := h.engine.AddSilence(silence)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	recordChange(c, nil, created)
+	c.JSON(http.StatusCreated, created)
+}
+
+// DeleteSilence removes a silence by ID
+func (h *AlertsHandler) DeleteSilence(c *gin.Context) {
+	silence, err := h.engine.DeleteSilence(c.Param("id"))
+	if errors.Is(err, alerts.ErrSilenceNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	recordChange(c, silence, nil)
+	c.Status(http.StatusNoContent)
+}
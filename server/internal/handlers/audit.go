@@ -0,0 +1,88 @@
+// internal/handlers/audit.go
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/audit"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/middleware"
+)
+
+type AuditHandler struct {
+	logger      *audit.Logger
+	adminGroups map[string]bool
+}
+
+func NewAuditHandler(logger *audit.Logger, adminGroups []string) *AuditHandler {
+	groups := make(map[string]bool, len(adminGroups))
+	for _, group := range adminGroups {
+		groups[group] = true
+	}
+	return &AuditHandler{logger: logger, adminGroups: groups}
+}
+
+// QueryEvents returns retained audit events. Only identities in one of the
+// admin groups may call it.
+func (h *AuditHandler) QueryEvents(c *gin.Context) {
+	if !h.isAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Audit log requires an admin identity"})
+		return
+	}
+
+	filter := audit.Filter{
+		Identity:  c.Query("identity"),
+		Namespace: c.Query("namespace"),
+		Resource:  c.Query("resource"),
+		Method:    c.Query("method"),
+		Outcome:   c.Query("outcome"),
+		Limit:     100,
+	}
+	if since := c.Query("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "since must be an RFC3339 timestamp"})
+			return
+		}
+		filter.Since = t
+	}
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+			return
+		}
+		filter.Limit = n
+	}
+
+	events, intact := h.logger.Query(filter)
+	c.JSON(http.StatusOK, gin.H{
+		"events":      events,
+		"count":       len(events),
+		"chain_valid": intact,
+	})
+}
+
+func (h *AuditHandler) isAdmin(c *gin.Context) bool {
+	identity, ok := middleware.GetIdentity(c)
+	if !ok {
+		return false
+	}
+	for _, group := range identity.Groups {
+		if h.adminGroups[group] {
+			return true
+		}
+	}
+	return false
+}
+
+// recordChange attaches a write action's before/after diff to the request's
+// audit event. Failing to diff never fails the request.
+func recordChange(c *gin.Context, before, after interface{}) {
+	if err := audit.RecordChange(c, before, after); err != nil {
+		log.Printf("Failed to record audit change for %s: %v", c.FullPath(), err)
+	}
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/alerts"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/audit"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/middleware"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/snapshots"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// discardSink drops audit lines; events are read back through Query
+type discardSink struct{}
+
+func (discardSink) Write([]byte) error { return nil }
+func (discardSink) Close() error       { return nil }
+
+func TestWriteActions_RecordAuditChanges(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cs := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}})
+	store, err := snapshots.Open(filepath.Join(t.TempDir(), "snapshots.db"), snapshots.Retention{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	logger := audit.NewLogger(discardSink{}, "test", 100)
+	defer logger.Close()
+
+	snapshotHandler := NewSnapshotHandler(&localMockK8s{cs: cs}, store)
+	alertsHandler := NewAlertsHandler(alerts.NewEngine(informers.NewSharedInformerFactory(cs, 0), &alerts.Config{}))
+	r := gin.New()
+	r.Use(middleware.LoggerWithConfig(middleware.LoggerConfig{Audit: logger}))
+	r.POST("/api/snapshots", snapshotHandler.CreateSnapshot)
+	r.DELETE("/api/snapshots/:id", snapshotHandler.DeleteSnapshot)
+	r.POST("/api/alerts/silences", alertsHandler.CreateSilence)
+	r.DELETE("/api/alerts/silences/:id", alertsHandler.DeleteSilence)
+
+	serve := func(method, url, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, url, bytes.NewBufferString(body)))
+		return w
+	}
+	changes := func(method, route string) map[string]audit.Change {
+		events, _ := logger.Query(audit.Filter{Method: method, Limit: 1})
+		if len(events) == 0 || events[0].Route != route {
+			t.Fatalf("expected %s %s audit event, got %+v", method, route, events)
+		}
+		byPath := make(map[string]audit.Change)
+		for _, change := range events[0].Changes {
+			byPath[change.Path] = change
+		}
+		return byPath
+	}
+
+	if w := serve(http.MethodPost, "/api/snapshots", ""); w.Code != http.StatusCreated {
+		t.Fatalf("create snapshot: %d %s", w.Code, w.Body.String())
+	}
+	created := changes(http.MethodPost, "/api/snapshots")
+	id, _ := created["/id"].After.(string)
+	if id == "" || created["/trigger"].After != "manual" || created["/id"].Before != nil {
+		t.Fatalf("expected snapshot creation diff, got %+v", created)
+	}
+
+	if w := serve(http.MethodDelete, "/api/snapshots/"+id, ""); w.Code != http.StatusNoContent {
+		t.Fatalf("delete snapshot: %d %s", w.Code, w.Body.String())
+	}
+	if deleted := changes(http.MethodDelete, "/api/snapshots/:id"); deleted["/id"].Before != id || deleted["/id"].After != nil {
+		t.Errorf("expected snapshot deletion diff, got %+v", deleted)
+	}
+
+	if w := serve(http.MethodPost, "/api/alerts/silences", `{"rule": "node-down", "comment": "maintenance"}`); w.Code != http.StatusCreated {
+		t.Fatalf("create silence: %d %s", w.Code, w.Body.String())
+	}
+	silence := changes(http.MethodPost, "/api/alerts/silences")
+	silenceID, _ := silence["/id"].After.(string)
+	if silenceID == "" || silence["/rule"].After != "node-down" {
+		t.Fatalf("expected silence creation diff, got %+v", silence)
+	}
+
+	if w := serve(http.MethodDelete, "/api/alerts/silences/"+silenceID, ""); w.Code != http.StatusNoContent {
+		t.Fatalf("delete silence: %d %s", w.Code, w.Body.String())
+	}
+	if deleted := changes(http.MethodDelete, "/api/alerts/silences/:id"); deleted["/comment"].Before != "maintenance" {
+		t.Errorf("expected silence deletion diff, got %+v", deleted)
+	}
+}
@@ -0,0 +1,126 @@
+// internal/handlers/configs.go
+package handlers
+
+import (
+	"net/http"
+	"path"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/middleware"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/services"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// SecretPolicy decides who may see Secret values. Values are revealed only
+// when ?reveal=true is requested by an identity in one of RevealGroups and
+// "namespace/name" matches one of the RevealPatterns globs.
+type SecretPolicy struct {
+	RevealGroups   []string
+	RevealPatterns []string
+}
+
+// allows reports whether the caller may see the values of namespace/name
+func (p SecretPolicy) allows(c *gin.Context, namespace, name string) bool {
+	identity, ok := middleware.GetIdentity(c)
+	if !ok {
+		return false
+	}
+
+	member := false
+	for _, group := range identity.Groups {
+		for _, allowed := range p.RevealGroups {
+			if group == allowed {
+				member = true
+			}
+		}
+	}
+	if !member {
+		return false
+	}
+
+	for _, pattern := range p.RevealPatterns {
+		if matched, err := path.Match(pattern, namespace+"/"+name); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+type ConfigHandler struct {
+	k8sClient services.K8sClientInterface
+	policy    SecretPolicy
+}
+
+func NewConfigHandler(k8sClient services.K8sClientInterface, policy SecretPolicy) *ConfigHandler {
+	return &ConfigHandler{k8sClient: k8sClient, policy: policy}
+}
+
+// ListConfigMaps returns ConfigMaps with the pods referencing them.
+// ?namespace= limits the list.
+func (h *ConfigHandler) ListConfigMaps(c *gin.Context) {
+	configMaps, err := services.ListConfigMaps(c.Request.Context(), h.k8sClient.GetClientset(), c.Query("namespace"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"configmaps": configMaps,
+		"count":      len(configMaps),
+	})
+}
+
+// GetConfigMap returns a ConfigMap's values, references and stale pods
+func (h *ConfigHandler) GetConfigMap(c *gin.Context) {
+	configMap, err := services.GetConfigMap(c.Request.Context(), h.k8sClient.GetClientset(), c.Param("namespace"), c.Param("name"))
+	if apierrors.IsNotFound(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, configMap)
+}
+
+// ListSecrets returns Secrets with their keys and referencing pods; values
+// are never listed. ?namespace= limits the list.
+func (h *ConfigHandler) ListSecrets(c *gin.Context) {
+	secrets, err := services.ListSecrets(c.Request.Context(), h.k8sClient.GetClientset(), c.Query("namespace"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secrets": secrets,
+		"count":   len(secrets),
+	})
+}
+
+// GetSecret returns a Secret's keys, references and stale pods. Values are
+// redacted unless ?reveal=true is set and the policy allows the caller.
+func (h *ConfigHandler) GetSecret(c *gin.Context) {
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+
+	reveal := c.Query("reveal") == "true"
+	if reveal && !h.policy.allows(c, namespace, name) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Revealing this secret is not allowed by policy"})
+		return
+	}
+
+	secret, err := services.GetSecret(c.Request.Context(), h.k8sClient.GetClientset(), namespace, name, reveal)
+	if apierrors.IsNotFound(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, secret)
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/models"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/services"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGetSecret_RevealPolicy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cs := fake.NewSimpleClientset(
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "shop"}, Data: map[string][]byte{"password": []byte("hunter2")}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "tls", Namespace: "shop"}, Data: map[string][]byte{"tls.key": []byte("key")}},
+	)
+	handler := NewConfigHandler(&localMockK8s{cs: cs}, SecretPolicy{
+		RevealGroups:   []string{"ops"},
+		RevealPatterns: []string{"shop/db*"},
+	})
+
+	serve := func(url string, groups ...string) *httptest.ResponseRecorder {
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			if groups != nil {
+				c.Set("identity", &models.Identity{Name: "alice", Groups: groups})
+			}
+			c.Next()
+		})
+		r.GET("/api/secrets/:namespace/:name", handler.GetSecret)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		return w
+	}
+
+	w := serve("/api/secrets/shop/db")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var secret services.ConfigObject
+	if err := json.Unmarshal(w.Body.Bytes(), &secret); err != nil {
+		t.Fatalf("failed to unmarshal secret: %v", err)
+	}
+	if !secret.Redacted || secret.Data != nil {
+		t.Errorf("expected redacted secret, got %+v", secret)
+	}
+
+	for _, tc := range []struct {
+		url    string
+		groups []string
+	}{
+		{"/api/secrets/shop/db?reveal=true", nil},
+		{"/api/secrets/shop/db?reveal=true", []string{"dev"}},
+		{"/api/secrets/shop/tls?reveal=true", []string{"ops"}},
+	} {
+		if w := serve(tc.url, tc.groups...); w.Code != http.StatusForbidden {
+			t.Errorf("%s with groups %v: expected 403, got %d", tc.url, tc.groups, w.Code)
+		}
+	}
+
+	w = serve("/api/secrets/shop/db?reveal=true", "ops")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	secret = services.ConfigObject{}
+	if err := json.Unmarshal(w.Body.Bytes(), &secret); err != nil {
+		t.Fatalf("failed to unmarshal secret: %v", err)
+	}
+	if secret.Redacted || secret.Data["password"] != "hunter2" {
+		t.Errorf("expected revealed secret, got %+v", secret)
+	}
+
+	if w := serve("/api/secrets/shop/missing"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for missing secret, got %d", w.Code)
+	}
+}
@@ -31,9 +31,14 @@ func (h *DeploymentHandler) ListDeployments(c *gin.Context) {
 
 	result := make([]gin.H, 0, len(deployments.Items))
 	for _, deploy := range deployments.Items {
+		replicas := int32(1) // API default when unset
 		readyReplicas := int32(0)
 		availableReplicas := int32(0)
 
+		if deploy.Spec.Replicas != nil {
+			replicas = *deploy.Spec.Replicas
+		}
+
 		if deploy.Status.ReadyReplicas != 0 {
 			readyReplicas = deploy.Status.ReadyReplicas
 		}
@@ -44,7 +49,7 @@ func (h *DeploymentHandler) ListDeployments(c *gin.Context) {
 		result = append(result, gin.H{
 			"name":               deploy.Name,
 			"namespace":          deploy.Namespace,
-			"replicas":           *deploy.Spec.Replicas,
+			"replicas":           replicas,
 			"ready_replicas":     readyReplicas,
 			"available_replicas": availableReplicas,
 			"created":            deploy.CreationTimestamp.Time,
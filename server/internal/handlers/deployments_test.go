@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestListDeployments_NilReplicasDefaultsToOne(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Replicas is optional in the API and defaults to 1
+	dep := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}}
+	handler := NewDeploymentHandler(&mockK8s{cs: fake.NewSimpleClientset(dep)})
+	r := gin.New()
+	r.GET("/api/deployments", handler.ListDeployments)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/deployments?namespace=default", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Deployments []struct {
+			Name     string `json:"name"`
+			Replicas int32  `json:"replicas"`
+		} `json:"deployments"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal body: %v", err)
+	}
+	if len(resp.Deployments) != 1 || resp.Deployments[0].Replicas != 1 {
+		t.Fatalf("expected one deployment with 1 replica, got %+v", resp.Deployments)
+	}
+}
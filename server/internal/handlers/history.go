@@ -0,0 +1,45 @@
+// internal/handlers/history.go
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/services"
+)
+
+type HistoryHandler struct {
+	history *services.MetricsHistory
+}
+
+func NewHistoryHandler(history *services.MetricsHistory) *HistoryHandler {
+	return &HistoryHandler{history: history}
+}
+
+// GetMetricsHistory returns sampled cluster metrics for ?range= (default 1h)
+// at ?step= resolution (default: finest available)
+func (h *HistoryHandler) GetMetricsHistory(c *gin.Context) {
+	window, err := time.ParseDuration(c.DefaultQuery("range", "1h"))
+	if err != nil || window <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "range must be a positive duration such as 1h"})
+		return
+	}
+
+	var step time.Duration
+	if value := c.Query("step"); value != "" {
+		step, err = time.ParseDuration(value)
+		if err != nil || step <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "step must be a positive duration such as 1m"})
+			return
+		}
+	}
+
+	samples, effectiveStep := h.history.Query(time.Now(), window, step)
+	c.JSON(http.StatusOK, gin.H{
+		"samples": samples,
+		"count":   len(samples),
+		"range":   window.String(),
+		"step":    effectiveStep.String(),
+	})
+}
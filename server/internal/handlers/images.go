@@ -0,0 +1,30 @@
+// internal/handlers/images.go
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/services"
+)
+
+type ImageHandler struct {
+	k8sClient services.K8sClientInterface
+}
+
+func NewImageHandler(k8sClient services.K8sClientInterface) *ImageHandler {
+	return &ImageHandler{k8sClient: k8sClient}
+}
+
+// ListImages returns running images grouped by repository and tag/digest,
+// the registries in use and replicas whose resolved digest drifted.
+// ?namespace= limits the inventory.
+func (h *ImageHandler) ListImages(c *gin.Context) {
+	inventory, err := services.GetImageInventory(c.Request.Context(), h.k8sClient.GetClientset(), c.Query("namespace"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, inventory)
+}
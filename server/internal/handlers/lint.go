@@ -0,0 +1,41 @@
+// internal/handlers/lint.go
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/services"
+)
+
+type LintHandler struct {
+	k8sClient services.K8sClientInterface
+}
+
+func NewLintHandler(k8sClient services.K8sClientInterface) *LintHandler {
+	return &LintHandler{k8sClient: k8sClient}
+}
+
+// GetLintReport lints workloads against best-practice rules. ?namespace=
+// limits the report and ?format=sarif returns a SARIF 2.1.0 log.
+func (h *LintHandler) GetLintReport(c *gin.Context) {
+	report, err := services.GetLintReport(c.Request.Context(), h.k8sClient.GetClientset(), c.Query("namespace"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.Query("format") == "sarif" {
+		var buf bytes.Buffer
+		if err := services.WriteLintSARIF(&buf, report); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("Content-Disposition", `attachment; filename="lint.sarif"`)
+		c.Data(http.StatusOK, "application/sarif+json", buf.Bytes())
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,83 @@
+// internal/handlers/netpol.go
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/services"
+	corev1 "k8s.io/api/core/v1"
+)
+
+type NetworkPolicyHandler struct {
+	k8sClient services.K8sClientInterface
+}
+
+func NewNetworkPolicyHandler(k8sClient services.K8sClientInterface) *NetworkPolicyHandler {
+	return &NetworkPolicyHandler{k8sClient: k8sClient}
+}
+
+// ListNetworkPolicies returns policies with the pods they select and, per
+// pod, the policies selecting it. ?namespace= limits both lists.
+func (h *NetworkPolicyHandler) ListNetworkPolicies(c *gin.Context) {
+	set, err := services.GetNetworkPolicySet(c.Request.Context(), h.k8sClient.GetClientset())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	namespace := c.Query("namespace")
+	policies := set.Policies(namespace)
+	c.JSON(http.StatusOK, gin.H{
+		"policies": policies,
+		"pods":     set.PodPolicies(namespace),
+		"count":    len(policies),
+	})
+}
+
+// CheckReachability answers whether ?from=namespace/pod can connect to
+// ?to=namespace/pod on ?port= (number or name, any when empty) and
+// ?protocol= (TCP by default)
+func (h *NetworkPolicyHandler) CheckReachability(c *gin.Context) {
+	fromNamespace, fromName, okFrom := strings.Cut(c.Query("from"), "/")
+	toNamespace, toName, okTo := strings.Cut(c.Query("to"), "/")
+	if !okFrom || !okTo || fromName == "" || toName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to must be namespace/pod"})
+		return
+	}
+	protocol := corev1.Protocol(strings.ToUpper(c.DefaultQuery("protocol", "TCP")))
+	if protocol != corev1.ProtocolTCP && protocol != corev1.ProtocolUDP && protocol != corev1.ProtocolSCTP {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "protocol must be TCP, UDP or SCTP"})
+		return
+	}
+
+	set, err := services.GetNetworkPolicySet(c.Request.Context(), h.k8sClient.GetClientset())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	src, ok := set.Pod(fromNamespace, fromName)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "pod " + c.Query("from") + " not found"})
+		return
+	}
+	dst, ok := set.Pod(toNamespace, toName)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "pod " + c.Query("to") + " not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, set.CanReach(src, dst, c.Query("port"), protocol))
+}
+
+// GetTrafficGraph returns the namespace-level allowed-traffic graph
+func (h *NetworkPolicyHandler) GetTrafficGraph(c *gin.Context) {
+	set, err := services.GetNetworkPolicySet(c.Request.Context(), h.k8sClient.GetClientset())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, set.Graph())
+}
@@ -80,3 +80,15 @@ func (h *NodeHandler) GetNode(c *gin.Context) {
 		"labels":          node.Labels,
 	})
 }
+
+// GetAllocation reports requests, limits, overcommit and free capacity per
+// node, plus cluster fragmentation and the largest pod that still fits
+func (h *NodeHandler) GetAllocation(c *gin.Context) {
+	report, err := services.GetAllocationReport(c.Request.Context(), h.k8sClient.GetClientset())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
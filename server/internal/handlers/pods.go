@@ -2,16 +2,25 @@
 package handlers
 
 import (
-
+	"bytes"
+	"context"
+	"fmt"
 	"io"
+	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/models"
 	"github.com/mugayoshi/k8s-visualizer/server/internal/services"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// maxPodEventLookups caps the per-pod event lists made by one ListPods call;
+// unhealthy pods beyond it are diagnosed from their status alone
+const maxPodEventLookups = 20
+
 type PodHandler struct {
 	k8sClient services.K8sClientInterface
 }
@@ -25,47 +34,28 @@ func (h *PodHandler) ListPods(c *gin.Context) {
 	namespace := c.DefaultQuery("namespace", "default")
 	clientset := h.k8sClient.GetClientset()
 
-	var pods *corev1.PodList
-	var err error
-
-	if namespace == "all" {
-		pods, err = clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
-	} else {
-		pods, err = clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
-	}
-
+	pods, err := h.listPods(ctx, namespace)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	result := make([]gin.H, 0, len(pods.Items))
-	for _, pod := range pods.Items {
-		containers := make([]gin.H, 0, len(pod.Spec.Containers))
-		for _, container := range pod.Spec.Containers {
-			ready := false
-			for _, cs := range pod.Status.ContainerStatuses {
-				if cs.Name == container.Name {
-					ready = cs.Ready
-					break
-				}
+	// Events are only needed to explain unhealthy pods. They are optional:
+	// if listing fails, the remaining pods are diagnosed without them.
+	lookups := 0
+	result := make([]models.PodResponse, 0, len(pods.Items))
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		var events []corev1.Event
+		if lookups < maxPodEventLookups && services.PodNeedsAttention(pod) {
+			lookups++
+			events, err = services.ListPodEvents(ctx, clientset, pod)
+			if err != nil {
+				log.Printf("Failed to list events for pod %s/%s: %v", pod.Namespace, pod.Name, err)
+				lookups = maxPodEventLookups
 			}
-			containers = append(containers, gin.H{
-				"name":  container.Name,
-				"image": container.Image,
-				"ready": ready,
-			})
 		}
-
-		result = append(result, gin.H{
-			"name":       pod.Name,
-			"namespace":  pod.Namespace,
-			"status":     string(pod.Status.Phase),
-			"node":       pod.Spec.NodeName,
-			"created":    pod.CreationTimestamp.Time,
-			"labels":     pod.Labels,
-			"containers": containers,
-		})
+		result = append(result, newPodResponse(pod, services.AnalyzePod(pod, events)))
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -74,6 +64,47 @@ func (h *PodHandler) ListPods(c *gin.Context) {
 	})
 }
 
+// listPods lists pods in namespace, or in every namespace for "all"
+func (h *PodHandler) listPods(ctx context.Context, namespace string) (*corev1.PodList, error) {
+	if namespace == "all" {
+		namespace = ""
+	}
+	return h.k8sClient.GetClientset().CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+}
+
+// GetSecurityReport scans pod specs for risky settings and Pod Security
+// Admission violations and scores each namespace. ?namespace= defaults to
+// all namespaces and ?format=csv downloads the findings.
+func (h *PodHandler) GetSecurityReport(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	pods, err := h.listPods(ctx, c.DefaultQuery("namespace", "all"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	namespaces, err := h.k8sClient.GetClientset().CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	report := services.BuildSecurityReport(pods.Items, namespaces.Items)
+
+	if c.Query("format") == "csv" {
+		var buf bytes.Buffer
+		if err := services.WriteSecurityCSV(&buf, report); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("Content-Disposition", `attachment; filename="security.csv"`)
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
 func (h *PodHandler) GetPod(c *gin.Context) {
 	ctx := c.Request.Context()
 	namespace := c.Param("namespace")
@@ -89,6 +120,49 @@ func (h *PodHandler) GetPod(c *gin.Context) {
 	c.JSON(http.StatusOK, pod)
 }
 
+// GetPodDiagnosis explains what is wrong with a pod and suggests next steps
+func (h *PodHandler) GetPodDiagnosis(c *gin.Context) {
+	ctx := c.Request.Context()
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+	clientset := h.k8sClient.GetClientset()
+
+	pod, err := clientset.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pod not found"})
+		return
+	}
+
+	diagnoses, err := services.DiagnosePod(ctx, clientset, pod)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":        string(pod.Status.Phase),
+		"status_detail": services.StatusDetail(diagnoses),
+		"diagnoses":     diagnoses,
+		"count":         len(diagnoses),
+	})
+}
+
+// GetPodScheduling evaluates every node against the pod's scheduling
+// constraints and reports which predicate failed on each
+func (h *PodHandler) GetPodScheduling(c *gin.Context) {
+	explanation, err := services.ExplainScheduling(c.Request.Context(), h.k8sClient.GetClientset(), c.Param("namespace"), c.Param("name"))
+	if apierrors.IsNotFound(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pod not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, explanation)
+}
+
 func (h *PodHandler) GetPodLogs(c *gin.Context) {
 	ctx := c.Request.Context()
 	namespace := c.Param("namespace")
@@ -121,3 +195,53 @@ func (h *PodHandler) GetPodLogs(c *gin.Context) {
 		"logs": string(logBytes),
 	})
 }
+
+// newPodResponse summarizes a pod and the analyzer's diagnoses
+func newPodResponse(pod *corev1.Pod, diagnoses []models.Diagnosis) models.PodResponse {
+	statuses := make(map[string]corev1.ContainerStatus, len(pod.Status.ContainerStatuses))
+	for _, cs := range pod.Status.ContainerStatuses {
+		statuses[cs.Name] = cs
+	}
+
+	containers := make([]models.ContainerInfo, 0, len(pod.Spec.Containers))
+	var ready int
+	var restarts int32
+	for _, container := range pod.Spec.Containers {
+		cs := statuses[container.Name]
+		state := ""
+		switch {
+		case cs.State.Running != nil:
+			state = "running"
+		case cs.State.Waiting != nil:
+			state = "waiting"
+		case cs.State.Terminated != nil:
+			state = "terminated"
+		}
+		if cs.Ready {
+			ready++
+		}
+		restarts += cs.RestartCount
+		containers = append(containers, models.ContainerInfo{
+			Name:         container.Name,
+			Image:        container.Image,
+			Ready:        cs.Ready,
+			RestartCount: cs.RestartCount,
+			State:        state,
+		})
+	}
+
+	return models.PodResponse{
+		Name:            pod.Name,
+		Namespace:       pod.Namespace,
+		Status:          string(pod.Status.Phase),
+		StatusDetail:    services.StatusDetail(diagnoses),
+		Diagnoses:       diagnoses,
+		Phase:           string(pod.Status.Phase),
+		ReadyContainers: fmt.Sprintf("%d/%d", ready, len(pod.Spec.Containers)),
+		Node:            pod.Spec.NodeName,
+		Created:         pod.CreationTimestamp.Time,
+		Labels:          pod.Labels,
+		Containers:      containers,
+		RestartCount:    restarts,
+	}
+}
@@ -3,6 +3,7 @@ package handlers
 import (
     "context"
     "encoding/json"
+    "fmt"
     "net/http"
     "net/http/httptest"
     "testing"
@@ -11,8 +12,10 @@ import (
     corev1 "k8s.io/api/core/v1"
     "k8s.io/apimachinery/pkg/api/resource"
     metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/apimachinery/pkg/runtime"
     "k8s.io/client-go/kubernetes"
     "k8s.io/client-go/kubernetes/fake"
+    k8stesting "k8s.io/client-go/testing"
 
     "github.com/gin-gonic/gin"
     "github.com/mugayoshi/k8s-visualizer/server/internal/services"
@@ -66,6 +69,41 @@ func TestListPods_ReturnsPods(t *testing.T) {
     }
 }
 
+func TestListPods_EventListError_StillReturnsPods(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+
+    pending := &corev1.Pod{
+        ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "default"},
+        Status: corev1.PodStatus{Phase: corev1.PodPending},
+    }
+    cs := fake.NewSimpleClientset(pending)
+    cs.Fake.PrependReactor("list", "events", func(action k8stesting.Action) (handled bool, ret runtime.Object, err error) {
+        return true, nil, fmt.Errorf("list error")
+    })
+
+    handler := NewPodHandler(&mockK8s{cs: cs})
+    r := gin.New()
+    r.GET("/api/pods", handler.ListPods)
+
+    req := httptest.NewRequest(http.MethodGet, "/api/pods?namespace=default", nil)
+    w := httptest.NewRecorder()
+    r.ServeHTTP(w, req)
+
+    if w.Code != http.StatusOK {
+        t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+    }
+
+    var resp struct{
+        Count int `json:"count"`
+    }
+    if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+        t.Fatalf("failed to unmarshal body: %v", err)
+    }
+    if resp.Count != 1 {
+        t.Fatalf("expected the pod without event diagnoses, got count=%d", resp.Count)
+    }
+}
+
 func TestListNodes_ReturnsNodes(t *testing.T) {
     gin.SetMode(gin.TestMode)
 
@@ -0,0 +1,39 @@
+// internal/handlers/quotas.go
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/services"
+)
+
+type QuotaHandler struct {
+	k8sClient services.K8sClientInterface
+}
+
+func NewQuotaHandler(k8sClient services.K8sClientInterface) *QuotaHandler {
+	return &QuotaHandler{k8sClient: k8sClient}
+}
+
+// ListQuotas returns ResourceQuota usage against hard limits and LimitRange
+// defaults per namespace. ?namespace= limits the result.
+func (h *QuotaHandler) ListQuotas(c *gin.Context) {
+	quotas, err := services.ListNamespaceQuotas(c.Request.Context(), h.k8sClient.GetClientset(), c.Query("namespace"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	nearLimit := make([]string, 0)
+	for _, namespace := range quotas {
+		if namespace.NearLimit {
+			nearLimit = append(nearLimit, namespace.Namespace)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"namespaces": quotas,
+		"count":      len(quotas),
+		"near_limit": nearLimit,
+	})
+}
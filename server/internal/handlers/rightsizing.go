@@ -0,0 +1,38 @@
+// internal/handlers/rightsizing.go
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/services"
+)
+
+type RightsizingHandler struct {
+	sampler *services.RightsizingSampler
+}
+
+func NewRightsizingHandler(sampler *services.RightsizingSampler) *RightsizingHandler {
+	return &RightsizingHandler{sampler: sampler}
+}
+
+// GetRecommendations returns per-container right-sizing recommendations with
+// workload and namespace rollups. ?namespace= limits the report and
+// ?format=csv downloads the recommendations as CSV.
+func (h *RightsizingHandler) GetRecommendations(c *gin.Context) {
+	report := h.sampler.Report(c.Query("namespace"))
+
+	if c.Query("format") == "csv" {
+		var buf bytes.Buffer
+		if err := services.WriteRightsizingCSV(&buf, report); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("Content-Disposition", `attachment; filename="rightsizing.csv"`)
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
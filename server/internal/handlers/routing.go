@@ -0,0 +1,70 @@
+// internal/handlers/routing.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/services"
+)
+
+type RoutingHandler struct {
+	k8sClient services.K8sClientInterface
+}
+
+func NewRoutingHandler(k8sClient services.K8sClientInterface) *RoutingHandler {
+	return &RoutingHandler{k8sClient: k8sClient}
+}
+
+// ListIngresses returns Ingress rules resolved to services, endpoints and
+// pods. ?namespace= limits the result.
+func (h *RoutingHandler) ListIngresses(c *gin.Context) {
+	routes, err := services.GetIngressRoutes(c.Request.Context(), h.k8sClient.GetClientset(), c.Query("namespace"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ingresses": routes,
+		"count":     len(routes),
+		"broken":    countBroken(routes),
+	})
+}
+
+// ListHTTPRoutes returns Gateway API HTTPRoutes resolved to services,
+// endpoints and pods. installed is false when the Gateway API is missing.
+func (h *RoutingHandler) ListHTTPRoutes(c *gin.Context) {
+	routes, err := services.GetHTTPRoutes(c.Request.Context(), h.k8sClient.GetClientset(), c.Query("namespace"))
+	if errors.Is(err, services.ErrGatewayAPIUnavailable) {
+		c.JSON(http.StatusOK, gin.H{
+			"httproutes": []services.Route{},
+			"count":      0,
+			"broken":     0,
+			"installed":  false,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"httproutes": routes,
+		"count":      len(routes),
+		"broken":     countBroken(routes),
+		"installed":  true,
+	})
+}
+
+func countBroken(routes []services.Route) int {
+	broken := 0
+	for _, route := range routes {
+		if route.Broken {
+			broken++
+		}
+	}
+	return broken
+}
@@ -0,0 +1,123 @@
+// internal/handlers/snapshots.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/services"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/snapshots"
+)
+
+type SnapshotHandler struct {
+	k8sClient services.K8sClientInterface
+	store     *snapshots.Store
+}
+
+func NewSnapshotHandler(k8sClient services.K8sClientInterface, store *snapshots.Store) *SnapshotHandler {
+	return &SnapshotHandler{k8sClient: k8sClient, store: store}
+}
+
+// ListSnapshots lists stored snapshots, newest first. With ?at=<RFC3339>
+// only the newest snapshot taken at or before that time is returned.
+func (h *SnapshotHandler) ListSnapshots(c *gin.Context) {
+	if at := c.Query("at"); at != "" {
+		t, err := time.Parse(time.RFC3339, at)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "at must be an RFC3339 timestamp"})
+			return
+		}
+		meta, err := h.store.At(t)
+		if errors.Is(err, snapshots.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No snapshot before " + at})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"snapshots": []snapshots.Meta{*meta},
+			"count":     1,
+		})
+		return
+	}
+
+	metas, err := h.store.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"snapshots": metas,
+		"count":     len(metas),
+	})
+}
+
+// CreateSnapshot captures and stores the current cluster state
+func (h *SnapshotHandler) CreateSnapshot(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	snapshot, err := services.CaptureSnapshot(ctx, h.k8sClient.GetClientset())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	meta, err := h.store.Save(snapshot, "manual")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	recordChange(c, nil, meta)
+	c.JSON(http.StatusCreated, meta)
+}
+
+// GetSnapshot returns a full snapshot by ID, or the newest one for "latest"
+func (h *SnapshotHandler) GetSnapshot(c *gin.Context) {
+	id := c.Param("id")
+	if id == "latest" {
+		metas, err := h.store.List()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if len(metas) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Snapshot not found"})
+			return
+		}
+		id = metas[0].ID
+	}
+
+	snapshot, err := h.store.Get(id)
+	if errors.Is(err, snapshots.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Snapshot not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, snapshot)
+}
+
+// DeleteSnapshot removes a snapshot
+func (h *SnapshotHandler) DeleteSnapshot(c *gin.Context) {
+	meta, err := h.store.Delete(c.Param("id"))
+	if errors.Is(err, snapshots.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Snapshot not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	recordChange(c, meta, nil)
+	c.Status(http.StatusNoContent)
+}
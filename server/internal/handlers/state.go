@@ -0,0 +1,75 @@
+// internal/handlers/state.go
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/services"
+)
+
+type StateHandler struct {
+	k8sClient services.K8sClientInterface
+}
+
+func NewStateHandler(k8sClient services.K8sClientInterface) *StateHandler {
+	return &StateHandler{k8sClient: k8sClient}
+}
+
+// diffRequest holds the documents to compare. When After is omitted the
+// Before document is compared against the live cluster.
+type diffRequest struct {
+	Before *services.ClusterSnapshot `json:"before"`
+	After  *services.ClusterSnapshot `json:"after"`
+}
+
+// ExportState returns the current cluster state as a JSON document that
+// can later be passed to DiffState
+func (h *StateHandler) ExportState(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	state, err := services.CaptureSnapshot(ctx, h.k8sClient.GetClientset())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.Query("download") == "true" {
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="cluster-state-%s.json"`, state.Time.Format("20060102T150405Z")))
+	}
+	c.JSON(http.StatusOK, state)
+}
+
+// DiffState compares two exported state documents, or one against live
+// state. ?format=text returns a human-readable report instead of JSON.
+func (h *StateHandler) DiffState(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req diffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Before == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "before is required"})
+		return
+	}
+
+	after := req.After
+	if after == nil {
+		live, err := services.CaptureSnapshot(ctx, h.k8sClient.GetClientset())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		after = live
+	}
+
+	diff := services.DiffSnapshots(req.Before, after)
+	if c.Query("format") == "text" {
+		c.String(http.StatusOK, diff.Report())
+		return
+	}
+	c.JSON(http.StatusOK, diff)
+}
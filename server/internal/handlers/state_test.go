@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/services"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDiffState_AgainstLive(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cs := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "new-pod", Namespace: "default"}},
+	)
+	handler := NewStateHandler(&localMockK8s{cs: cs})
+	r := gin.New()
+	r.POST("/api/state/diff", handler.DiffState)
+
+	body, _ := json.Marshal(gin.H{"before": services.ClusterSnapshot{
+		Namespaces: []corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "default"}}},
+	}})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/state/diff", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var diff services.StateDiff
+	if err := json.Unmarshal(w.Body.Bytes(), &diff); err != nil {
+		t.Fatalf("failed to unmarshal diff: %v", err)
+	}
+	if len(diff.PodsAdded) != 1 || diff.PodsAdded[0].Name != "new-pod" {
+		t.Fatalf("expected new-pod to appear, got %+v", diff.PodsAdded)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/state/diff?format=text", bytes.NewReader(body))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), "+ default/new-pod") {
+		t.Fatalf("expected text report to list new-pod, got:\n%s", w.Body.String())
+	}
+}
+
+func TestDiffState_MissingBefore_Returns400(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewStateHandler(&localMockK8s{cs: fake.NewSimpleClientset()})
+	r := gin.New()
+	r.POST("/api/state/diff", handler.DiffState)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/state/diff", strings.NewReader(`{}`))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,69 @@
+// internal/handlers/storage.go
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/services"
+)
+
+type StorageHandler struct {
+	k8sClient services.K8sClientInterface
+}
+
+func NewStorageHandler(k8sClient services.K8sClientInterface) *StorageHandler {
+	return &StorageHandler{k8sClient: k8sClient}
+}
+
+// ListClaims returns PersistentVolumeClaims with the pods mounting them.
+// Pending claims include their provisioning events. ?namespace= limits the
+// result.
+func (h *StorageHandler) ListClaims(c *gin.Context) {
+	claims, err := services.ListPersistentVolumeClaims(c.Request.Context(), h.k8sClient.GetClientset(), c.Query("namespace"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	pending := 0
+	for _, claim := range claims {
+		if claim.Pending {
+			pending++
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"claims":  claims,
+		"count":   len(claims),
+		"pending": pending,
+	})
+}
+
+// ListVolumes returns PersistentVolumes with their claims, the pods
+// mounting them and their node affinity
+func (h *StorageHandler) ListVolumes(c *gin.Context) {
+	volumes, err := services.ListPersistentVolumes(c.Request.Context(), h.k8sClient.GetClientset())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"volumes": volumes,
+		"count":   len(volumes),
+	})
+}
+
+// ListStorageClasses returns StorageClasses with their usage
+func (h *StorageHandler) ListStorageClasses(c *gin.Context) {
+	classes, err := services.ListStorageClasses(c.Request.Context(), h.k8sClient.GetClientset())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"storage_classes": classes,
+		"count":           len(classes),
+	})
+}
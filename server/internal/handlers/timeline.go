@@ -0,0 +1,47 @@
+// internal/handlers/timeline.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/services"
+)
+
+type TimelineHandler struct {
+	timeline *services.Timeline
+}
+
+func NewTimelineHandler(timeline *services.Timeline) *TimelineHandler {
+	return &TimelineHandler{timeline: timeline}
+}
+
+// GetTimeline returns the chronological history of a pod, deployment or
+// node. Nodes are cluster scoped, so their namespace segment is "_".
+func (h *TimelineHandler) GetTimeline(c *gin.Context) {
+	kind := c.Param("kind")
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+	if namespace == "_" {
+		namespace = ""
+	}
+
+	entries, err := h.timeline.Get(c.Request.Context(), kind, namespace, name)
+	if errors.Is(err, services.ErrUnsupportedKind) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"kind":      kind,
+		"namespace": namespace,
+		"name":      name,
+		"entries":   entries,
+		"count":     len(entries),
+	})
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/services"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGetTimeline(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cs := fake.NewSimpleClientset(&corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "node-1.1", Namespace: "default"},
+		InvolvedObject: corev1.ObjectReference{Kind: "Node", Name: "node-1"},
+		Reason:         "NodeNotReady",
+		Type:           corev1.EventTypeWarning,
+	})
+	handler := NewTimelineHandler(services.NewTimeline(cs, informers.NewSharedInformerFactory(cs, 0), 10, 10))
+	r := gin.New()
+	r.GET("/api/timeline/:kind/:namespace/:name", handler.GetTimeline)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/timeline/node/_/node-1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Entries []services.TimelineEntry `json:"entries"`
+		Count   int                      `json:"count"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if body.Count != 1 || body.Entries[0].Reason != "NodeNotReady" {
+		t.Fatalf("expected the node event, got %+v", body.Entries)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/timeline/services/default/web", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unsupported kind, got %d", w.Code)
+	}
+}
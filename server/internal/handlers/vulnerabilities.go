@@ -0,0 +1,31 @@
+// internal/handlers/vulnerabilities.go
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/services"
+)
+
+type VulnerabilityHandler struct {
+	k8sClient services.K8sClientInterface
+	db        *services.VulnDB
+}
+
+func NewVulnerabilityHandler(k8sClient services.K8sClientInterface, db *services.VulnDB) *VulnerabilityHandler {
+	return &VulnerabilityHandler{k8sClient: k8sClient, db: db}
+}
+
+// ListVulnerabilities matches running images against the local advisory
+// database and groups matches by workload and namespace. ?namespace= limits
+// the report.
+func (h *VulnerabilityHandler) ListVulnerabilities(c *gin.Context) {
+	inventory, err := services.GetImageInventory(c.Request.Context(), h.k8sClient.GetClientset(), c.Query("namespace"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.db.MatchImages(inventory))
+}
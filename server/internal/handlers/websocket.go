@@ -6,12 +6,15 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/alerts"
 	"github.com/mugayoshi/k8s-visualizer/server/internal/models"
 	"github.com/mugayoshi/k8s-visualizer/server/internal/services"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/telemetry"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/watch"
 )
@@ -28,12 +31,19 @@ var upgrader = websocket.Upgrader{
 
 type WebSocketHandler struct {
 	k8sClient services.K8sClientInterface
+	alerts    *alerts.Engine
 }
 
 func NewWebSocketHandler(k8sClient services.K8sClientInterface) *WebSocketHandler {
 	return &WebSocketHandler{k8sClient: k8sClient}
 }
 
+// WithAlerts pushes alert transitions from the engine to every client
+func (h *WebSocketHandler) WithAlerts(engine *alerts.Engine) *WebSocketHandler {
+	h.alerts = engine
+	return h
+}
+
 // HandleWebSocket handles WebSocket connections for real-time updates
 func (h *WebSocketHandler) HandleWebSocket(c *gin.Context) {
 	// Upgrade HTTP connection to WebSocket
@@ -45,10 +55,14 @@ func (h *WebSocketHandler) HandleWebSocket(c *gin.Context) {
 	defer conn.Close()
 
 	log.Printf("WebSocket client connected from %s", conn.RemoteAddr())
+	telemetry.WebSocketConnections.Inc()
+	defer telemetry.WebSocketConnections.Dec()
 
 	// Create context with cancel
-	ctx, cancel := context.WithCancel(c.Request.Context())
+	stats := &connStats{}
+	ctx, cancel := context.WithCancel(context.WithValue(c.Request.Context(), connStatsKey{}, stats))
 	defer cancel()
+	defer stats.observe()
 
 	// Channel for sending messages to client
 	send := make(chan models.WebSocketMessage, 256)
@@ -62,11 +76,79 @@ func (h *WebSocketHandler) HandleWebSocket(c *gin.Context) {
 	// Start watching Kubernetes resources
 	go h.watchPods(ctx, send, "")
 
+	if h.alerts != nil {
+		go h.forwardAlerts(ctx, send)
+	}
+
 	// Keep connection alive until context is cancelled
 	<-ctx.Done()
 	log.Printf("WebSocket client disconnected from %s", conn.RemoteAddr())
 }
 
+// connStats counts the messages queued and dropped for one connection
+type connStats struct {
+	sent        atomic.Int64
+	dropped     atomic.Int64
+	lastDropLog atomic.Int64 // UnixNano of the last drop log line
+}
+
+// dropLogInterval limits drop logging to one line per connection per interval
+const dropLogInterval = 10 * time.Second
+
+// connStatsKey is the context key for the connection's *connStats
+type connStatsKey struct{}
+
+// observe records the connection totals once it closes
+func (s *connStats) observe() {
+	telemetry.WebSocketConnectionMessages.WithLabelValues("sent").Observe(float64(s.sent.Load()))
+	telemetry.WebSocketConnectionMessages.WithLabelValues("dropped").Observe(float64(s.dropped.Load()))
+}
+
+// deliver queues a message the client cannot do without, such as the
+// initial pod list, waiting for buffer space until the connection closes
+func deliver(ctx context.Context, send chan models.WebSocketMessage, message models.WebSocketMessage) bool {
+	stats, _ := ctx.Value(connStatsKey{}).(*connStats)
+
+	select {
+	case send <- message:
+		telemetry.WebSocketMessagesSent.WithLabelValues(message.Type).Inc()
+		if stats != nil {
+			stats.sent.Add(1)
+		}
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// enqueue queues an incremental update for the client without blocking.
+// When the send buffer is full the message is dropped so a slow client
+// cannot stall the watch.
+func enqueue(ctx context.Context, send chan models.WebSocketMessage, message models.WebSocketMessage) bool {
+	stats, _ := ctx.Value(connStatsKey{}).(*connStats)
+
+	select {
+	case send <- message:
+		telemetry.WebSocketMessagesSent.WithLabelValues(message.Type).Inc()
+		if stats != nil {
+			stats.sent.Add(1)
+		}
+		return true
+	default:
+		telemetry.WebSocketMessagesDropped.WithLabelValues(message.Type).Inc()
+		if stats == nil {
+			log.Printf("Dropped %s message: client send buffer full", message.Type)
+			return false
+		}
+		dropped := stats.dropped.Add(1)
+		now := time.Now().UnixNano()
+		if last := stats.lastDropLog.Load(); now-last >= int64(dropLogInterval) && stats.lastDropLog.CompareAndSwap(last, now) {
+			log.Printf("Dropped %s message: client send buffer full (%d dropped on this connection)", message.Type, dropped)
+		}
+		return false
+	}
+}
+
 // writeMessages writes messages from the send channel to the WebSocket
 func (h *WebSocketHandler) writeMessages(conn *websocket.Conn, ctx context.Context, send chan models.WebSocketMessage, cancel context.CancelFunc) {
 	ticker := time.NewTicker(30 * time.Second)
@@ -132,6 +214,9 @@ func (h *WebSocketHandler) readMessages(conn *websocket.Conn, ctx context.Contex
 
 // handleClientMessage handles messages received from the client
 func (h *WebSocketHandler) handleClientMessage(message models.WebSocketMessage, send chan models.WebSocketMessage, ctx context.Context) {
+	ctx, span := telemetry.StartSpan(ctx, "websocket "+message.Action)
+	defer span.End()
+
 	switch message.Action {
 	case "subscribe_pods":
 		// Client wants to subscribe to pod updates
@@ -151,12 +236,12 @@ func (h *WebSocketHandler) handleClientMessage(message models.WebSocketMessage,
 			return
 		}
 
-		send <- models.WebSocketMessage{
+		deliver(ctx, send, models.WebSocketMessage{
 			Type:      "metrics",
 			Action:    "update",
 			Data:      metrics,
 			Timestamp: time.Now(),
-		}
+		})
 
 	default:
 		log.Printf("Unknown action: %s", message.Action)
@@ -214,13 +299,13 @@ func (h *WebSocketHandler) watchPods(ctx context.Context, send chan models.WebSo
 			result = append(result, podData)
 		}
 
-		send <- models.WebSocketMessage{
+		deliver(ctx, send, models.WebSocketMessage{
 			Type:      "pods",
 			Action:    "initial",
 			Namespace: namespace,
 			Data:      result,
 			Timestamp: time.Now(),
-		}
+		})
 	}
 
 	// Watch for changes
@@ -233,6 +318,7 @@ func (h *WebSocketHandler) watchPods(ctx context.Context, send chan models.WebSo
 		case event, ok := <-watcher.ResultChan():
 			if !ok {
 				log.Printf("Pod watcher channel closed, restarting...")
+				telemetry.PodWatchRestarts.Inc()
 				// Restart watcher
 				time.Sleep(1 * time.Second)
 				h.watchPods(ctx, send, namespace)
@@ -303,15 +389,44 @@ func (h *WebSocketHandler) watchPods(ctx context.Context, send chan models.WebSo
 			}
 
 			// Send update to client
-			send <- models.WebSocketMessage{
+			enqueue(ctx, send, models.WebSocketMessage{
 				Type:      "pods",
 				Action:    eventType,
 				Namespace: namespace,
 				Data:      simplifiedPod,
 				Timestamp: time.Now(),
-			}
+			})
 
 			log.Printf("Pod %s: %v in namespace %s", eventType, metadata["name"], metadata["namespace"])
 		}
 	}
 }
+
+// forwardAlerts sends the active alerts, then each alert as it fires or
+// resolves, with the alert state as the action
+func (h *WebSocketHandler) forwardAlerts(ctx context.Context, send chan models.WebSocketMessage) {
+	updates, unsubscribe := h.alerts.Subscribe()
+	defer unsubscribe()
+
+	deliver(ctx, send, models.WebSocketMessage{
+		Type:      "alerts",
+		Action:    "list",
+		Data:      h.alerts.Active(),
+		Timestamp: time.Now(),
+	})
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case alert := <-updates:
+			enqueue(ctx, send, models.WebSocketMessage{
+				Type:      "alerts",
+				Action:    alert.State,
+				Namespace: alert.Labels["namespace"],
+				Data:      alert,
+				Timestamp: time.Now(),
+			})
+		}
+	}
+}
@@ -93,3 +93,43 @@ func TestHandleClientMessage_GetMetrics_SuccessSendsMetrics(t *testing.T) {
 		t.Fatalf("expected a metrics message but none received")
 	}
 }
+
+func TestDeliver_WaitsForSpaceWhereEnqueueDrops(t *testing.T) {
+	stats := &connStats{}
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), connStatsKey{}, stats))
+	defer cancel()
+
+	send := make(chan models.WebSocketMessage, 1)
+	send <- models.WebSocketMessage{Type: "pods", Action: "modified"}
+
+	if enqueue(ctx, send, models.WebSocketMessage{Type: "pods", Action: "modified"}) {
+		t.Fatal("expected enqueue to drop on a full buffer")
+	}
+	if stats.dropped.Load() != 1 {
+		t.Errorf("expected one dropped message, got %d", stats.dropped.Load())
+	}
+
+	delivered := make(chan bool)
+	go func() {
+		delivered <- deliver(ctx, send, models.WebSocketMessage{Type: "pods", Action: "initial"})
+	}()
+	select {
+	case <-delivered:
+		t.Fatal("deliver should wait while the buffer is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	<-send
+	if ok := <-delivered; !ok {
+		t.Fatal("expected deliver to succeed once space is available")
+	}
+	if message := <-send; message.Action != "initial" {
+		t.Errorf("expected the initial message, got %+v", message)
+	}
+
+	send <- models.WebSocketMessage{Type: "pods"}
+	cancel()
+	if deliver(ctx, send, models.WebSocketMessage{Type: "pods", Action: "initial"}) {
+		t.Error("expected deliver to give up once the connection closes")
+	}
+}
@@ -0,0 +1,39 @@
+// internal/middleware/identity.go
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/models"
+)
+
+// identityKey is the gin context key holding the caller's *models.Identity
+const identityKey = "identity"
+
+// ClientCertIdentity maps a verified client certificate to an identity.
+// The certificate CN becomes the identity name and each O becomes a group.
+func ClientCertIdentity() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		state := c.Request.TLS
+		// Only trust certificates that were verified against the client CA bundle
+		if state != nil && len(state.VerifiedChains) > 0 && len(state.VerifiedChains[0]) > 0 {
+			cert := state.VerifiedChains[0][0]
+			c.Set(identityKey, &models.Identity{
+				Name:   cert.Subject.CommonName,
+				Groups: cert.Subject.Organization,
+				Source: "client-cert",
+			})
+		}
+
+		c.Next()
+	}
+}
+
+// GetIdentity returns the identity attached to the request, if any
+func GetIdentity(c *gin.Context) (*models.Identity, bool) {
+	value, ok := c.Get(identityKey)
+	if !ok {
+		return nil, false
+	}
+	identity, ok := value.(*models.Identity)
+	return identity, ok
+}
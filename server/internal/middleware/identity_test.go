@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestClientCertIdentity_MapsVerifiedCertificate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ClientCertIdentity())
+	r.GET("/whoami", func(c *gin.Context) {
+		identity, ok := GetIdentity(c)
+		if !ok {
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+		c.JSON(http.StatusOK, identity)
+	})
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "alice", Organization: []string{"sre", "admins"}}}
+	req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if body := w.Body.String(); body != `{"name":"alice","groups":["sre","admins"],"source":"client-cert"}` {
+		t.Fatalf("unexpected identity: %s", body)
+	}
+}
+
+func TestClientCertIdentity_IgnoresUnverifiedCertificate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ClientCertIdentity())
+	r.GET("/whoami", func(c *gin.Context) {
+		if _, ok := GetIdentity(c); ok {
+			c.Status(http.StatusOK)
+			return
+		}
+		c.Status(http.StatusUnauthorized)
+	})
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "mallory"}}
+	req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for unverified certificate, got %d", w.Code)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/audit"
 )
 
 // Logger is a custom logging middleware
@@ -57,6 +58,8 @@ type LoggerConfig struct {
 	SkipPaths []string
 	// SkipPathRegexps is a list of regex patterns to skip logging
 	SkipPathRegexps []string
+	// Audit, when set, also records every logged request as an audit event
+	Audit *audit.Logger
 }
 
 func LoggerWithConfig(config LoggerConfig) gin.HandlerFunc {
@@ -99,5 +102,10 @@ func LoggerWithConfig(config LoggerConfig) gin.HandlerFunc {
 			path,
 			errorMessage,
 		)
+
+		if config.Audit != nil {
+			identity, _ := GetIdentity(c)
+			config.Audit.RecordRequest(c, identity, start)
+		}
 	}
 }
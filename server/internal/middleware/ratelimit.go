@@ -0,0 +1,148 @@
+// internal/middleware/ratelimit.go
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+// clientKey identifies the caller for per-client limits. Authenticated
+// identities share a budget across IPs; anonymous callers are keyed by IP.
+func clientKey(c *gin.Context) string {
+	if identity, ok := GetIdentity(c); ok && identity.Name != "" {
+		return "id:" + identity.Name
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// tooManyRequests aborts with 429 and a Retry-After header
+func tooManyRequests(c *gin.Context, retryAfter time.Duration, message string) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": message})
+}
+
+// maxRateLimitClients bounds the buckets RateLimit tracks so a flood of
+// distinct clients cannot grow memory without limit
+const maxRateLimitClients = 10000
+
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// RateLimit applies a token bucket of rps requests per second with the
+// given burst to each client. Idle buckets are forgotten after ten minutes,
+// and the least recently seen one when maxRateLimitClients is reached.
+func RateLimit(rps float64, burst int) gin.HandlerFunc {
+	var mu sync.Mutex
+	clients := make(map[string]*clientLimiter)
+	lastCleanup := time.Now()
+
+	return func(c *gin.Context) {
+		key := clientKey(c)
+		now := time.Now()
+
+		mu.Lock()
+		if now.Sub(lastCleanup) > time.Minute {
+			for k, cl := range clients {
+				if now.Sub(cl.lastSeen) > 10*time.Minute {
+					delete(clients, k)
+				}
+			}
+			lastCleanup = now
+		}
+		cl, ok := clients[key]
+		if !ok {
+			if len(clients) >= maxRateLimitClients {
+				evictOldestClient(clients)
+			}
+			cl = &clientLimiter{limiter: rate.NewLimiter(rate.Limit(rps), burst)}
+			clients[key] = cl
+		}
+		cl.lastSeen = now
+		mu.Unlock()
+
+		reservation := cl.limiter.ReserveN(now, 1)
+		if !reservation.OK() {
+			tooManyRequests(c, time.Second, "Rate limit exceeded")
+			return
+		}
+		if delay := reservation.DelayFrom(now); delay > 0 {
+			// Give the token back; the client is told when to retry instead
+			reservation.CancelAt(now)
+			tooManyRequests(c, delay, "Rate limit exceeded")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// evictOldestClient forgets the least recently seen client
+func evictOldestClient(clients map[string]*clientLimiter) {
+	oldestKey := ""
+	var oldest time.Time
+	for key, cl := range clients {
+		if oldestKey == "" || cl.lastSeen.Before(oldest) {
+			oldestKey, oldest = key, cl.lastSeen
+		}
+	}
+	delete(clients, oldestKey)
+}
+
+// ConcurrencyLimit caps how many requests passing through it run at the
+// same time across all clients. It is meant for expensive cluster-wide Lists.
+func ConcurrencyLimit(max int) gin.HandlerFunc {
+	slots := make(chan struct{}, max)
+
+	return func(c *gin.Context) {
+		select {
+		case slots <- struct{}{}:
+			defer func() { <-slots }()
+			c.Next()
+		default:
+			tooManyRequests(c, time.Second, "Too many concurrent expensive requests")
+		}
+	}
+}
+
+// ConnectionLimit caps how many long-lived connections (e.g. WebSockets)
+// each client may hold open at once
+func ConnectionLimit(max int) gin.HandlerFunc {
+	var mu sync.Mutex
+	open := make(map[string]int)
+
+	return func(c *gin.Context) {
+		key := clientKey(c)
+
+		mu.Lock()
+		if open[key] >= max {
+			mu.Unlock()
+			tooManyRequests(c, 5*time.Second, "Too many open connections")
+			return
+		}
+		open[key]++
+		mu.Unlock()
+
+		defer func() {
+			mu.Lock()
+			open[key]--
+			if open[key] <= 0 {
+				delete(open, key)
+			}
+			mu.Unlock()
+		}()
+
+		c.Next()
+	}
+}
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimit_Returns429WithRetryAfter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RateLimit(1, 2))
+	r.GET("/api/pods", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	codes := make([]int, 0, 3)
+	var last *httptest.ResponseRecorder
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/api/pods", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		last = httptest.NewRecorder()
+		r.ServeHTTP(last, req)
+		codes = append(codes, last.Code)
+	}
+
+	if codes[0] != http.StatusOK || codes[1] != http.StatusOK || codes[2] != http.StatusTooManyRequests {
+		t.Fatalf("expected 200,200,429 got %v", codes)
+	}
+	if last.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected Retry-After header on 429")
+	}
+
+	// A different client has its own bucket
+	req := httptest.NewRequest(http.MethodGet, "/api/pods", nil)
+	req.RemoteAddr = "10.0.0.2:1234"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected other client to be allowed, got %d", w.Code)
+	}
+}
+
+func TestConcurrencyLimit_RejectsWhenFull(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	r.GET("/api/metrics", ConcurrencyLimit(1), func(c *gin.Context) {
+		close(entered)
+		<-release
+		c.Status(http.StatusOK)
+	})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/metrics", nil))
+	}()
+	<-entered
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/metrics", nil))
+	close(release)
+	wg.Wait()
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 while slot is taken, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") != "1" {
+		t.Fatalf("expected Retry-After: 1, got %q", w.Header().Get("Retry-After"))
+	}
+}
+
+func TestRateLimit_IgnoresSpoofedForwardedFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	if err := r.SetTrustedProxies(nil); err != nil {
+		t.Fatal(err)
+	}
+	r.Use(RateLimit(1, 1))
+	r.GET("/api/pods", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	codes := make([]int, 0, 2)
+	for _, forwarded := range []string{"1.1.1.1", "2.2.2.2"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/pods", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-Forwarded-For", forwarded)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		codes = append(codes, w.Code)
+	}
+	if codes[0] != http.StatusOK || codes[1] != http.StatusTooManyRequests {
+		t.Fatalf("expected a new X-Forwarded-For not to reset the bucket, got %v", codes)
+	}
+}
+
+func TestRateLimit_EvictsOldestClientWhenFull(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RateLimit(0.001, 1))
+	r.GET("/api/pods", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	serve := func(ip string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/pods", nil)
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	serve("10.0.0.1")
+	if code := serve("10.0.0.1"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 for an exhausted client, got %d", code)
+	}
+	for i := 0; i < maxRateLimitClients; i++ {
+		serve(fmt.Sprintf("10.1.%d.%d", i/256, i%256))
+	}
+	// The first client was the least recently seen, so it starts over
+	if code := serve("10.0.0.1"); code != http.StatusOK {
+		t.Fatalf("expected evicted client to get a new bucket, got %d", code)
+	}
+}
@@ -29,6 +29,7 @@ type PodResponse struct {
 	Namespace       string            `json:"namespace"`
 	Status          string            `json:"status"`
 	StatusDetail    string            `json:"status_detail,omitempty"`
+	Diagnoses       []Diagnosis       `json:"diagnoses,omitempty"`
 	Phase           string            `json:"phase"`
 	ReadyContainers string            `json:"ready_containers"`
 	Node            string            `json:"node"`
@@ -128,3 +129,27 @@ type WatchEvent struct {
 	Type   string      `json:"type"` // ADDED, MODIFIED, DELETED
 	Object interface{} `json:"object"`
 }
+
+// Identity represents the authenticated caller of a request
+type Identity struct {
+	Name   string   `json:"name"`
+	Groups []string `json:"groups,omitempty"`
+	Source string   `json:"source"` // e.g. "client-cert"
+}
+
+// Diagnosis severities, most severe first
+const (
+	SeverityCritical = "critical"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+)
+
+// Diagnosis is a human-readable explanation of a pod problem
+type Diagnosis struct {
+	Code      string   `json:"code"` // e.g. "CrashLoopBackOff", "ImagePullBackOff"
+	Severity  string   `json:"severity"`
+	Container string   `json:"container,omitempty"`
+	Summary   string   `json:"summary"`
+	Detail    string   `json:"detail,omitempty"`
+	NextSteps []string `json:"next_steps"`
+}
@@ -5,15 +5,27 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 // Config holds the application configuration
 type Config struct {
-	Server     ServerConfig
-	Kubernetes KubernetesConfig
-	WebSocket  WebSocketConfig
-	Logging    LoggingConfig
+	Server      ServerConfig
+	TLS         TLSConfig
+	Kubernetes  KubernetesConfig
+	WebSocket   WebSocketConfig
+	Logging     LoggingConfig
+	Audit       AuditConfig
+	RateLimit   RateLimitConfig
+	Tracing     TracingConfig
+	History     HistoryConfig
+	Snapshots   SnapshotConfig
+	Timeline    TimelineConfig
+	Rightsizing RightsizingConfig
+	Alerts      AlertsConfig
+	Vulns       VulnConfig
+	Secrets     SecretsConfig
 }
 
 // ServerConfig holds server-related configuration
@@ -23,6 +35,38 @@ type ServerConfig struct {
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
 	Mode         string // "debug" or "release"
+	MetricsPath  string // Prometheus endpoint; must not collide with /api/metrics
+	// TrustedProxies may set X-Forwarded-For; empty trusts none so the
+	// client IP is always the connection's remote address
+	TrustedProxies []string
+	// ShutdownTimeout bounds draining requests and flushing on SIGTERM
+	ShutdownTimeout time.Duration
+}
+
+// TLSConfig holds HTTPS and client-certificate configuration
+type TLSConfig struct {
+	CertFile       string
+	KeyFile        string
+	ClientCAFile   string        // CA bundle used to verify client certificates
+	ClientAuth     string        // "none", "request", "verify-if-given" or "require"; see ClientAuthMode
+	ReloadInterval time.Duration // How often the cert/key/CA files are checked for rotation
+}
+
+// Enabled reports whether the server should serve HTTPS
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != "" && t.KeyFile != ""
+}
+
+// ClientAuthMode returns the client certificate mode. It defaults to
+// "require" when a client CA is set and to "none" otherwise.
+func (t TLSConfig) ClientAuthMode() string {
+	if t.ClientAuth != "" {
+		return t.ClientAuth
+	}
+	if t.ClientCAFile != "" {
+		return "require"
+	}
+	return "none"
 }
 
 // KubernetesConfig holds Kubernetes client configuration
@@ -50,15 +94,102 @@ type LoggingConfig struct {
 	OutputPath string
 }
 
+// AuditConfig holds audit trail configuration
+type AuditConfig struct {
+	Sink        string // "", "file" or "webhook"; empty disables auditing
+	FilePath    string
+	MaxSizeMB   int
+	MaxBackups  int
+	WebhookURL  string
+	Cluster     string   // Cluster name stamped on every event
+	Retain      int      // Number of events kept in memory for queries
+	AdminGroups []string // Identity groups allowed to query the audit log
+}
+
+// RateLimitConfig holds per-client and global request limits.
+// A zero value disables the corresponding limit.
+type RateLimitConfig struct {
+	RequestsPerSecond      float64
+	Burst                  int
+	MaxConcurrentExpensive int // Cluster-wide Lists such as /api/metrics
+	MaxWebSocketsPerClient int
+}
+
+// TracingConfig holds OpenTelemetry tracing configuration
+type TracingConfig struct {
+	Exporter    string // "", "otlp" or "stdout"; empty disables tracing
+	Endpoint    string // OTLP/HTTP collector host:port
+	Insecure    bool   // Use plain HTTP for the OTLP endpoint
+	ServiceName string
+	SampleRatio float64
+}
+
+// HistoryConfig holds the metrics history sampler configuration
+type HistoryConfig struct {
+	Enabled bool
+	Tiers   string // "step:retention" pairs, finest first, e.g. "10s:1h,1m:24h"
+	// Interval is how often metrics are sampled; zero uses the finest tier step
+	Interval time.Duration
+}
+
+// SnapshotConfig holds the persistent cluster snapshot configuration
+type SnapshotConfig struct {
+	Path     string        // bbolt database file; empty disables snapshots
+	Interval time.Duration // Scheduled snapshot interval; 0 means on demand only
+	MaxCount int
+	MaxAge   time.Duration
+}
+
+// TimelineConfig holds the per-object change timeline configuration
+type TimelineConfig struct {
+	Enabled    bool
+	MaxEntries int // Entries kept per object
+	MaxObjects int // Objects tracked before the least recently changed is dropped
+}
+
+// RightsizingConfig holds the metrics-server usage sampler configuration
+type RightsizingConfig struct {
+	Enabled  bool
+	Interval time.Duration
+	Window   time.Duration // Usage older than this is forgotten
+}
+
+// AlertsConfig holds the alerting rules engine configuration
+type AlertsConfig struct {
+	RulesFile string // YAML rules file; alerting is disabled when empty
+}
+
+// VulnConfig holds the offline vulnerability matching configuration
+type VulnConfig struct {
+	DBFile string // OSV JSON export; matching is disabled when empty
+}
+
+// SecretsConfig holds the policy for revealing Secret values. Both lists
+// are empty by default, so values are always redacted.
+type SecretsConfig struct {
+	RevealGroups   []string // Identity groups allowed to request values
+	RevealPatterns []string // "namespace/name" globs whose values may be revealed
+}
+
 // Load loads configuration from environment variables
 func Load() *Config {
 	return &Config{
 		Server: ServerConfig{
-			Host:         getEnv("SERVER_HOST", "0.0.0.0"),
-			Port:         getEnv("SERVER_PORT", "8080"),
-			ReadTimeout:  getDurationEnv("SERVER_READ_TIMEOUT", 15*time.Second),
-			WriteTimeout: getDurationEnv("SERVER_WRITE_TIMEOUT", 15*time.Second),
-			Mode:         getEnv("GIN_MODE", "debug"),
+			Host:            getEnv("SERVER_HOST", "0.0.0.0"),
+			Port:            getEnv("SERVER_PORT", "8080"),
+			ReadTimeout:     getDurationEnv("SERVER_READ_TIMEOUT", 15*time.Second),
+			WriteTimeout:    getDurationEnv("SERVER_WRITE_TIMEOUT", 15*time.Second),
+			Mode:            getEnv("GIN_MODE", "debug"),
+			MetricsPath:     getEnv("SERVER_METRICS_PATH", "/metrics"),
+			TrustedProxies:  getListEnv("SERVER_TRUSTED_PROXIES", nil),
+			ShutdownTimeout: getDurationEnv("SERVER_SHUTDOWN_TIMEOUT", 15*time.Second),
+		},
+		TLS: TLSConfig{
+			CertFile:       getEnv("TLS_CERT_FILE", ""),
+			KeyFile:        getEnv("TLS_KEY_FILE", ""),
+			ClientCAFile:   getEnv("TLS_CLIENT_CA_FILE", ""),
+			ClientAuth:     getEnv("TLS_CLIENT_AUTH", ""),
+			ReloadInterval: getDurationEnv("TLS_RELOAD_INTERVAL", 30*time.Second),
 		},
 		Kubernetes: KubernetesConfig{
 			KubeConfig: getEnv("KUBECONFIG", ""),
@@ -79,6 +210,60 @@ func Load() *Config {
 			Format:     getEnv("LOG_FORMAT", "text"),
 			OutputPath: getEnv("LOG_OUTPUT_PATH", "stdout"),
 		},
+		Audit: AuditConfig{
+			Sink:        getEnv("AUDIT_SINK", ""),
+			FilePath:    getEnv("AUDIT_FILE_PATH", "audit.log"),
+			MaxSizeMB:   getIntEnv("AUDIT_MAX_SIZE_MB", 100),
+			MaxBackups:  getIntEnv("AUDIT_MAX_BACKUPS", 5),
+			WebhookURL:  getEnv("AUDIT_WEBHOOK_URL", ""),
+			Cluster:     getEnv("AUDIT_CLUSTER_NAME", "default"),
+			Retain:      getIntEnv("AUDIT_RETAIN", 1000),
+			AdminGroups: getListEnv("AUDIT_ADMIN_GROUPS", []string{"admins"}),
+		},
+		RateLimit: RateLimitConfig{
+			RequestsPerSecond:      float64(getFloat32Env("RATE_LIMIT_RPS", 10)),
+			Burst:                  getIntEnv("RATE_LIMIT_BURST", 20),
+			MaxConcurrentExpensive: getIntEnv("RATE_LIMIT_MAX_CONCURRENT_EXPENSIVE", 4),
+			MaxWebSocketsPerClient: getIntEnv("RATE_LIMIT_MAX_WS_PER_CLIENT", 5),
+		},
+		Tracing: TracingConfig{
+			Exporter:    getEnv("TRACING_EXPORTER", ""),
+			Endpoint:    getEnv("TRACING_OTLP_ENDPOINT", "localhost:4318"),
+			Insecure:    getBoolEnv("TRACING_INSECURE", true),
+			ServiceName: getEnv("TRACING_SERVICE_NAME", "k8s-visualizer-server"),
+			SampleRatio: float64(getFloat32Env("TRACING_SAMPLE_RATIO", 1.0)),
+		},
+		History: HistoryConfig{
+			Enabled:  getBoolEnv("METRICS_HISTORY_ENABLED", true),
+			Tiers:    getEnv("METRICS_HISTORY_TIERS", "10s:1h,1m:24h"),
+			Interval: getDurationEnv("METRICS_HISTORY_INTERVAL", 0),
+		},
+		Snapshots: SnapshotConfig{
+			Path:     getEnv("SNAPSHOT_DB_PATH", ""),
+			Interval: getDurationEnv("SNAPSHOT_INTERVAL", time.Hour),
+			MaxCount: getIntEnv("SNAPSHOT_MAX_COUNT", 168),
+			MaxAge:   getDurationEnv("SNAPSHOT_MAX_AGE", 7*24*time.Hour),
+		},
+		Timeline: TimelineConfig{
+			Enabled:    getBoolEnv("TIMELINE_ENABLED", true),
+			MaxEntries: getIntEnv("TIMELINE_MAX_ENTRIES", 100),
+			MaxObjects: getIntEnv("TIMELINE_MAX_OBJECTS", 5000),
+		},
+		Rightsizing: RightsizingConfig{
+			Enabled:  getBoolEnv("RIGHTSIZING_ENABLED", true),
+			Interval: getDurationEnv("RIGHTSIZING_INTERVAL", time.Minute),
+			Window:   getDurationEnv("RIGHTSIZING_WINDOW", 24*time.Hour),
+		},
+		Alerts: AlertsConfig{
+			RulesFile: getEnv("ALERT_RULES_FILE", ""),
+		},
+		Vulns: VulnConfig{
+			DBFile: getEnv("VULN_DB_FILE", ""),
+		},
+		Secrets: SecretsConfig{
+			RevealGroups:   getListEnv("SECRETS_REVEAL_GROUPS", nil),
+			RevealPatterns: getListEnv("SECRETS_REVEAL_PATTERNS", nil),
+		},
 	}
 }
 
@@ -144,14 +329,38 @@ func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
 	return duration
 }
 
+func getListEnv(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // Print logs the configuration (sanitized)
 func (c *Config) Print() {
 	log.Println("=== Configuration ===")
 	log.Printf("Server Host: %s", c.Server.Host)
 	log.Printf("Server Port: %s", c.Server.Port)
 	log.Printf("Server Mode: %s", c.Server.Mode)
+	log.Printf("TLS Enabled: %t", c.TLS.Enabled())
+	if c.TLS.Enabled() {
+		log.Printf("TLS Client Auth: %s", c.TLS.ClientAuthMode())
+	}
 	log.Printf("K8s In-Cluster: %t", c.Kubernetes.InCluster)
 	log.Printf("K8s Default Namespace: %s", c.Kubernetes.Namespace)
 	log.Printf("Log Level: %s", c.Logging.Level)
+	if c.Tracing.Exporter != "" {
+		log.Printf("Tracing Exporter: %s", c.Tracing.Exporter)
+	}
+	if c.Audit.Sink != "" {
+		log.Printf("Audit Sink: %s", c.Audit.Sink)
+	}
 	log.Println("====================")
 }
@@ -0,0 +1,235 @@
+// internal/services/allocation.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// NodeAllocation is how much of a node is promised to its pods
+type NodeAllocation struct {
+	Name              string `json:"name"`
+	Status            string `json:"status"`
+	Schedulable       bool   `json:"schedulable"`
+	CPUAllocatable    string `json:"cpu_allocatable"`
+	MemoryAllocatable string `json:"memory_allocatable"`
+	CPURequests       string `json:"cpu_requests"`
+	CPULimits         string `json:"cpu_limits"`
+	MemoryRequests    string `json:"memory_requests"`
+	MemoryLimits      string `json:"memory_limits"`
+	CPUFree           string `json:"cpu_free"`    // Allocatable minus requests
+	MemoryFree        string `json:"memory_free"` // Allocatable minus requests
+
+	// Ratios to allocatable; limit ratios above 1 mean the node is overcommitted
+	CPURequestRatio    float64 `json:"cpu_request_ratio"`
+	MemoryRequestRatio float64 `json:"memory_request_ratio"`
+	CPULimitRatio      float64 `json:"cpu_limit_ratio"`
+	MemoryLimitRatio   float64 `json:"memory_limit_ratio"`
+
+	PodCount      int     `json:"pod_count"`
+	PodCapacity   int64   `json:"pod_capacity"`
+	PodSlotsRatio float64 `json:"pod_slots_ratio"`
+
+	// Unformatted values used to rank nodes
+	cpuFree        resource.Quantity
+	memoryFree     resource.Quantity
+	hasFreePodSlot bool
+}
+
+// LargestFit is the biggest request that still fits on some node
+type LargestFit struct {
+	CPU                 string `json:"cpu"`
+	CPUNode             string `json:"cpu_node,omitempty"`
+	MemoryFreeOnCPUNode string `json:"cpu_node_memory_free,omitempty"`
+	Memory              string `json:"memory"`
+	MemoryNode          string `json:"memory_node,omitempty"`
+	CPUFreeOnMemoryNode string `json:"memory_node_cpu_free,omitempty"`
+}
+
+// AllocationReport summarizes requests, limits and free capacity per node
+// and for the cluster
+type AllocationReport struct {
+	Nodes              []NodeAllocation `json:"nodes"`
+	CPURequestRatio    float64          `json:"cpu_request_ratio"`
+	MemoryRequestRatio float64          `json:"memory_request_ratio"`
+	CPULimitRatio      float64          `json:"cpu_limit_ratio"`
+	MemoryLimitRatio   float64          `json:"memory_limit_ratio"`
+	PodSlotsRatio      float64          `json:"pod_slots_ratio"`
+	OvercommitNodes    []string         `json:"overcommitted_nodes"`
+
+	// Fragmentation is 0 when all free capacity sits on one node and
+	// approaches 1 as it is spread thinly across many nodes
+	CPUFragmentation    float64    `json:"cpu_fragmentation"`
+	MemoryFragmentation float64    `json:"memory_fragmentation"`
+	Fragmentation       float64    `json:"fragmentation"`
+	LargestFit          LargestFit `json:"largest_pod_that_fits"`
+}
+
+// GetAllocationReport lists nodes and pods and builds an AllocationReport
+func GetAllocationReport(ctx context.Context, clientset kubernetes.Interface) (*AllocationReport, error) {
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	pods, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	return BuildAllocationReport(nodes.Items, pods.Items), nil
+}
+
+// BuildAllocationReport computes per-node allocation, overcommit,
+// fragmentation and the largest pod that still fits. Only Ready,
+// schedulable nodes count towards free capacity.
+func BuildAllocationReport(nodes []corev1.Node, pods []corev1.Pod) *AllocationReport {
+	byNode := podsByNode(pods)
+	report := &AllocationReport{
+		Nodes:           make([]NodeAllocation, 0, len(nodes)),
+		OvercommitNodes: make([]string, 0),
+	}
+
+	var cluster resourceTotals
+	var clusterCPU, clusterMemory resource.Quantity
+	var clusterPods, clusterSlots int64
+
+	for i := range nodes {
+		node := &nodes[i]
+		totals := nodeResources(byNode[node.Name])
+		cpu := node.Status.Allocatable.Cpu().DeepCopy()
+		memory := node.Status.Allocatable.Memory().DeepCopy()
+
+		running := 0
+		for _, pod := range byNode[node.Name] {
+			if !podIsTerminal(pod) {
+				running++
+			}
+		}
+
+		allocation := NodeAllocation{
+			Name:               node.Name,
+			Status:             nodeReadyStatus(node),
+			Schedulable:        !node.Spec.Unschedulable,
+			CPUAllocatable:     cpu.String(),
+			MemoryAllocatable:  memory.String(),
+			CPURequests:        totals.CPURequest.String(),
+			CPULimits:          totals.CPULimit.String(),
+			MemoryRequests:     totals.MemoryRequest.String(),
+			MemoryLimits:       totals.MemoryLimit.String(),
+			CPURequestRatio:    quantityRatio(totals.CPURequest, cpu),
+			MemoryRequestRatio: quantityRatio(totals.MemoryRequest, memory),
+			CPULimitRatio:      quantityRatio(totals.CPULimit, cpu),
+			MemoryLimitRatio:   quantityRatio(totals.MemoryLimit, memory),
+			PodCount:           running,
+			PodCapacity:        node.Status.Allocatable.Pods().Value(),
+		}
+		allocation.PodSlotsRatio = ratio(float64(running), float64(allocation.PodCapacity))
+		allocation.hasFreePodSlot = int64(running) < allocation.PodCapacity
+
+		allocation.cpuFree = freeQuantity(cpu, totals.CPURequest)
+		allocation.memoryFree = freeQuantity(memory, totals.MemoryRequest)
+		allocation.CPUFree = allocation.cpuFree.String()
+		allocation.MemoryFree = allocation.memoryFree.String()
+
+		if allocation.CPULimitRatio > 1 || allocation.MemoryLimitRatio > 1 {
+			report.OvercommitNodes = append(report.OvercommitNodes, node.Name)
+		}
+
+		cluster.add(totals)
+		clusterCPU.Add(cpu)
+		clusterMemory.Add(memory)
+		clusterPods += int64(running)
+		clusterSlots += allocation.PodCapacity
+		report.Nodes = append(report.Nodes, allocation)
+	}
+
+	report.CPURequestRatio = quantityRatio(cluster.CPURequest, clusterCPU)
+	report.MemoryRequestRatio = quantityRatio(cluster.MemoryRequest, clusterMemory)
+	report.CPULimitRatio = quantityRatio(cluster.CPULimit, clusterCPU)
+	report.MemoryLimitRatio = quantityRatio(cluster.MemoryLimit, clusterMemory)
+	report.PodSlotsRatio = ratio(float64(clusterPods), float64(clusterSlots))
+
+	// Free capacity only counts where a new pod could actually land
+	usable := make([]*NodeAllocation, 0, len(report.Nodes))
+	for i := range report.Nodes {
+		n := &report.Nodes[i]
+		if n.Status == "Ready" && n.Schedulable && n.hasFreePodSlot {
+			usable = append(usable, n)
+		}
+	}
+	report.CPUFragmentation = fragmentation(usable, func(n *NodeAllocation) resource.Quantity { return n.cpuFree })
+	report.MemoryFragmentation = fragmentation(usable, func(n *NodeAllocation) resource.Quantity { return n.memoryFree })
+	report.Fragmentation = (report.CPUFragmentation + report.MemoryFragmentation) / 2
+	report.LargestFit = largestFit(usable)
+
+	sort.Slice(report.Nodes, func(i, j int) bool { return report.Nodes[i].Name < report.Nodes[j].Name })
+	return report
+}
+
+// fragmentation is 1 minus the share of the free capacity held by the
+// node with the most of it
+func fragmentation(nodes []*NodeAllocation, free func(*NodeAllocation) resource.Quantity) float64 {
+	var total, largest int64
+	for _, n := range nodes {
+		value := free(n)
+		milli := value.MilliValue()
+		total += milli
+		if milli > largest {
+			largest = milli
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return round2(1 - float64(largest)/float64(total))
+}
+
+func largestFit(nodes []*NodeAllocation) LargestFit {
+	fit := LargestFit{CPU: "0", Memory: "0"}
+	var cpu, memory *NodeAllocation
+	for _, n := range nodes {
+		if cpu == nil || n.cpuFree.Cmp(cpu.cpuFree) > 0 {
+			cpu = n
+		}
+		if memory == nil || n.memoryFree.Cmp(memory.memoryFree) > 0 {
+			memory = n
+		}
+	}
+	if cpu != nil {
+		fit.CPU, fit.CPUNode, fit.MemoryFreeOnCPUNode = cpu.CPUFree, cpu.Name, cpu.MemoryFree
+	}
+	if memory != nil {
+		fit.Memory, fit.MemoryNode, fit.CPUFreeOnMemoryNode = memory.MemoryFree, memory.Name, memory.CPUFree
+	}
+	return fit
+}
+
+// freeQuantity is allocatable minus requested, floored at zero
+func freeQuantity(allocatable, requested resource.Quantity) resource.Quantity {
+	free := allocatable.DeepCopy()
+	free.Sub(requested)
+	if free.Sign() < 0 {
+		return resource.Quantity{}
+	}
+	return free
+}
+
+func quantityRatio(used, total resource.Quantity) float64 {
+	return ratio(float64(used.MilliValue()), float64(total.MilliValue()))
+}
+
+func ratio(used, total float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return round2(used / total)
+}
+
+func round2(v float64) float64 {
+	return float64(int64(v*100+0.5)) / 100
+}
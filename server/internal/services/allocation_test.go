@@ -0,0 +1,73 @@
+package services
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func allocationPod(name, node, cpuRequest, cpuLimit, memoryRequest string) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: corev1.PodSpec{NodeName: node, Containers: []corev1.Container{{
+			Name: "app",
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpuRequest), corev1.ResourceMemory: resource.MustParse(memoryRequest)},
+				Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpuLimit)},
+			},
+		}}},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+func allocationNode(name string) corev1.Node {
+	return corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{
+			Allocatable: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("2"),
+				corev1.ResourceMemory: resource.MustParse("4Gi"),
+				corev1.ResourcePods:   resource.MustParse("10"),
+			},
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	}
+}
+
+func TestBuildAllocationReport(t *testing.T) {
+	cordoned := allocationNode("node-c")
+	cordoned.Spec.Unschedulable = true
+	nodes := []corev1.Node{allocationNode("node-a"), allocationNode("node-b"), cordoned}
+
+	done := allocationPod("job", "node-a", "1", "1", "1Gi")
+	done.Status.Phase = corev1.PodSucceeded
+	pods := []corev1.Pod{
+		allocationPod("web-1", "node-a", "1500m", "3", "1Gi"),
+		allocationPod("web-2", "node-b", "500m", "1", "3Gi"),
+		done,
+	}
+
+	report := BuildAllocationReport(nodes, pods)
+
+	a := report.Nodes[0]
+	if a.Name != "node-a" || a.CPURequests != "1500m" || a.CPURequestRatio != 0.75 || a.CPULimitRatio != 1.5 || a.PodCount != 1 {
+		t.Fatalf("unexpected node-a allocation: %+v", a)
+	}
+	if a.PodSlotsRatio != 0.1 {
+		t.Fatalf("expected 1 of 10 pod slots used, got %v", a.PodSlotsRatio)
+	}
+	if len(report.OvercommitNodes) != 1 || report.OvercommitNodes[0] != "node-a" {
+		t.Fatalf("expected node-a to be overcommitted, got %v", report.OvercommitNodes)
+	}
+
+	// The cordoned node's 2 free CPUs do not count; node-b holds 1.5 of 2 free
+	fit := report.LargestFit
+	if fit.CPU != "1500m" || fit.CPUNode != "node-b" || fit.Memory != "3Gi" || fit.MemoryNode != "node-a" {
+		t.Fatalf("unexpected largest fit: %+v", fit)
+	}
+	if report.CPUFragmentation != 0.25 {
+		t.Fatalf("expected cpu fragmentation 0.25, got %v", report.CPUFragmentation)
+	}
+}
@@ -0,0 +1,405 @@
+// internal/services/analyzer.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mugayoshi/k8s-visualizer/server/internal/models"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// missingRefPattern matches kubelet messages such as
+// `configmap "app-config" not found` or `secret "db" not found`
+var missingRefPattern = regexp.MustCompile(`(?i)(configmap|secret) "([^"]+)" not found`)
+
+// DiagnosePod lists the pod's events when it looks unhealthy and analyzes it
+func DiagnosePod(ctx context.Context, clientset kubernetes.Interface, pod *corev1.Pod) ([]models.Diagnosis, error) {
+	if !PodNeedsAttention(pod) {
+		return AnalyzePod(pod, nil), nil
+	}
+	events, err := ListPodEvents(ctx, clientset, pod)
+	if err != nil {
+		return nil, err
+	}
+	return AnalyzePod(pod, events), nil
+}
+
+// ListPodEvents lists the events about one pod with a field selector
+func ListPodEvents(ctx context.Context, clientset kubernetes.Interface, pod *corev1.Pod) ([]corev1.Event, error) {
+	events, err := clientset.CoreV1().Events(pod.Namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: "involvedObject.kind=Pod,involvedObject.name=" + pod.Name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	return PodEvents(pod, events.Items), nil
+}
+
+// PodEvents returns the events from events that are about pod
+func PodEvents(pod *corev1.Pod, events []corev1.Event) []corev1.Event {
+	result := make([]corev1.Event, 0)
+	for _, event := range events {
+		if event.InvolvedObject.Kind == "Pod" && event.InvolvedObject.Namespace == pod.Namespace && event.InvolvedObject.Name == pod.Name {
+			result = append(result, event)
+		}
+	}
+	return result
+}
+
+// PodNeedsAttention reports whether a pod is anything other than running
+// with all containers ready, or completed
+func PodNeedsAttention(pod *corev1.Pod) bool {
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded:
+		return false
+	case corev1.PodRunning:
+		for _, status := range pod.Status.ContainerStatuses {
+			if !status.Ready {
+				return true
+			}
+		}
+		return !isPodReady(pod) && len(pod.Status.Conditions) > 0
+	}
+	return true
+}
+
+// AnalyzePod inspects container statuses, termination states, conditions
+// and the given events, and returns diagnoses ordered by severity
+func AnalyzePod(pod *corev1.Pod, events []corev1.Event) []models.Diagnosis {
+	diagnoses := make([]models.Diagnosis, 0)
+	seen := make(map[string]bool)
+	add := func(d models.Diagnosis) {
+		key := d.Code + "/" + d.Container
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		diagnoses = append(diagnoses, d)
+	}
+
+	if pod.Status.Reason == "Evicted" {
+		add(models.Diagnosis{
+			Code:     "Evicted",
+			Severity: models.SeverityWarning,
+			Summary:  "Pod was evicted",
+			Detail:   pod.Status.Message,
+			NextSteps: []string{
+				"Check the node for memory or disk pressure",
+				"Set resource requests so the pod is not the first to be evicted",
+				"Delete the evicted pod once investigated; its controller has already replaced it",
+			},
+		})
+	}
+
+	if d, ok := diagnoseScheduling(pod, events); ok {
+		add(d)
+	}
+
+	limits := make(map[string]corev1.ResourceList)
+	probes := make(map[string]bool)
+	for _, container := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+		limits[container.Name] = container.Resources.Limits
+		probes[container.Name] = container.ReadinessProbe != nil
+	}
+
+	statuses := append(append([]corev1.ContainerStatus(nil), pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		for _, d := range diagnoseContainer(pod, status, limits[status.Name]) {
+			add(d)
+		}
+		if status.State.Running != nil && !status.Ready && probes[status.Name] {
+			add(readinessDiagnosis(status.Name, events))
+		}
+	}
+
+	for _, event := range events {
+		switch {
+		case event.Reason == "FailedMount":
+			if d, ok := missingRefDiagnosis("", event.Message); ok {
+				add(d)
+			}
+		case event.Reason == "Unhealthy" && strings.Contains(event.Message, "Liveness probe failed"):
+			add(models.Diagnosis{
+				Code:     "LivenessProbeFailing",
+				Severity: models.SeverityWarning,
+				Summary:  "Liveness probe is failing, so the kubelet keeps restarting the container",
+				Detail:   event.Message,
+				NextSteps: []string{
+					"Check the probe path, port and timeout match what the application serves",
+					"Raise initialDelaySeconds or use a startupProbe if the app starts slowly",
+				},
+			})
+		}
+	}
+
+	sort.SliceStable(diagnoses, func(i, j int) bool {
+		return severityRank(diagnoses[i].Severity) < severityRank(diagnoses[j].Severity)
+	})
+	return diagnoses
+}
+
+// StatusDetail is a one-line summary of the most severe diagnosis
+func StatusDetail(diagnoses []models.Diagnosis) string {
+	if len(diagnoses) == 0 {
+		return ""
+	}
+	return diagnoses[0].Summary
+}
+
+func severityRank(severity string) int {
+	switch severity {
+	case models.SeverityCritical:
+		return 0
+	case models.SeverityWarning:
+		return 1
+	}
+	return 2
+}
+
+// diagnoseScheduling explains why a pending pod has not been placed
+func diagnoseScheduling(pod *corev1.Pod, events []corev1.Event) (models.Diagnosis, bool) {
+	if pod.Status.Phase != corev1.PodPending || pod.Spec.NodeName != "" {
+		return models.Diagnosis{}, false
+	}
+
+	var reason string
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse && condition.Reason == corev1.PodReasonUnschedulable {
+			reason = condition.Message
+		}
+	}
+	for _, event := range events {
+		if reason == "" && event.Reason == "FailedScheduling" {
+			reason = event.Message
+		}
+	}
+	if reason == "" {
+		return models.Diagnosis{}, false
+	}
+
+	steps := make([]string, 0)
+	lower := strings.ToLower(reason)
+	if strings.Contains(lower, "insufficient") {
+		steps = append(steps, "Lower the pod's resource requests or add node capacity")
+	}
+	if strings.Contains(lower, "taint") {
+		steps = append(steps, "Add a matching toleration or remove the taint from the nodes")
+	}
+	if strings.Contains(lower, "affinity") || strings.Contains(lower, "selector") {
+		steps = append(steps, "Check nodeSelector and affinity rules against the node labels")
+	}
+	if strings.Contains(lower, "persistentvolumeclaim") || strings.Contains(lower, "volume") {
+		steps = append(steps, "Check the pod's PersistentVolumeClaims are bound and in a reachable zone")
+	}
+	if strings.Contains(lower, "unschedulable") {
+		steps = append(steps, "Uncordon nodes that were drained for maintenance")
+	}
+	if len(steps) == 0 {
+		steps = append(steps, "Read the scheduler message and compare the pod spec to the available nodes")
+	}
+
+	return models.Diagnosis{
+		Code:      "Unschedulable",
+		Severity:  models.SeverityCritical,
+		Summary:   "Pod cannot be scheduled",
+		Detail:    reason,
+		NextSteps: steps,
+	}, true
+}
+
+// diagnoseContainer explains waiting and terminated container states
+func diagnoseContainer(pod *corev1.Pod, status corev1.ContainerStatus, limits corev1.ResourceList) []models.Diagnosis {
+	diagnoses := make([]models.Diagnosis, 0)
+	logsHint := fmt.Sprintf("Read the previous container's logs: kubectl logs -p %s -c %s -n %s", pod.Name, status.Name, pod.Namespace)
+	last := status.LastTerminationState.Terminated
+
+	if waiting := status.State.Waiting; waiting != nil {
+		switch waiting.Reason {
+		case "CrashLoopBackOff":
+			d := models.Diagnosis{
+				Code:      "CrashLoopBackOff",
+				Severity:  models.SeverityCritical,
+				Container: status.Name,
+				Summary:   fmt.Sprintf("Container %s is crash looping", status.Name),
+				Detail:    fmt.Sprintf("Restarted %d times", status.RestartCount),
+				NextSteps: []string{logsHint},
+			}
+			if last != nil {
+				d.Summary += " (" + exitDescription(last) + ")"
+				d.Detail += "; " + exitDescription(last)
+				d.NextSteps = append(exitNextSteps(last, limits), d.NextSteps...)
+			}
+			diagnoses = append(diagnoses, d)
+
+		case "ImagePullBackOff", "ErrImagePull", "InvalidImageName":
+			ref := ParseImageRef(status.Image)
+			steps := []string{
+				fmt.Sprintf("Check that tag %q exists in %s", ref.Version(), ref.FullRepository()),
+			}
+			lower := strings.ToLower(waiting.Message)
+			if strings.Contains(lower, "unauthorized") || strings.Contains(lower, "denied") || strings.Contains(lower, "authentication") {
+				steps = append(steps, "Add or fix imagePullSecrets for the registry")
+			}
+			if waiting.Reason == "InvalidImageName" {
+				steps = []string{"Fix the image reference; it is not a valid name"}
+			}
+			diagnoses = append(diagnoses, models.Diagnosis{
+				Code:      "ImagePullBackOff",
+				Severity:  models.SeverityCritical,
+				Container: status.Name,
+				Summary:   fmt.Sprintf("Image %s cannot be pulled", status.Image),
+				Detail:    waiting.Message,
+				NextSteps: steps,
+			})
+
+		case "CreateContainerConfigError":
+			if d, ok := missingRefDiagnosis(status.Name, waiting.Message); ok {
+				diagnoses = append(diagnoses, d)
+			} else {
+				diagnoses = append(diagnoses, models.Diagnosis{
+					Code:      "CreateContainerConfigError",
+					Severity:  models.SeverityCritical,
+					Container: status.Name,
+					Summary:   fmt.Sprintf("Container %s configuration is invalid", status.Name),
+					Detail:    waiting.Message,
+					NextSteps: []string{"Check env, envFrom and volume references in the pod spec"},
+				})
+			}
+
+		case "CreateContainerError", "RunContainerError":
+			diagnoses = append(diagnoses, models.Diagnosis{
+				Code:      waiting.Reason,
+				Severity:  models.SeverityCritical,
+				Container: status.Name,
+				Summary:   fmt.Sprintf("Container %s failed to start", status.Name),
+				Detail:    waiting.Message,
+				NextSteps: []string{"Check the container command, entrypoint and mounts"},
+			})
+		}
+		return diagnoses
+	}
+
+	// Running again after a crash: report the last termination
+	if status.State.Running != nil && last != nil && last.Reason != "Completed" {
+		severity := models.SeverityInfo
+		if last.Reason == "OOMKilled" || status.RestartCount >= 3 {
+			severity = models.SeverityWarning
+		}
+		diagnoses = append(diagnoses, models.Diagnosis{
+			Code:      "Restarted",
+			Severity:  severity,
+			Container: status.Name,
+			Summary:   fmt.Sprintf("Container %s restarted %d times (%s)", status.Name, status.RestartCount, exitDescription(last)),
+			NextSteps: append(exitNextSteps(last, limits), logsHint),
+		})
+	}
+
+	if terminated := status.State.Terminated; terminated != nil && terminated.ExitCode != 0 && pod.Status.Phase == corev1.PodFailed {
+		diagnoses = append(diagnoses, models.Diagnosis{
+			Code:      "Failed",
+			Severity:  models.SeverityCritical,
+			Container: status.Name,
+			Summary:   fmt.Sprintf("Container %s failed (%s)", status.Name, exitDescription(terminated)),
+			Detail:    terminated.Message,
+			NextSteps: exitNextSteps(terminated, limits),
+		})
+	}
+	return diagnoses
+}
+
+// readinessDiagnosis explains a running container that is not ready
+func readinessDiagnosis(container string, events []corev1.Event) models.Diagnosis {
+	d := models.Diagnosis{
+		Code:      "ReadinessProbeFailing",
+		Severity:  models.SeverityWarning,
+		Container: container,
+		Summary:   fmt.Sprintf("Container %s is running but failing its readiness probe", container),
+		NextSteps: []string{
+			"Check the probe path, port and timeout match what the application serves",
+			"Check dependencies the readiness endpoint waits on",
+		},
+	}
+	for _, event := range events {
+		if event.Reason == "Unhealthy" && strings.Contains(event.Message, "Readiness probe failed") {
+			d.Detail = event.Message
+		}
+	}
+	return d
+}
+
+// missingRefDiagnosis recognizes a missing ConfigMap or Secret in a
+// kubelet message
+func missingRefDiagnosis(container, message string) (models.Diagnosis, bool) {
+	match := missingRefPattern.FindStringSubmatch(message)
+	if match == nil {
+		return models.Diagnosis{}, false
+	}
+	kind := "ConfigMap"
+	if strings.EqualFold(match[1], "secret") {
+		kind = "Secret"
+	}
+	return models.Diagnosis{
+		Code:      "Missing" + kind,
+		Severity:  models.SeverityCritical,
+		Container: container,
+		Summary:   fmt.Sprintf("%s %q referenced by the pod does not exist", kind, match[2]),
+		Detail:    message,
+		NextSteps: []string{
+			fmt.Sprintf("Create the %s %q in the pod's namespace", kind, match[2]),
+			"Or fix the reference, or mark it optional: true",
+		},
+	}, true
+}
+
+// exitDescription turns a termination state into text such as
+// "OOMKilled, exit code 137"
+func exitDescription(terminated *corev1.ContainerStateTerminated) string {
+	meaning := ""
+	switch terminated.ExitCode {
+	case 126:
+		meaning = "command not executable"
+	case 127:
+		meaning = "command not found"
+	case 137:
+		if terminated.Reason != "OOMKilled" {
+			meaning = "killed by SIGKILL"
+		}
+	case 139:
+		meaning = "segmentation fault"
+	case 143:
+		meaning = "terminated by SIGTERM"
+	}
+
+	parts := make([]string, 0, 3)
+	if terminated.Reason != "" {
+		parts = append(parts, terminated.Reason)
+	}
+	parts = append(parts, fmt.Sprintf("exit code %d", terminated.ExitCode))
+	if meaning != "" {
+		parts = append(parts, meaning)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// exitNextSteps suggests fixes for a termination state
+func exitNextSteps(terminated *corev1.ContainerStateTerminated, limits corev1.ResourceList) []string {
+	switch {
+	case terminated.Reason == "OOMKilled":
+		step := "Raise the container's memory limit"
+		if memory, ok := limits[corev1.ResourceMemory]; ok {
+			step = fmt.Sprintf("Raise the container's memory limit (currently %s)", memory.String())
+		}
+		return []string{step, "Check the application for memory leaks or unbounded caches"}
+	case terminated.ExitCode == 126 || terminated.ExitCode == 127:
+		return []string{"Check the container's command and args exist in the image"}
+	case terminated.ExitCode == 137:
+		return []string{"Check whether a liveness probe or the node killed the container"}
+	}
+	return nil
+}
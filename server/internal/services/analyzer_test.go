@@ -0,0 +1,159 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mugayoshi/k8s-visualizer/server/internal/models"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAnalyzePod(t *testing.T) {
+	base := func() *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default"},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name:      "web",
+				Image:     "ghcr.io/example/web:1.2",
+				Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("256Mi")}},
+			}}},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		pod      func() *corev1.Pod
+		events   []corev1.Event
+		code     string
+		severity string
+		contains string // Expected in summary, detail or next steps
+	}{
+		{
+			name: "crash loop after OOM",
+			pod: func() *corev1.Pod {
+				pod := base()
+				pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+					Name:                 "web",
+					RestartCount:         5,
+					State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+					LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137}},
+				}}
+				return pod
+			},
+			code:     "CrashLoopBackOff",
+			severity: models.SeverityCritical,
+			contains: "currently 256Mi",
+		},
+		{
+			name: "image pull with bad tag",
+			pod: func() *corev1.Pod {
+				pod := base()
+				pod.Status.Phase = corev1.PodPending
+				pod.Spec.NodeName = "node-1"
+				pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+					Name:  "web",
+					Image: "ghcr.io/example/web:1.2",
+					State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "manifest unknown"}},
+				}}
+				return pod
+			},
+			code:     "ImagePullBackOff",
+			severity: models.SeverityCritical,
+			contains: `tag "1.2" exists in ghcr.io/example/web`,
+		},
+		{
+			name: "unschedulable",
+			pod: func() *corev1.Pod {
+				pod := base()
+				pod.Status.Phase = corev1.PodPending
+				pod.Status.Conditions = []corev1.PodCondition{{
+					Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: corev1.PodReasonUnschedulable,
+					Message: "0/3 nodes are available: 3 Insufficient cpu.",
+				}}
+				return pod
+			},
+			code:     "Unschedulable",
+			severity: models.SeverityCritical,
+			contains: "Insufficient cpu",
+		},
+		{
+			name: "missing configmap mount",
+			pod: func() *corev1.Pod {
+				pod := base()
+				pod.Status.Phase = corev1.PodPending
+				pod.Spec.NodeName = "node-1"
+				pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+					Name:  "web",
+					State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}},
+				}}
+				return pod
+			},
+			events: []corev1.Event{{
+				Reason:  "FailedMount",
+				Message: `MountVolume.SetUp failed for volume "config" : configmap "web-config" not found`,
+			}},
+			code:     "MissingConfigMap",
+			severity: models.SeverityCritical,
+			contains: `ConfigMap "web-config"`,
+		},
+		{
+			name: "failing readiness probe",
+			pod: func() *corev1.Pod {
+				pod := base()
+				pod.Spec.Containers[0].ReadinessProbe = &corev1.Probe{}
+				pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+					Name:  "web",
+					State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+				}}
+				return pod
+			},
+			events: []corev1.Event{{
+				Reason:  "Unhealthy",
+				Message: "Readiness probe failed: HTTP probe failed with statuscode: 503",
+			}},
+			code:     "ReadinessProbeFailing",
+			severity: models.SeverityWarning,
+			contains: "statuscode: 503",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagnoses := AnalyzePod(tt.pod(), tt.events)
+			if len(diagnoses) == 0 {
+				t.Fatalf("expected a diagnosis")
+			}
+			d := diagnoses[0]
+			if d.Code != tt.code || d.Severity != tt.severity {
+				t.Fatalf("expected %s/%s, got %s/%s", tt.code, tt.severity, d.Code, d.Severity)
+			}
+			text := d.Summary + "\n" + d.Detail + "\n" + strings.Join(d.NextSteps, "\n")
+			if !strings.Contains(text, tt.contains) {
+				t.Fatalf("expected %q in diagnosis, got:\n%s", tt.contains, text)
+			}
+			if StatusDetail(diagnoses) != d.Summary {
+				t.Fatalf("expected status detail to summarize the first diagnosis")
+			}
+		})
+	}
+}
+
+func TestAnalyzePod_HealthyPodHasNoDiagnoses(t *testing.T) {
+	pod := &corev1.Pod{
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "web"}}},
+		Status: corev1.PodStatus{
+			Phase:             corev1.PodRunning,
+			Conditions:        []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "web", Ready: true, State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}}},
+		},
+	}
+	if PodNeedsAttention(pod) {
+		t.Fatalf("healthy pod should not need attention")
+	}
+	if diagnoses := AnalyzePod(pod, nil); len(diagnoses) != 0 {
+		t.Fatalf("expected no diagnoses, got %+v", diagnoses)
+	}
+}
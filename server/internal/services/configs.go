@@ -0,0 +1,362 @@
+// internal/services/configs.go
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Ways a pod can reference a ConfigMap or Secret
+const (
+	ConfigRefEnv             = "env"
+	ConfigRefEnvFrom         = "envFrom"
+	ConfigRefVolume          = "volume"
+	ConfigRefProjected       = "projected"
+	ConfigRefImagePullSecret = "imagePullSecret"
+)
+
+// DataKey is one key of a ConfigMap or Secret
+type DataKey struct {
+	Name   string `json:"name"`
+	Size   int    `json:"size"`
+	Binary bool   `json:"binary,omitempty"`
+}
+
+// ConfigReference is one place a pod uses a ConfigMap or Secret
+type ConfigReference struct {
+	Pod       string `json:"pod"`
+	Container string `json:"container,omitempty"`
+	Via       string `json:"via"`
+	Key       string `json:"key,omitempty"`    // For env references
+	Volume    string `json:"volume,omitempty"` // For volume and projected references
+	SubPath   bool   `json:"sub_path,omitempty"`
+	Optional  bool   `json:"optional,omitempty"`
+}
+
+// StalePod is a pod whose containers started before the object last changed.
+// RestartRequired is set for env, envFrom and subPath references; other
+// volume mounts are refreshed in place by the kubelet.
+type StalePod struct {
+	Pod             string    `json:"pod"`
+	StartedAt       time.Time `json:"started_at"`
+	RestartRequired bool      `json:"restart_required"`
+}
+
+// ConfigObject is a ConfigMap or Secret with the pods that use it
+type ConfigObject struct {
+	Kind        string            `json:"kind"`
+	Name        string            `json:"name"`
+	Namespace   string            `json:"namespace"`
+	Type        string            `json:"type,omitempty"` // Secret type
+	Immutable   bool              `json:"immutable"`
+	Created     time.Time         `json:"created"`
+	LastChanged time.Time         `json:"last_changed"`
+	Keys        []DataKey         `json:"keys"`
+	Data        map[string]string `json:"data,omitempty"`
+	Redacted    bool              `json:"redacted,omitempty"`
+	UsedBy      int               `json:"used_by"`
+	References  []ConfigReference `json:"references"`
+	StalePods   []StalePod        `json:"stale_pods"`
+}
+
+// ListConfigMaps lists ConfigMaps in namespace (all when empty) with their
+// usage. Values are left out of the list.
+func ListConfigMaps(ctx context.Context, clientset kubernetes.Interface, namespace string) ([]ConfigObject, error) {
+	configMaps, err := clientset.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list configmaps: %w", err)
+	}
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	objects := make([]ConfigObject, 0, len(configMaps.Items))
+	for i := range configMaps.Items {
+		object := NewConfigMapObject(&configMaps.Items[i], pods.Items)
+		object.Data = nil
+		objects = append(objects, object)
+	}
+	return objects, nil
+}
+
+// GetConfigMap returns a ConfigMap with its values and usage
+func GetConfigMap(ctx context.Context, clientset kubernetes.Interface, namespace, name string) (*ConfigObject, error) {
+	configMap, err := clientset.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get configmap: %w", err)
+	}
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	object := NewConfigMapObject(configMap, pods.Items)
+	return &object, nil
+}
+
+// ListSecrets lists Secrets in namespace (all when empty) with their usage.
+// Values are never included in the list.
+func ListSecrets(ctx context.Context, clientset kubernetes.Interface, namespace string) ([]ConfigObject, error) {
+	secrets, err := clientset.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets: %w", err)
+	}
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	objects := make([]ConfigObject, 0, len(secrets.Items))
+	for i := range secrets.Items {
+		objects = append(objects, NewSecretObject(&secrets.Items[i], pods.Items, false))
+	}
+	return objects, nil
+}
+
+// GetSecret returns a Secret with its usage. Values are only included when
+// reveal is set; the caller decides whether policy allows it.
+func GetSecret(ctx context.Context, clientset kubernetes.Interface, namespace, name string, reveal bool) (*ConfigObject, error) {
+	secret, err := clientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret: %w", err)
+	}
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	object := NewSecretObject(secret, pods.Items, reveal)
+	return &object, nil
+}
+
+// NewConfigMapObject describes a ConfigMap and the pods referencing it
+func NewConfigMapObject(configMap *corev1.ConfigMap, pods []corev1.Pod) ConfigObject {
+	object := ConfigObject{
+		Kind:      "ConfigMap",
+		Name:      configMap.Name,
+		Namespace: configMap.Namespace,
+		Immutable: configMap.Immutable != nil && *configMap.Immutable,
+		Keys:      make([]DataKey, 0, len(configMap.Data)+len(configMap.BinaryData)),
+		Data:      make(map[string]string, len(configMap.Data)),
+	}
+	for key, value := range configMap.Data {
+		object.Keys = append(object.Keys, DataKey{Name: key, Size: len(value)})
+		object.Data[key] = value
+	}
+	for key, value := range configMap.BinaryData {
+		object.Keys = append(object.Keys, DataKey{Name: key, Size: len(value), Binary: true})
+	}
+	object.addUsage(&configMap.ObjectMeta, pods)
+	return object
+}
+
+// NewSecretObject describes a Secret and the pods referencing it, with
+// values redacted unless reveal is set
+func NewSecretObject(secret *corev1.Secret, pods []corev1.Pod, reveal bool) ConfigObject {
+	object := ConfigObject{
+		Kind:      "Secret",
+		Name:      secret.Name,
+		Namespace: secret.Namespace,
+		Type:      string(secret.Type),
+		Immutable: secret.Immutable != nil && *secret.Immutable,
+		Keys:      make([]DataKey, 0, len(secret.Data)),
+		Redacted:  !reveal,
+	}
+	if reveal {
+		object.Data = make(map[string]string, len(secret.Data))
+	}
+	for key, value := range secret.Data {
+		object.Keys = append(object.Keys, DataKey{Name: key, Size: len(value)})
+		if reveal {
+			object.Data[key] = string(value)
+		}
+	}
+	object.addUsage(&secret.ObjectMeta, pods)
+	return object
+}
+
+// addUsage fills timestamps, references and stale pods
+func (o *ConfigObject) addUsage(meta *metav1.ObjectMeta, pods []corev1.Pod) {
+	sort.Slice(o.Keys, func(i, j int) bool { return o.Keys[i].Name < o.Keys[j].Name })
+	o.Created = meta.CreationTimestamp.Time
+	o.LastChanged = lastChanged(meta)
+	o.References = make([]ConfigReference, 0)
+	o.StalePods = make([]StalePod, 0)
+
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Namespace != o.Namespace || podIsTerminal(pod) {
+			continue
+		}
+		refs := podConfigReferences(pod, o.Kind, o.Name)
+		if len(refs) == 0 {
+			continue
+		}
+		o.References = append(o.References, refs...)
+		o.UsedBy++
+
+		started := podStartedAt(pod)
+		if started.IsZero() || !started.Before(o.LastChanged) || onlyPullSecret(refs) {
+			continue
+		}
+		restart := false
+		for _, ref := range refs {
+			if ref.Via == ConfigRefEnv || ref.Via == ConfigRefEnvFrom || ref.SubPath {
+				restart = true
+			}
+		}
+		o.StalePods = append(o.StalePods, StalePod{Pod: pod.Name, StartedAt: started, RestartRequired: restart})
+	}
+}
+
+// dataFields are the managed field sets holding ConfigMap and Secret values
+var dataFields = []string{"f:data", "f:binaryData", "f:stringData"}
+
+// onlyPullSecret reports whether every reference is an imagePullSecret,
+// which is read at pull time and never makes a running pod stale
+func onlyPullSecret(refs []ConfigReference) bool {
+	for _, ref := range refs {
+		if ref.Via != ConfigRefImagePullSecret {
+			return false
+		}
+	}
+	return true
+}
+
+// lastChanged is the latest write by a manager that owns data fields,
+// falling back to the creation time. Writes that only touch labels or
+// annotations do not change what pods see, so they are ignored.
+func lastChanged(meta *metav1.ObjectMeta) time.Time {
+	changed := meta.CreationTimestamp.Time
+	for _, entry := range meta.ManagedFields {
+		if entry.Time != nil && entry.Time.After(changed) && touchesData(entry) {
+			changed = entry.Time.Time
+		}
+	}
+	return changed
+}
+
+// touchesData reports whether a managed fields entry owns any data field
+func touchesData(entry metav1.ManagedFieldsEntry) bool {
+	if entry.FieldsV1 == nil {
+		return false
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+		return false
+	}
+	for _, field := range dataFields {
+		if _, ok := fields[field]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// podStartedAt is when the pod's containers last started: the latest
+// running container start, or the pod start time if none are running
+func podStartedAt(pod *corev1.Pod) time.Time {
+	var started time.Time
+	for _, status := range pod.Status.ContainerStatuses {
+		if running := status.State.Running; running != nil && running.StartedAt.After(started) {
+			started = running.StartedAt.Time
+		}
+	}
+	if started.IsZero() && pod.Status.StartTime != nil {
+		started = pod.Status.StartTime.Time
+	}
+	return started
+}
+
+// podConfigReferences returns every reference a pod makes to the named
+// ConfigMap or Secret
+func podConfigReferences(pod *corev1.Pod, kind, name string) []ConfigReference {
+	var refs []ConfigReference
+	isSecret := kind == "Secret"
+	optional := func(b *bool) bool { return b != nil && *b }
+
+	// Volumes are matched first so container mounts can note subPath use
+	volumes := make(map[string]ConfigReference)
+	for _, volume := range pod.Spec.Volumes {
+		switch {
+		case !isSecret && volume.ConfigMap != nil && volume.ConfigMap.Name == name:
+			volumes[volume.Name] = ConfigReference{Via: ConfigRefVolume, Volume: volume.Name, Optional: optional(volume.ConfigMap.Optional)}
+		case isSecret && volume.Secret != nil && volume.Secret.SecretName == name:
+			volumes[volume.Name] = ConfigReference{Via: ConfigRefVolume, Volume: volume.Name, Optional: optional(volume.Secret.Optional)}
+		case volume.Projected != nil:
+			for _, source := range volume.Projected.Sources {
+				if !isSecret && source.ConfigMap != nil && source.ConfigMap.Name == name {
+					volumes[volume.Name] = ConfigReference{Via: ConfigRefProjected, Volume: volume.Name, Optional: optional(source.ConfigMap.Optional)}
+				}
+				if isSecret && source.Secret != nil && source.Secret.Name == name {
+					volumes[volume.Name] = ConfigReference{Via: ConfigRefProjected, Volume: volume.Name, Optional: optional(source.Secret.Optional)}
+				}
+			}
+		}
+	}
+
+	mounted := make(map[string]bool)
+	for _, container := range podContainers(pod) {
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if ref := env.ValueFrom.ConfigMapKeyRef; !isSecret && ref != nil && ref.Name == name {
+				refs = append(refs, ConfigReference{Container: container.Name, Via: ConfigRefEnv, Key: ref.Key, Optional: optional(ref.Optional)})
+			}
+			if ref := env.ValueFrom.SecretKeyRef; isSecret && ref != nil && ref.Name == name {
+				refs = append(refs, ConfigReference{Container: container.Name, Via: ConfigRefEnv, Key: ref.Key, Optional: optional(ref.Optional)})
+			}
+		}
+		for _, envFrom := range container.EnvFrom {
+			if ref := envFrom.ConfigMapRef; !isSecret && ref != nil && ref.Name == name {
+				refs = append(refs, ConfigReference{Container: container.Name, Via: ConfigRefEnvFrom, Optional: optional(ref.Optional)})
+			}
+			if ref := envFrom.SecretRef; isSecret && ref != nil && ref.Name == name {
+				refs = append(refs, ConfigReference{Container: container.Name, Via: ConfigRefEnvFrom, Optional: optional(ref.Optional)})
+			}
+		}
+		for _, mount := range container.VolumeMounts {
+			ref, ok := volumes[mount.Name]
+			if !ok {
+				continue
+			}
+			ref.Container = container.Name
+			ref.SubPath = mount.SubPath != "" || mount.SubPathExpr != ""
+			refs = append(refs, ref)
+			mounted[mount.Name] = true
+		}
+	}
+	// Volumes declared but not mounted by any container still pin the object
+	unmounted := make([]string, 0)
+	for volume := range volumes {
+		if !mounted[volume] {
+			unmounted = append(unmounted, volume)
+		}
+	}
+	sort.Strings(unmounted)
+	for _, volume := range unmounted {
+		refs = append(refs, volumes[volume])
+	}
+
+	if isSecret {
+		for _, pullSecret := range pod.Spec.ImagePullSecrets {
+			if pullSecret.Name == name {
+				refs = append(refs, ConfigReference{Via: ConfigRefImagePullSecret})
+			}
+		}
+	}
+
+	for i := range refs {
+		refs[i].Pod = pod.Name
+	}
+	return refs
+}
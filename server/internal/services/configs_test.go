@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGetConfigMap_ReferencesAndStalePods(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	updated := metav1.NewTime(created.Add(2 * time.Hour))
+	before := metav1.NewTime(created.Add(time.Hour))
+	after := metav1.NewTime(created.Add(3 * time.Hour))
+	relabelled := metav1.NewTime(created.Add(4 * time.Hour))
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "app-config",
+			Namespace:         "shop",
+			CreationTimestamp: metav1.NewTime(created),
+			ManagedFields: []metav1.ManagedFieldsEntry{
+				{Manager: "kubectl", Time: &updated, FieldsType: "FieldsV1", FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:data":{"f:LOG_LEVEL":{}}}`)}},
+				// A later label change by another manager is not a data change
+				{Manager: "labeller", Time: &relabelled, FieldsType: "FieldsV1", FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:metadata":{"f:labels":{"f:team":{}}}}`)}},
+			},
+		},
+		Data:       map[string]string{"LOG_LEVEL": "debug", "app.yaml": "port: 80"},
+		BinaryData: map[string][]byte{"logo.png": {1, 2, 3}},
+	}
+
+	running := func(name string, started metav1.Time, spec corev1.PodSpec) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop"},
+			Spec:       spec,
+			Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:  "app",
+					State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: started}},
+				}},
+			},
+		}
+	}
+	envPod := running("env-pod", before, corev1.PodSpec{Containers: []corev1.Container{{
+		Name: "app",
+		Env: []corev1.EnvVar{{Name: "LOG_LEVEL", ValueFrom: &corev1.EnvVarSource{
+			ConfigMapKeyRef: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "app-config"}, Key: "LOG_LEVEL"},
+		}}},
+	}}})
+	volumePod := running("volume-pod", before, corev1.PodSpec{
+		Volumes: []corev1.Volume{{Name: "config", VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{
+			Sources: []corev1.VolumeProjection{{ConfigMap: &corev1.ConfigMapProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "app-config"}}}},
+		}}}},
+		Containers: []corev1.Container{{Name: "app", VolumeMounts: []corev1.VolumeMount{{Name: "config", MountPath: "/etc/app"}}}},
+	})
+	freshPod := running("fresh-pod", after, corev1.PodSpec{Containers: []corev1.Container{{
+		Name:    "app",
+		EnvFrom: []corev1.EnvFromSource{{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "app-config"}}}},
+	}}})
+	unrelated := running("other", before, corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}})
+
+	clientset := fake.NewSimpleClientset(configMap, envPod, volumePod, freshPod, unrelated)
+	object, err := GetConfigMap(context.Background(), clientset, "shop", "app-config")
+	if err != nil {
+		t.Fatalf("GetConfigMap failed: %v", err)
+	}
+
+	if len(object.Keys) != 3 || object.Keys[2].Name != "logo.png" || !object.Keys[2].Binary || object.Data["LOG_LEVEL"] != "debug" {
+		t.Errorf("unexpected keys/data %+v %v", object.Keys, object.Data)
+	}
+	if !object.LastChanged.Equal(updated.Time) {
+		t.Errorf("expected last change from managed fields, got %s", object.LastChanged)
+	}
+	if object.UsedBy != 3 || len(object.References) != 3 {
+		t.Fatalf("expected 3 referencing pods, got %+v", object.References)
+	}
+	vias := map[string]string{}
+	for _, ref := range object.References {
+		vias[ref.Pod] = ref.Via
+	}
+	if vias["env-pod"] != ConfigRefEnv || vias["volume-pod"] != ConfigRefProjected || vias["fresh-pod"] != ConfigRefEnvFrom {
+		t.Errorf("unexpected reference kinds %v", vias)
+	}
+
+	if len(object.StalePods) != 2 {
+		t.Fatalf("expected 2 stale pods, got %+v", object.StalePods)
+	}
+	for _, stale := range object.StalePods {
+		if stale.RestartRequired != (stale.Pod == "env-pod") {
+			t.Errorf("unexpected restart flag for %s: %v", stale.Pod, stale.RestartRequired)
+		}
+	}
+
+	list, err := ListConfigMaps(context.Background(), clientset, "")
+	if err != nil {
+		t.Fatalf("ListConfigMaps failed: %v", err)
+	}
+	if len(list) != 1 || list[0].Data != nil || list[0].UsedBy != 3 {
+		t.Errorf("expected list without values, got %+v", list)
+	}
+}
+
+func TestGetSecret_Redaction(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "shop"},
+		Type:       corev1.SecretTypeOpaque,
+		Data:       map[string][]byte{"password": []byte("hunter2")},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "shop"},
+		Spec: corev1.PodSpec{
+			ImagePullSecrets: []corev1.LocalObjectReference{{Name: "db"}},
+			Volumes:          []corev1.Volume{{Name: "creds", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "db"}}}},
+			Containers: []corev1.Container{{
+				Name:         "api",
+				VolumeMounts: []corev1.VolumeMount{{Name: "creds", MountPath: "/run/secrets/password", SubPath: "password"}},
+			}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	clientset := fake.NewSimpleClientset(secret, pod)
+
+	redacted, err := GetSecret(context.Background(), clientset, "shop", "db", false)
+	if err != nil {
+		t.Fatalf("GetSecret failed: %v", err)
+	}
+	if !redacted.Redacted || redacted.Data != nil || redacted.Keys[0].Size != 7 {
+		t.Errorf("expected redacted secret with key sizes, got %+v", redacted)
+	}
+	if len(redacted.References) != 2 || !redacted.References[0].SubPath || redacted.References[1].Via != ConfigRefImagePullSecret {
+		t.Errorf("unexpected references %+v", redacted.References)
+	}
+
+	revealed, err := GetSecret(context.Background(), clientset, "shop", "db", true)
+	if err != nil {
+		t.Fatalf("GetSecret failed: %v", err)
+	}
+	if revealed.Redacted || revealed.Data["password"] != "hunter2" {
+		t.Errorf("expected revealed value, got %+v", revealed)
+	}
+}
+
+func TestGetSecret_PullSecretOnlyPodIsNotStale(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	updated := metav1.NewTime(created.Add(2 * time.Hour))
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "registry",
+			Namespace:         "shop",
+			CreationTimestamp: metav1.NewTime(created),
+			ManagedFields: []metav1.ManagedFieldsEntry{
+				{Manager: "rotator", Time: &updated, FieldsType: "FieldsV1", FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:data":{"f:.dockerconfigjson":{}}}`)}},
+			},
+		},
+		Type: corev1.SecretTypeDockerConfigJson,
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "shop"},
+		Spec: corev1.PodSpec{
+			ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}},
+			Containers:       []corev1.Container{{Name: "api"}},
+		},
+		Status: corev1.PodStatus{
+			Phase:     corev1.PodRunning,
+			StartTime: &metav1.Time{Time: created.Add(time.Hour)},
+		},
+	}
+
+	object, err := GetSecret(context.Background(), fake.NewSimpleClientset(secret, pod), "shop", "registry", false)
+	if err != nil {
+		t.Fatalf("GetSecret failed: %v", err)
+	}
+	if object.UsedBy != 1 || len(object.StalePods) != 0 {
+		t.Errorf("expected the pull-secret user without stale pods, got used_by=%d stale=%+v", object.UsedBy, object.StalePods)
+	}
+}
@@ -0,0 +1,537 @@
+// internal/services/demo.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// DemoOptions controls the size of the simulated cluster and its churn
+type DemoOptions struct {
+	Nodes          int
+	Namespaces     int
+	Deployments    int     // Per namespace
+	EventsPerSec   float64 // Simulated changes per second
+	Seed           int64
+	NodeFlapChance float64 // Probability that a change is a node NotReady flap
+}
+
+// DefaultDemoOptions returns a small but busy cluster
+func DefaultDemoOptions() DemoOptions {
+	return DemoOptions{
+		Nodes:          5,
+		Namespaces:     3,
+		Deployments:    4,
+		EventsPerSec:   2,
+		Seed:           time.Now().UnixNano(),
+		NodeFlapChance: 0.02,
+	}
+}
+
+var demoImages = []string{
+	"nginx:1.25", "redis:7.2", "postgres:16", "ghcr.io/example/api:2.3.1",
+	"ghcr.io/example/worker:2.3.1", "busybox:latest", "grafana/grafana:10.2.0",
+}
+
+// demoMaxEvents bounds the simulated Events kept in the fake clientset; the
+// oldest are deleted first, as the API server's event TTL would
+const demoMaxEvents = 500
+
+// DemoClient backs K8sClientInterface with a fake clientset populated with
+// synthetic nodes, namespaces and deployments, and simulates pod churn on it
+type DemoClient struct {
+	*K8sClient
+	fake *fake.Clientset
+	opts DemoOptions
+	rng  *rand.Rand
+	seq  int
+	// events holds the recorded Events, oldest first, for pruning
+	events []types.NamespacedName
+}
+
+// NewDemoClient creates and populates a simulated cluster. Call Run to
+// start the churn.
+func NewDemoClient(opts DemoOptions) *DemoClient {
+	if opts.Nodes < 1 {
+		opts.Nodes = 1
+	}
+	if opts.EventsPerSec <= 0 {
+		opts.EventsPerSec = DefaultDemoOptions().EventsPerSec
+	}
+	cs := fake.NewSimpleClientset()
+	d := &DemoClient{
+		K8sClient: &K8sClient{clientset: cs},
+		fake:      cs,
+		opts:      opts,
+		rng:       rand.New(rand.NewSource(opts.Seed)),
+	}
+	d.populate()
+	return d
+}
+
+// GetPodLogs returns synthetic log lines. It uses the global source since
+// d.rng belongs to the simulation goroutine.
+func (d *DemoClient) GetPodLogs(ctx context.Context, namespace, podName string, opts *corev1.PodLogOptions) (io.ReadCloser, error) {
+	var b strings.Builder
+	now := time.Now()
+	for i := 20; i > 0; i-- {
+		fmt.Fprintf(&b, "%s INFO %s handled request id=%d status=200\n", now.Add(-time.Duration(i)*time.Second).Format(time.RFC3339), podName, rand.Intn(100000))
+	}
+	return io.NopCloser(strings.NewReader(b.String())), nil
+}
+
+// populate creates the initial objects directly in the tracker
+func (d *DemoClient) populate() {
+	objects := make([]runtime.Object, 0)
+	created := metav1.NewTime(time.Now().Add(-72 * time.Hour))
+
+	for i := 1; i <= d.opts.Nodes; i++ {
+		objects = append(objects, &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              fmt.Sprintf("demo-node-%d", i),
+				CreationTimestamp: created,
+				Labels: map[string]string{
+					"kubernetes.io/hostname":      fmt.Sprintf("demo-node-%d", i),
+					"topology.kubernetes.io/zone": fmt.Sprintf("zone-%c", 'a'+rune(i%3)),
+				},
+			},
+			Status: corev1.NodeStatus{
+				Capacity: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("4"),
+					corev1.ResourceMemory: resource.MustParse("16Gi"),
+					corev1.ResourcePods:   resource.MustParse("110"),
+				},
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("3800m"),
+					corev1.ResourceMemory: resource.MustParse("15Gi"),
+					corev1.ResourcePods:   resource.MustParse("110"),
+				},
+				Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue, LastTransitionTime: created}},
+				Addresses:  []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: fmt.Sprintf("10.0.0.%d", i)}},
+			},
+		})
+	}
+
+	for n := 0; n < d.opts.Namespaces; n++ {
+		namespace := fmt.Sprintf("demo-%d", n)
+		objects = append(objects, &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: namespace, CreationTimestamp: created},
+			Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceActive},
+		})
+
+		for i := 0; i < d.opts.Deployments; i++ {
+			name := fmt.Sprintf("app-%d", i)
+			replicas := int32(1 + d.rng.Intn(4))
+			image := demoImages[d.rng.Intn(len(demoImages))]
+			labels := map[string]string{"app": name}
+			template := corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{
+					Name:  name,
+					Image: image,
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("100m"),
+							corev1.ResourceMemory: resource.MustParse("128Mi"),
+						},
+						Limits: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("500m"),
+							corev1.ResourceMemory: resource.MustParse("256Mi"),
+						},
+					},
+				}}},
+			}
+
+			deployment := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, CreationTimestamp: created, Labels: labels},
+				Spec: appsv1.DeploymentSpec{
+					Replicas: &replicas,
+					Selector: &metav1.LabelSelector{MatchLabels: labels},
+					Template: template,
+				},
+			}
+			replicaSet := &appsv1.ReplicaSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              name + "-7d9f8b6c5",
+					Namespace:         namespace,
+					CreationTimestamp: created,
+					Labels:            labels,
+					Annotations:       map[string]string{"deployment.kubernetes.io/revision": "1"},
+					OwnerReferences:   []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: name, Controller: boolPtr(true)}},
+				},
+				Spec: appsv1.ReplicaSetSpec{Replicas: &replicas, Selector: deployment.Spec.Selector, Template: template},
+			}
+			objects = append(objects, deployment, replicaSet)
+
+			for r := int32(0); r < replicas; r++ {
+				objects = append(objects, d.newPod(replicaSet, corev1.PodRunning))
+			}
+		}
+	}
+
+	for _, obj := range objects {
+		if err := d.fake.Tracker().Add(obj); err != nil {
+			log.Printf("Failed to add demo object: %v", err)
+		}
+	}
+	d.syncDeployments(context.Background())
+}
+
+// newPod builds a pod owned by rs in the given phase
+func (d *DemoClient) newPod(rs *appsv1.ReplicaSet, phase corev1.PodPhase) *corev1.Pod {
+	d.seq++
+	container := rs.Spec.Template.Spec.Containers[0]
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              fmt.Sprintf("%s-%05x", rs.Name, d.seq),
+			Namespace:         rs.Namespace,
+			CreationTimestamp: metav1.Now(),
+			Labels:            rs.Spec.Template.Labels,
+			OwnerReferences:   []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: rs.Name, Controller: boolPtr(true)}},
+		},
+		Spec: *rs.Spec.Template.Spec.DeepCopy(),
+	}
+	pod.Spec.NodeName = fmt.Sprintf("demo-node-%d", 1+d.rng.Intn(d.opts.Nodes))
+
+	if phase == corev1.PodPending {
+		pod.Spec.NodeName = ""
+		pod.Status = corev1.PodStatus{
+			Phase:      corev1.PodPending,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodScheduled, Status: corev1.ConditionFalse}},
+		}
+		return pod
+	}
+
+	started := metav1.Now()
+	pod.Status = corev1.PodStatus{
+		Phase:     corev1.PodRunning,
+		StartTime: &started,
+		Conditions: []corev1.PodCondition{
+			{Type: corev1.PodScheduled, Status: corev1.ConditionTrue},
+			{Type: corev1.PodReady, Status: corev1.ConditionTrue},
+		},
+		ContainerStatuses: []corev1.ContainerStatus{{
+			Name:    container.Name,
+			Image:   container.Image,
+			ImageID: "docker-pullable://" + container.Image + "@sha256:" + strings.Repeat("ab", 32),
+			Ready:   true,
+			State:   corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: started}},
+		}},
+	}
+	return pod
+}
+
+// Run applies random changes at the configured rate until ctx is cancelled
+func (d *DemoClient) Run(ctx context.Context) {
+	interval := time.Duration(float64(time.Second) / d.opts.EventsPerSec)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("Demo mode: simulating %.1f changes/sec across %d nodes", d.opts.EventsPerSec, d.opts.Nodes)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := d.Step(ctx); err != nil {
+				log.Printf("Demo step failed: %v", err)
+			}
+		}
+	}
+}
+
+// Step applies a single simulated change
+func (d *DemoClient) Step(ctx context.Context) error {
+	cs := d.fake
+
+	nodes, err := cs.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	pods, err := cs.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	// Heal first: recover flapped nodes and let pending/crashed pods settle
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		if nodeReadyStatus(node) == "NotReady" && d.rng.Float64() < 0.3 {
+			return d.setNodeReady(ctx, node, corev1.ConditionTrue)
+		}
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		switch {
+		case pod.Status.Phase == corev1.PodPending && d.rng.Float64() < 0.5:
+			return d.startPod(ctx, pod)
+		case pod.Status.Phase == corev1.PodFailed && d.rng.Float64() < 0.5:
+			return d.replacePod(ctx, pod)
+		case isCrashing(pod) && d.rng.Float64() < 0.2:
+			return d.startPod(ctx, pod)
+		}
+	}
+
+	if d.rng.Float64() < d.opts.NodeFlapChance && len(nodes.Items) > 0 {
+		return d.setNodeReady(ctx, &nodes.Items[d.rng.Intn(len(nodes.Items))], corev1.ConditionFalse)
+	}
+	if len(pods.Items) == 0 {
+		return nil
+	}
+
+	pod := &pods.Items[d.rng.Intn(len(pods.Items))]
+	switch roll := d.rng.Float64(); {
+	case roll < 0.35:
+		return d.crashPod(ctx, pod)
+	case roll < 0.55:
+		return d.restartContainer(ctx, pod)
+	case roll < 0.65:
+		return d.evictPod(ctx, pod)
+	default:
+		// Rolling replacement: delete the pod and create a new pending one
+		return d.replacePod(ctx, pod)
+	}
+}
+
+func (d *DemoClient) setNodeReady(ctx context.Context, node *corev1.Node, status corev1.ConditionStatus) error {
+	for i := range node.Status.Conditions {
+		if node.Status.Conditions[i].Type == corev1.NodeReady {
+			node.Status.Conditions[i].Status = status
+			node.Status.Conditions[i].LastTransitionTime = metav1.Now()
+		}
+	}
+	if _, err := d.fake.CoreV1().Nodes().UpdateStatus(ctx, node, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	d.recordEvent(ctx, "Node", "", node.Name, "NodeReady", fmt.Sprintf("Node %s status is now: %s", node.Name, map[corev1.ConditionStatus]string{corev1.ConditionTrue: "NodeReady", corev1.ConditionFalse: "NodeNotReady"}[status]))
+	return nil
+}
+
+func (d *DemoClient) startPod(ctx context.Context, pod *corev1.Pod) error {
+	rs := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{Name: ownerName(pod), Namespace: pod.Namespace},
+		Spec:       appsv1.ReplicaSetSpec{Template: corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: pod.Labels}, Spec: pod.Spec}},
+	}
+	running := d.newPod(rs, corev1.PodRunning)
+	pod.Spec.NodeName = running.Spec.NodeName
+	restarts := int32(0)
+	if len(pod.Status.ContainerStatuses) > 0 {
+		restarts = pod.Status.ContainerStatuses[0].RestartCount
+	}
+	lastState := corev1.ContainerState{}
+	if len(pod.Status.ContainerStatuses) > 0 {
+		lastState = pod.Status.ContainerStatuses[0].LastTerminationState
+	}
+	pod.Status = running.Status
+	pod.Status.ContainerStatuses[0].RestartCount = restarts
+	pod.Status.ContainerStatuses[0].LastTerminationState = lastState
+
+	_, err := d.fake.CoreV1().Pods(pod.Namespace).UpdateStatus(ctx, pod, metav1.UpdateOptions{})
+	if err == nil {
+		d.recordEvent(ctx, "Pod", pod.Namespace, pod.Name, "Started", "Started container "+pod.Spec.Containers[0].Name)
+		d.syncDeployments(ctx)
+	}
+	return err
+}
+
+func (d *DemoClient) crashPod(ctx context.Context, pod *corev1.Pod) error {
+	if len(pod.Status.ContainerStatuses) == 0 {
+		return nil
+	}
+	reason, exitCode := "Error", int32(1)
+	if d.rng.Float64() < 0.4 {
+		reason, exitCode = "OOMKilled", 137
+	}
+
+	status := &pod.Status.ContainerStatuses[0]
+	status.Ready = false
+	status.RestartCount++
+	status.LastTerminationState = corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+		ExitCode: exitCode, Reason: reason, FinishedAt: metav1.Now(),
+	}}
+	status.State = corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{
+		Reason:  "CrashLoopBackOff",
+		Message: fmt.Sprintf("back-off 10s restarting failed container=%s pod=%s", status.Name, pod.Name),
+	}}
+	setPodReady(pod, corev1.ConditionFalse)
+
+	_, err := d.fake.CoreV1().Pods(pod.Namespace).UpdateStatus(ctx, pod, metav1.UpdateOptions{})
+	if err == nil {
+		d.recordEvent(ctx, "Pod", pod.Namespace, pod.Name, "BackOff", "Back-off restarting failed container "+status.Name)
+		d.syncDeployments(ctx)
+	}
+	return err
+}
+
+func (d *DemoClient) restartContainer(ctx context.Context, pod *corev1.Pod) error {
+	if len(pod.Status.ContainerStatuses) == 0 {
+		return nil
+	}
+	status := &pod.Status.ContainerStatuses[0]
+	status.RestartCount++
+	status.LastTerminationState = corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+		ExitCode: 0, Reason: "Completed", FinishedAt: metav1.Now(),
+	}}
+	_, err := d.fake.CoreV1().Pods(pod.Namespace).UpdateStatus(ctx, pod, metav1.UpdateOptions{})
+	return err
+}
+
+func (d *DemoClient) evictPod(ctx context.Context, pod *corev1.Pod) error {
+	pod.Status.Phase = corev1.PodFailed
+	pod.Status.Reason = "Evicted"
+	pod.Status.Message = "The node was low on resource: memory."
+	for i := range pod.Status.ContainerStatuses {
+		pod.Status.ContainerStatuses[i].Ready = false
+		pod.Status.ContainerStatuses[i].State = corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+			ExitCode: 137, Reason: "Error", FinishedAt: metav1.Now(),
+		}}
+	}
+	setPodReady(pod, corev1.ConditionFalse)
+
+	_, err := d.fake.CoreV1().Pods(pod.Namespace).UpdateStatus(ctx, pod, metav1.UpdateOptions{})
+	if err == nil {
+		d.recordEvent(ctx, "Pod", pod.Namespace, pod.Name, "Evicted", pod.Status.Message)
+		d.syncDeployments(ctx)
+	}
+	return err
+}
+
+// replacePod deletes a pod and creates a pending replacement from the same owner
+func (d *DemoClient) replacePod(ctx context.Context, pod *corev1.Pod) error {
+	if err := d.fake.CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{}); err != nil {
+		return err
+	}
+	rs := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{Name: ownerName(pod), Namespace: pod.Namespace},
+		Spec:       appsv1.ReplicaSetSpec{Template: corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: pod.Labels}, Spec: pod.Spec}},
+	}
+	replacement := d.newPod(rs, corev1.PodPending)
+	_, err := d.fake.CoreV1().Pods(pod.Namespace).Create(ctx, replacement, metav1.CreateOptions{})
+	if err == nil {
+		d.recordEvent(ctx, "Pod", pod.Namespace, replacement.Name, "Scheduled", "Waiting for a node")
+		d.syncDeployments(ctx)
+	}
+	return err
+}
+
+// syncDeployments recomputes deployment status from the pods they own
+func (d *DemoClient) syncDeployments(ctx context.Context) {
+	deployments, err := d.fake.AppsV1().Deployments("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return
+	}
+	pods, err := d.fake.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return
+	}
+
+	for i := range deployments.Items {
+		deployment := &deployments.Items[i]
+		var total, ready int32
+		for j := range pods.Items {
+			pod := &pods.Items[j]
+			if pod.Namespace != deployment.Namespace || pod.Labels["app"] != deployment.Labels["app"] {
+				continue
+			}
+			total++
+			if isPodReady(pod) {
+				ready++
+			}
+		}
+		if deployment.Status.Replicas == total && deployment.Status.ReadyReplicas == ready {
+			continue
+		}
+		deployment.Status.Replicas = total
+		deployment.Status.UpdatedReplicas = total
+		deployment.Status.ReadyReplicas = ready
+		deployment.Status.AvailableReplicas = ready
+		d.fake.AppsV1().Deployments(deployment.Namespace).UpdateStatus(ctx, deployment, metav1.UpdateOptions{})
+	}
+}
+
+func (d *DemoClient) recordEvent(ctx context.Context, kind, namespace, name, reason, message string) {
+	eventNamespace := namespace
+	if eventNamespace == "" {
+		eventNamespace = "default"
+	}
+	d.seq++
+	eventType := corev1.EventTypeNormal
+	if reason == "BackOff" || reason == "Evicted" || strings.Contains(message, "NotReady") {
+		eventType = corev1.EventTypeWarning
+	}
+	now := metav1.Now()
+	eventName := fmt.Sprintf("%s.%x", name, d.seq)
+	_, err := d.fake.CoreV1().Events(eventNamespace).Create(ctx, &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: eventName, Namespace: eventNamespace},
+		InvolvedObject: corev1.ObjectReference{Kind: kind, Namespace: namespace, Name: name},
+		Reason:         reason,
+		Message:        message,
+		Type:           eventType,
+		Source:         corev1.EventSource{Component: "demo-simulator"},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return
+	}
+
+	d.events = append(d.events, types.NamespacedName{Namespace: eventNamespace, Name: eventName})
+	for len(d.events) > demoMaxEvents {
+		oldest := d.events[0]
+		d.events = d.events[1:]
+		d.fake.CoreV1().Events(oldest.Namespace).Delete(ctx, oldest.Name, metav1.DeleteOptions{})
+	}
+}
+
+func isCrashing(pod *corev1.Pod) bool {
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.State.Waiting != nil && cs.State.Waiting.Reason == "CrashLoopBackOff" {
+			return true
+		}
+	}
+	return false
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func setPodReady(pod *corev1.Pod, status corev1.ConditionStatus) {
+	for i := range pod.Status.Conditions {
+		if pod.Status.Conditions[i].Type == corev1.PodReady {
+			pod.Status.Conditions[i].Status = status
+			return
+		}
+	}
+	pod.Status.Conditions = append(pod.Status.Conditions, corev1.PodCondition{Type: corev1.PodReady, Status: status})
+}
+
+func ownerName(pod *corev1.Pod) string {
+	for _, ref := range pod.OwnerReferences {
+		if ref.Controller != nil && *ref.Controller {
+			return ref.Name
+		}
+	}
+	return pod.Name
+}
+
+func boolPtr(b bool) *bool { return &b }
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDemoClient_PopulatesCluster(t *testing.T) {
+	client := NewDemoClient(DemoOptions{Nodes: 3, Namespaces: 2, Deployments: 2, EventsPerSec: 10, Seed: 1})
+
+	var _ K8sClientInterface = client
+	metrics, err := client.GetClusterMetrics(context.Background())
+	if err != nil {
+		t.Fatalf("GetClusterMetrics failed: %v", err)
+	}
+	if metrics.TotalNodes != 3 || metrics.TotalNamespaces != 2 {
+		t.Fatalf("expected 3 nodes and 2 namespaces, got %d and %d", metrics.TotalNodes, metrics.TotalNamespaces)
+	}
+	if metrics.TotalPods < 4 {
+		t.Fatalf("expected at least one pod per deployment, got %d", metrics.TotalPods)
+	}
+
+	deployments, err := client.GetClientset().AppsV1().Deployments("demo-0").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("list deployments failed: %v", err)
+	}
+	for _, d := range deployments.Items {
+		if d.Status.ReadyReplicas != *d.Spec.Replicas {
+			t.Fatalf("expected %s to start fully ready, got %d/%d", d.Name, d.Status.ReadyReplicas, *d.Spec.Replicas)
+		}
+	}
+}
+
+func TestDemoClient_StepEmitsWatchEvents(t *testing.T) {
+	client := NewDemoClient(DemoOptions{Nodes: 2, Namespaces: 1, Deployments: 2, EventsPerSec: 10, Seed: 42, NodeFlapChance: 0.2})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	watcher, err := client.GetClientset().CoreV1().Pods("").Watch(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("watch failed: %v", err)
+	}
+	defer watcher.Stop()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			if err := client.Step(ctx); err != nil {
+				t.Errorf("step %d failed: %v", i, err)
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-watcher.ResultChan():
+	case <-ctx.Done():
+		t.Fatal("expected a pod watch event from simulated churn")
+	}
+	<-done
+
+	logs, err := client.GetPodLogs(ctx, "demo-0", "app-0", nil)
+	if err != nil {
+		t.Fatalf("GetPodLogs failed: %v", err)
+	}
+	logs.Close()
+}
+
+func TestDemoClient_PrunesOldestEvents(t *testing.T) {
+	client := NewDemoClient(DemoOptions{Nodes: 1, Namespaces: 1, Deployments: 1, EventsPerSec: 10, Seed: 1})
+	ctx := context.Background()
+
+	for i := 0; i < demoMaxEvents+10; i++ {
+		client.recordEvent(ctx, "Pod", "demo-0", "app-0", "Started", "Started container")
+	}
+
+	events, err := client.GetClientset().CoreV1().Events("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("list events failed: %v", err)
+	}
+	if len(events.Items) > demoMaxEvents {
+		t.Fatalf("expected at most %d events, got %d", demoMaxEvents, len(events.Items))
+	}
+	if _, err := client.GetClientset().CoreV1().Events("demo-0").Get(ctx, client.events[0].Name, metav1.GetOptions{}); err != nil {
+		t.Fatalf("expected the oldest retained event to exist: %v", err)
+	}
+}
@@ -0,0 +1,331 @@
+// internal/services/diff.go
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// StateDiff describes what changed between two cluster snapshots
+type StateDiff struct {
+	BeforeTime        time.Time          `json:"before_time"`
+	AfterTime         time.Time          `json:"after_time"`
+	PodsAdded         []PodRef           `json:"pods_added"`
+	PodsRemoved       []PodRef           `json:"pods_removed"`
+	DeploymentChanges []DeploymentChange `json:"deployment_changes"`
+	NodeChanges       []NodeChange       `json:"node_changes"`
+	NamespacesAdded   []string           `json:"namespaces_added"`
+	NamespacesRemoved []string           `json:"namespaces_removed"`
+}
+
+// PodRef identifies a pod in a diff
+type PodRef struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Node      string `json:"node,omitempty"`
+	Phase     string `json:"phase"`
+}
+
+// DeploymentChange describes an added, removed or modified deployment
+type DeploymentChange struct {
+	Namespace      string            `json:"namespace"`
+	Name           string            `json:"name"`
+	Change         string            `json:"change"` // "added", "removed" or "modified"
+	ReplicasBefore *int32            `json:"replicas_before,omitempty"`
+	ReplicasAfter  *int32            `json:"replicas_after,omitempty"`
+	ImagesBefore   map[string]string `json:"images_before,omitempty"`
+	ImagesAfter    map[string]string `json:"images_after,omitempty"`
+}
+
+// NodeChange describes a node whose readiness changed, appeared or disappeared
+type NodeChange struct {
+	Name         string `json:"name"`
+	StatusBefore string `json:"status_before,omitempty"`
+	StatusAfter  string `json:"status_after,omitempty"`
+}
+
+// IsEmpty reports whether nothing changed
+func (d *StateDiff) IsEmpty() bool {
+	return len(d.PodsAdded) == 0 && len(d.PodsRemoved) == 0 && len(d.DeploymentChanges) == 0 &&
+		len(d.NodeChanges) == 0 && len(d.NamespacesAdded) == 0 && len(d.NamespacesRemoved) == 0
+}
+
+// DiffSnapshots compares two snapshots. Pods are matched by namespace, name
+// and UID, so a pod recreated under the same name shows as removed and added.
+func DiffSnapshots(before, after *ClusterSnapshot) *StateDiff {
+	diff := &StateDiff{
+		BeforeTime:        before.Time,
+		AfterTime:         after.Time,
+		PodsAdded:         make([]PodRef, 0),
+		PodsRemoved:       make([]PodRef, 0),
+		DeploymentChanges: make([]DeploymentChange, 0),
+		NodeChanges:       make([]NodeChange, 0),
+		NamespacesAdded:   make([]string, 0),
+		NamespacesRemoved: make([]string, 0),
+	}
+
+	// Pods
+	podKey := func(pod *corev1.Pod) string {
+		return pod.Namespace + "/" + pod.Name + "/" + string(pod.UID)
+	}
+	podRef := func(pod *corev1.Pod) PodRef {
+		return PodRef{Namespace: pod.Namespace, Name: pod.Name, Node: pod.Spec.NodeName, Phase: string(pod.Status.Phase)}
+	}
+	beforePods := make(map[string]*corev1.Pod, len(before.Pods))
+	for i := range before.Pods {
+		beforePods[podKey(&before.Pods[i])] = &before.Pods[i]
+	}
+	afterPods := make(map[string]*corev1.Pod, len(after.Pods))
+	for i := range after.Pods {
+		afterPods[podKey(&after.Pods[i])] = &after.Pods[i]
+	}
+	for key, pod := range afterPods {
+		if _, ok := beforePods[key]; !ok {
+			diff.PodsAdded = append(diff.PodsAdded, podRef(pod))
+		}
+	}
+	for key, pod := range beforePods {
+		if _, ok := afterPods[key]; !ok {
+			diff.PodsRemoved = append(diff.PodsRemoved, podRef(pod))
+		}
+	}
+	sortPodRefs(diff.PodsAdded)
+	sortPodRefs(diff.PodsRemoved)
+
+	// Deployments
+	beforeDeploys := make(map[string]*appsv1.Deployment, len(before.Deployments))
+	for i := range before.Deployments {
+		d := &before.Deployments[i]
+		beforeDeploys[d.Namespace+"/"+d.Name] = d
+	}
+	afterDeploys := make(map[string]*appsv1.Deployment, len(after.Deployments))
+	for i := range after.Deployments {
+		d := &after.Deployments[i]
+		afterDeploys[d.Namespace+"/"+d.Name] = d
+	}
+	for key, a := range afterDeploys {
+		b, ok := beforeDeploys[key]
+		if !ok {
+			diff.DeploymentChanges = append(diff.DeploymentChanges, DeploymentChange{
+				Namespace: a.Namespace, Name: a.Name, Change: "added",
+				ReplicasAfter: a.Spec.Replicas, ImagesAfter: deploymentImages(a),
+			})
+			continue
+		}
+		change := DeploymentChange{Namespace: a.Namespace, Name: a.Name, Change: "modified"}
+		changed := false
+		if !int32PtrEqual(b.Spec.Replicas, a.Spec.Replicas) {
+			change.ReplicasBefore, change.ReplicasAfter = b.Spec.Replicas, a.Spec.Replicas
+			changed = true
+		}
+		beforeImages, afterImages := deploymentImages(b), deploymentImages(a)
+		if !stringMapEqual(beforeImages, afterImages) {
+			change.ImagesBefore, change.ImagesAfter = beforeImages, afterImages
+			changed = true
+		}
+		if changed {
+			diff.DeploymentChanges = append(diff.DeploymentChanges, change)
+		}
+	}
+	for key, b := range beforeDeploys {
+		if _, ok := afterDeploys[key]; !ok {
+			diff.DeploymentChanges = append(diff.DeploymentChanges, DeploymentChange{
+				Namespace: b.Namespace, Name: b.Name, Change: "removed",
+				ReplicasBefore: b.Spec.Replicas, ImagesBefore: deploymentImages(b),
+			})
+		}
+	}
+	sort.Slice(diff.DeploymentChanges, func(i, j int) bool {
+		a, b := diff.DeploymentChanges[i], diff.DeploymentChanges[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+
+	// Nodes
+	beforeNodes := make(map[string]string, len(before.Nodes))
+	for i := range before.Nodes {
+		beforeNodes[before.Nodes[i].Name] = nodeReadyStatus(&before.Nodes[i])
+	}
+	afterNodes := make(map[string]string, len(after.Nodes))
+	for i := range after.Nodes {
+		afterNodes[after.Nodes[i].Name] = nodeReadyStatus(&after.Nodes[i])
+	}
+	for name, status := range afterNodes {
+		if prev, ok := beforeNodes[name]; !ok || prev != status {
+			diff.NodeChanges = append(diff.NodeChanges, NodeChange{Name: name, StatusBefore: prev, StatusAfter: status})
+		}
+	}
+	for name, status := range beforeNodes {
+		if _, ok := afterNodes[name]; !ok {
+			diff.NodeChanges = append(diff.NodeChanges, NodeChange{Name: name, StatusBefore: status})
+		}
+	}
+	sort.Slice(diff.NodeChanges, func(i, j int) bool { return diff.NodeChanges[i].Name < diff.NodeChanges[j].Name })
+
+	// Namespaces
+	beforeNamespaces := make(map[string]bool, len(before.Namespaces))
+	for _, ns := range before.Namespaces {
+		beforeNamespaces[ns.Name] = true
+	}
+	afterNamespaces := make(map[string]bool, len(after.Namespaces))
+	for _, ns := range after.Namespaces {
+		afterNamespaces[ns.Name] = true
+		if !beforeNamespaces[ns.Name] {
+			diff.NamespacesAdded = append(diff.NamespacesAdded, ns.Name)
+		}
+	}
+	for _, ns := range before.Namespaces {
+		if !afterNamespaces[ns.Name] {
+			diff.NamespacesRemoved = append(diff.NamespacesRemoved, ns.Name)
+		}
+	}
+	sort.Strings(diff.NamespacesAdded)
+	sort.Strings(diff.NamespacesRemoved)
+
+	return diff
+}
+
+// Report renders the diff as a human-readable text report
+func (d *StateDiff) Report() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Cluster changes from %s to %s\n", d.BeforeTime.Format(time.RFC3339), d.AfterTime.Format(time.RFC3339))
+	if d.IsEmpty() {
+		b.WriteString("\nNo changes.\n")
+		return b.String()
+	}
+
+	if len(d.NodeChanges) > 0 {
+		b.WriteString("\nNodes:\n")
+		for _, n := range d.NodeChanges {
+			switch {
+			case n.StatusBefore == "":
+				fmt.Fprintf(&b, "  + %s (%s)\n", n.Name, n.StatusAfter)
+			case n.StatusAfter == "":
+				fmt.Fprintf(&b, "  - %s (was %s)\n", n.Name, n.StatusBefore)
+			default:
+				marker := "~"
+				if n.StatusAfter == "NotReady" {
+					marker = "!"
+				}
+				fmt.Fprintf(&b, "  %s %s: %s -> %s\n", marker, n.Name, n.StatusBefore, n.StatusAfter)
+			}
+		}
+	}
+
+	if len(d.NamespacesAdded) > 0 || len(d.NamespacesRemoved) > 0 {
+		b.WriteString("\nNamespaces:\n")
+		for _, ns := range d.NamespacesAdded {
+			fmt.Fprintf(&b, "  + %s\n", ns)
+		}
+		for _, ns := range d.NamespacesRemoved {
+			fmt.Fprintf(&b, "  - %s\n", ns)
+		}
+	}
+
+	if len(d.DeploymentChanges) > 0 {
+		b.WriteString("\nDeployments:\n")
+		for _, c := range d.DeploymentChanges {
+			switch c.Change {
+			case "added":
+				fmt.Fprintf(&b, "  + %s/%s (replicas %s)\n", c.Namespace, c.Name, formatReplicas(c.ReplicasAfter))
+			case "removed":
+				fmt.Fprintf(&b, "  - %s/%s\n", c.Namespace, c.Name)
+			default:
+				fmt.Fprintf(&b, "  ~ %s/%s\n", c.Namespace, c.Name)
+				if c.ReplicasBefore != nil || c.ReplicasAfter != nil {
+					fmt.Fprintf(&b, "      replicas: %s -> %s\n", formatReplicas(c.ReplicasBefore), formatReplicas(c.ReplicasAfter))
+				}
+				for _, container := range sortedKeys(c.ImagesBefore, c.ImagesAfter) {
+					if c.ImagesBefore[container] != c.ImagesAfter[container] {
+						fmt.Fprintf(&b, "      image %s: %s -> %s\n", container, orNone(c.ImagesBefore[container]), orNone(c.ImagesAfter[container]))
+					}
+				}
+			}
+		}
+	}
+
+	if len(d.PodsAdded) > 0 || len(d.PodsRemoved) > 0 {
+		fmt.Fprintf(&b, "\nPods: %d appeared, %d disappeared\n", len(d.PodsAdded), len(d.PodsRemoved))
+		for _, p := range d.PodsAdded {
+			fmt.Fprintf(&b, "  + %s/%s (%s)\n", p.Namespace, p.Name, p.Phase)
+		}
+		for _, p := range d.PodsRemoved {
+			fmt.Fprintf(&b, "  - %s/%s (was %s)\n", p.Namespace, p.Name, p.Phase)
+		}
+	}
+
+	return b.String()
+}
+
+// deploymentImages maps container name to image for a deployment's pod template
+func deploymentImages(d *appsv1.Deployment) map[string]string {
+	images := make(map[string]string, len(d.Spec.Template.Spec.Containers))
+	for _, container := range d.Spec.Template.Spec.Containers {
+		images[container.Name] = container.Image
+	}
+	return images
+}
+
+func sortPodRefs(refs []PodRef) {
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Namespace != refs[j].Namespace {
+			return refs[i].Namespace < refs[j].Namespace
+		}
+		return refs[i].Name < refs[j].Name
+	})
+}
+
+func int32PtrEqual(a, b *int32) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func stringMapEqual(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedKeys(maps ...map[string]string) []string {
+	seen := make(map[string]bool)
+	keys := make([]string, 0)
+	for _, m := range maps {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatReplicas(r *int32) string {
+	if r == nil {
+		return "unset"
+	}
+	return fmt.Sprintf("%d", *r)
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
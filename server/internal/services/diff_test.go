@@ -0,0 +1,93 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func diffDeployment(name string, replicas int32, image string) appsv1.Deployment {
+	return appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: image}}}},
+		},
+	}
+}
+
+func diffNode(name string, ready corev1.ConditionStatus) corev1.Node {
+	return corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status:     corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}}},
+	}
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	now := time.Now()
+	before := &ClusterSnapshot{
+		Time:       now.Add(-time.Hour),
+		Namespaces: []corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "default"}}},
+		Nodes:      []corev1.Node{diffNode("node-1", corev1.ConditionTrue), diffNode("node-2", corev1.ConditionTrue)},
+		Pods: []corev1.Pod{
+			{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default", UID: "a"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "db-0", Namespace: "default", UID: "b"}},
+		},
+		Deployments: []appsv1.Deployment{diffDeployment("web", 2, "web:1.0"), diffDeployment("old", 1, "old:1")},
+	}
+	after := &ClusterSnapshot{
+		Time: now,
+		Namespaces: []corev1.Namespace{
+			{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "payments"}},
+		},
+		Nodes: []corev1.Node{diffNode("node-1", corev1.ConditionTrue), diffNode("node-2", corev1.ConditionFalse)},
+		Pods: []corev1.Pod{
+			{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default", UID: "a"}},
+			// Recreated with the same name
+			{ObjectMeta: metav1.ObjectMeta{Name: "db-0", Namespace: "default", UID: "c"}},
+		},
+		Deployments: []appsv1.Deployment{diffDeployment("web", 3, "web:1.1")},
+	}
+
+	diff := DiffSnapshots(before, after)
+
+	if len(diff.PodsAdded) != 1 || len(diff.PodsRemoved) != 1 || diff.PodsAdded[0].Name != "db-0" {
+		t.Fatalf("expected db-0 replaced, got added=%+v removed=%+v", diff.PodsAdded, diff.PodsRemoved)
+	}
+	if len(diff.NamespacesAdded) != 1 || diff.NamespacesAdded[0] != "payments" {
+		t.Fatalf("unexpected namespaces added: %v", diff.NamespacesAdded)
+	}
+	if len(diff.NodeChanges) != 1 || diff.NodeChanges[0].Name != "node-2" || diff.NodeChanges[0].StatusAfter != "NotReady" {
+		t.Fatalf("unexpected node changes: %+v", diff.NodeChanges)
+	}
+	if len(diff.DeploymentChanges) != 2 {
+		t.Fatalf("expected 2 deployment changes, got %+v", diff.DeploymentChanges)
+	}
+	removed, modified := diff.DeploymentChanges[0], diff.DeploymentChanges[1]
+	if removed.Name != "old" || removed.Change != "removed" {
+		t.Fatalf("unexpected removed deployment: %+v", removed)
+	}
+	if modified.Name != "web" || *modified.ReplicasBefore != 2 || *modified.ReplicasAfter != 3 || modified.ImagesAfter["app"] != "web:1.1" {
+		t.Fatalf("unexpected modified deployment: %+v", modified)
+	}
+
+	report := diff.Report()
+	for _, want := range []string{"! node-2: Ready -> NotReady", "+ payments", "replicas: 2 -> 3", "image app: web:1.0 -> web:1.1", "1 appeared, 1 disappeared"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report missing %q:\n%s", want, report)
+		}
+	}
+}
+
+func TestDiffSnapshots_NoChanges(t *testing.T) {
+	snapshot := &ClusterSnapshot{Nodes: []corev1.Node{diffNode("node-1", corev1.ConditionTrue)}}
+	diff := DiffSnapshots(snapshot, snapshot)
+	if !diff.IsEmpty() || !strings.Contains(diff.Report(), "No changes.") {
+		t.Fatalf("expected empty diff, got %+v", diff)
+	}
+}
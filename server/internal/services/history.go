@@ -0,0 +1,236 @@
+// internal/services/history.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MetricsSample is a point-in-time summary of ClusterMetrics kept in history
+type MetricsSample struct {
+	Time            time.Time      `json:"time"`
+	TotalNodes      int            `json:"total_nodes"`
+	TotalPods       int            `json:"total_pods"`
+	TotalNamespaces int            `json:"total_namespaces"`
+	Nodes           []NodeSample   `json:"nodes"`
+	Namespaces      map[string]int `json:"namespace_pod_counts"`
+}
+
+// NodeSample is the per-node part of a MetricsSample. Requests are the
+// summed requests of the node's pods, so they track allocation over time.
+type NodeSample struct {
+	Name              string `json:"name"`
+	Status            string `json:"status"`
+	PodCount          int    `json:"pod_count"`
+	CPUAllocatable    string `json:"cpu_allocatable"`
+	MemoryAllocatable string `json:"memory_allocatable"`
+	CPURequests       string `json:"cpu_requests,omitempty"`
+	MemoryRequests    string `json:"memory_requests,omitempty"`
+}
+
+// HistoryTier keeps one sample per Step for Retention
+type HistoryTier struct {
+	Step      time.Duration
+	Retention time.Duration
+}
+
+// ParseHistoryTiers parses "10s:1h,1m:24h" into tiers ordered from finest to
+// coarsest. Each step must be a multiple of the previous one.
+func ParseHistoryTiers(value string) ([]HistoryTier, error) {
+	tiers := make([]HistoryTier, 0)
+	for _, part := range strings.Split(value, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ":")
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid history tier %q, expected step:retention", part)
+		}
+		step, err := time.ParseDuration(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid tier step %q: %w", fields[0], err)
+		}
+		retention, err := time.ParseDuration(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid tier retention %q: %w", fields[1], err)
+		}
+		if step <= 0 || retention < step {
+			return nil, fmt.Errorf("tier %q must have 0 < step <= retention", part)
+		}
+		if n := len(tiers); n > 0 && (step <= tiers[n-1].Step || step%tiers[n-1].Step != 0) {
+			return nil, fmt.Errorf("tier step %s must be a larger multiple of %s", step, tiers[n-1].Step)
+		}
+		tiers = append(tiers, HistoryTier{Step: step, Retention: retention})
+	}
+	if len(tiers) == 0 {
+		return nil, fmt.Errorf("no history tiers configured")
+	}
+	return tiers, nil
+}
+
+// ring is a fixed-size buffer of samples, oldest overwritten first
+type ring struct {
+	samples []MetricsSample
+	next    int
+	full    bool
+}
+
+func newRing(size int) *ring {
+	return &ring{samples: make([]MetricsSample, size)}
+}
+
+func (r *ring) add(s MetricsSample) {
+	r.samples[r.next] = s
+	r.next = (r.next + 1) % len(r.samples)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// ordered returns samples oldest first
+func (r *ring) ordered() []MetricsSample {
+	if !r.full {
+		return append([]MetricsSample(nil), r.samples[:r.next]...)
+	}
+	return append(append([]MetricsSample(nil), r.samples[r.next:]...), r.samples[:r.next]...)
+}
+
+// tierBuffer downsamples into a ring by keeping the last sample of each step
+type tierBuffer struct {
+	tier    HistoryTier
+	ring    *ring
+	pending *MetricsSample
+}
+
+func (t *tierBuffer) add(s MetricsSample) {
+	if t.pending != nil && !s.Time.Truncate(t.tier.Step).Equal(t.pending.Time.Truncate(t.tier.Step)) {
+		t.ring.add(*t.pending)
+	}
+	t.pending = &s
+}
+
+func (t *tierBuffer) samples() []MetricsSample {
+	samples := t.ring.ordered()
+	if t.pending != nil {
+		samples = append(samples, *t.pending)
+	}
+	return samples
+}
+
+// MetricsHistory samples cluster metrics in the background and keeps them
+// in bounded, downsampled tiers
+type MetricsHistory struct {
+	mu       sync.RWMutex
+	interval time.Duration
+	tiers    []*tierBuffer
+}
+
+// NewMetricsHistory creates a history with the given tiers that samples
+// every interval, or every finest-tier step when interval is zero. A
+// coarser interval would leave gaps in the finest tier, so it is rejected.
+func NewMetricsHistory(tiers []HistoryTier, interval time.Duration) (*MetricsHistory, error) {
+	if len(tiers) == 0 {
+		return nil, fmt.Errorf("no history tiers configured")
+	}
+	if interval == 0 {
+		interval = tiers[0].Step
+	}
+	if interval < 0 || interval > tiers[0].Step {
+		return nil, fmt.Errorf("history interval %s must be positive and no coarser than the finest tier step %s", interval, tiers[0].Step)
+	}
+
+	h := &MetricsHistory{interval: interval}
+	for _, tier := range tiers {
+		size := int(tier.Retention / tier.Step)
+		h.tiers = append(h.tiers, &tierBuffer{tier: tier, ring: newRing(size)})
+	}
+	return h, nil
+}
+
+// Run samples metrics every interval until ctx is cancelled
+func (h *MetricsHistory) Run(ctx context.Context, collect func(context.Context) (*ClusterMetrics, error)) {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		metrics, err := collect(ctx)
+		if err != nil {
+			log.Printf("Failed to sample cluster metrics: %v", err)
+		} else {
+			h.Record(time.Now(), metrics)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Record adds a sample taken at t to every tier
+func (h *MetricsHistory) Record(t time.Time, metrics *ClusterMetrics) {
+	sample := MetricsSample{
+		Time:            t,
+		TotalNodes:      metrics.TotalNodes,
+		TotalPods:       metrics.TotalPods,
+		TotalNamespaces: metrics.TotalNamespaces,
+		Nodes:           make([]NodeSample, 0, len(metrics.NodeMetrics)),
+		Namespaces:      make(map[string]int, len(metrics.NamespaceMetrics)),
+	}
+	for _, node := range metrics.NodeMetrics {
+		sample.Nodes = append(sample.Nodes, NodeSample{
+			Name:              node.Name,
+			Status:            node.Status,
+			PodCount:          node.PodCount,
+			CPUAllocatable:    node.CPUAllocatable,
+			MemoryAllocatable: node.MemoryAllocatable,
+			CPURequests:       node.CPURequests,
+			MemoryRequests:    node.MemoryRequests,
+		})
+	}
+	for _, ns := range metrics.NamespaceMetrics {
+		sample.Namespaces[ns.Name] = ns.PodCount
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, tier := range h.tiers {
+		tier.add(sample)
+	}
+}
+
+// Query returns samples from the last window, at most one per step. It reads
+// from the finest tier whose retention covers window; a step finer than that
+// tier is raised to the tier's step. The returned step is the effective one.
+func (h *MetricsHistory) Query(now time.Time, window, step time.Duration) ([]MetricsSample, time.Duration) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	source := h.tiers[len(h.tiers)-1]
+	for _, tier := range h.tiers {
+		if tier.tier.Retention >= window {
+			source = tier
+			break
+		}
+	}
+	if step < source.tier.Step {
+		step = source.tier.Step
+	}
+
+	since := now.Add(-window)
+	result := make([]MetricsSample, 0)
+	for _, sample := range source.samples() {
+		if sample.Time.Before(since) {
+			continue
+		}
+		// Keep only the last sample of each requested step
+		if n := len(result); n > 0 && sample.Time.Truncate(step).Equal(result[n-1].Time.Truncate(step)) {
+			result[n-1] = sample
+			continue
+		}
+		result = append(result, sample)
+	}
+	return result, step
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestParseHistoryTiers(t *testing.T) {
+	tiers, err := ParseHistoryTiers("10s:1h, 1m:24h")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tiers) != 2 || tiers[0].Step != 10*time.Second || tiers[1].Retention != 24*time.Hour {
+		t.Fatalf("unexpected tiers: %+v", tiers)
+	}
+
+	for _, bad := range []string{"", "10s", "1m:10s", "1m:1h,10s:24h", "10s:1h,15s:24h"} {
+		if _, err := ParseHistoryTiers(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestNewMetricsHistory_ValidatesInterval(t *testing.T) {
+	tiers := []HistoryTier{
+		{Step: 10 * time.Second, Retention: time.Minute},
+		{Step: time.Minute, Retention: 10 * time.Minute},
+	}
+	for _, interval := range []time.Duration{0, 5 * time.Second, 10 * time.Second} {
+		if _, err := NewMetricsHistory(tiers, interval); err != nil {
+			t.Errorf("interval %s: unexpected error %v", interval, err)
+		}
+	}
+	for _, interval := range []time.Duration{-time.Second, 30 * time.Second} {
+		if _, err := NewMetricsHistory(tiers, interval); err == nil {
+			t.Errorf("interval %s: expected an error", interval)
+		}
+	}
+}
+
+func TestMetricsHistory_DownsamplesAndBoundsTiers(t *testing.T) {
+	h, err := NewMetricsHistory([]HistoryTier{
+		{Step: 10 * time.Second, Retention: time.Minute},
+		{Step: time.Minute, Retention: 10 * time.Minute},
+	}, 0)
+	if err != nil {
+		t.Fatalf("NewMetricsHistory: %v", err)
+	}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// 5 minutes of samples every 10s; pod count tracks the sample index
+	var now time.Time
+	for i := 0; i < 30; i++ {
+		now = start.Add(time.Duration(i) * 10 * time.Second)
+		h.Record(now, &ClusterMetrics{
+			TotalPods:        i,
+			NodeMetrics:      []NodeMetrics{{Name: "node-1", PodCount: i, CPURequests: fmt.Sprintf("%dm", i*100)}},
+			NamespaceMetrics: []NamespaceMetrics{{Name: "default", PodCount: i}},
+		})
+	}
+
+	fine, step := h.Query(now, time.Minute, 0)
+	if step != 10*time.Second {
+		t.Fatalf("expected 10s step for 1m range, got %s", step)
+	}
+	// Ring holds 6 completed samples plus the pending one, filtered to the last minute
+	if len(fine) != 7 || fine[len(fine)-1].TotalPods != 29 {
+		t.Fatalf("unexpected fine samples: %d, last=%+v", len(fine), fine[len(fine)-1])
+	}
+
+	coarse, step := h.Query(now, 5*time.Minute, 0)
+	if step != time.Minute {
+		t.Fatalf("expected 1m step for 5m range, got %s", step)
+	}
+	if len(coarse) != 5 {
+		t.Fatalf("expected 5 one-minute samples, got %d", len(coarse))
+	}
+	// Each minute keeps its last 10s sample
+	if coarse[0].TotalPods != 5 || coarse[0].Namespaces["default"] != 5 || coarse[0].Nodes[0].PodCount != 5 || coarse[0].Nodes[0].CPURequests != "500m" {
+		t.Fatalf("unexpected first coarse sample: %+v", coarse[0])
+	}
+
+	thinned, step := h.Query(now, time.Minute, 30*time.Second)
+	if step != 30*time.Second || len(thinned) != 3 {
+		t.Fatalf("expected 3 samples at 30s, got %d at %s", len(thinned), step)
+	}
+}
@@ -0,0 +1,344 @@
+// internal/services/images.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// defaultRegistry is where image references without a registry resolve to
+const defaultRegistry = "docker.io"
+
+// ImageRef is a parsed image reference
+type ImageRef struct {
+	Registry   string `json:"registry"`
+	Repository string `json:"repository"` // Without the registry, e.g. library/nginx
+	Tag        string `json:"tag,omitempty"`
+	Digest     string `json:"digest,omitempty"`
+	// ImplicitTag is set when the reference names neither tag nor digest
+	// and Tag was defaulted to latest
+	ImplicitTag bool `json:"-"`
+}
+
+// ParseImageRef splits an image reference into registry, repository, tag
+// and digest, applying Docker Hub defaults the way the container runtime does
+func ParseImageRef(image string) ImageRef {
+	var ref ImageRef
+	name := image
+	if at := strings.Index(name, "@"); at >= 0 {
+		ref.Digest = name[at+1:]
+		name = name[:at]
+	}
+	if colon := strings.LastIndex(name, ":"); colon > strings.LastIndex(name, "/") {
+		ref.Tag = name[colon+1:]
+		name = name[:colon]
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = "latest"
+		ref.ImplicitTag = true
+	}
+
+	// The first component is a registry only if it looks like a host
+	if slash := strings.Index(name, "/"); slash >= 0 {
+		host := name[:slash]
+		if strings.ContainsAny(host, ".:") || host == "localhost" {
+			ref.Registry = host
+			name = name[slash+1:]
+		}
+	}
+	if ref.Registry == "" {
+		ref.Registry = defaultRegistry
+	}
+	if ref.Registry == defaultRegistry && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	ref.Repository = name
+	return ref
+}
+
+// FullRepository is the registry and repository, e.g. docker.io/library/nginx
+func (r ImageRef) FullRepository() string {
+	return r.Registry + "/" + r.Repository
+}
+
+// Version is the tag, digest or both as written in the reference
+func (r ImageRef) Version() string {
+	switch {
+	case r.Tag != "" && r.Digest != "":
+		return r.Tag + "@" + r.Digest
+	case r.Digest != "":
+		return "@" + r.Digest
+	default:
+		return r.Tag
+	}
+}
+
+// ImagePod is one container running an image
+type ImagePod struct {
+	Namespace    string `json:"namespace"`
+	Pod          string `json:"pod"`
+	Node         string `json:"node"`
+	Container    string `json:"container"`
+	WorkloadKind string `json:"workload_kind"`
+	Workload     string `json:"workload"`
+	Digest       string `json:"digest,omitempty"`  // Resolved digest from the container status imageID
+	Drifted      bool   `json:"drifted,omitempty"` // Digest differs from most replicas of the workload
+}
+
+// ImageVersion is one tag or digest of a repository and where it runs
+type ImageVersion struct {
+	Image      string     `json:"image"`
+	Tag        string     `json:"tag,omitempty"`
+	Digest     string     `json:"digest,omitempty"`
+	Digests    []string   `json:"resolved_digests"`
+	Pods       []ImagePod `json:"pods"`
+	Namespaces []string   `json:"namespaces"`
+	Nodes      []string   `json:"nodes"`
+	PodCount   int        `json:"pod_count"`
+}
+
+// ImageRepository groups the versions of one repository
+type ImageRepository struct {
+	Repository string         `json:"repository"` // Including the registry
+	Registry   string         `json:"registry"`
+	Versions   []ImageVersion `json:"versions"`
+	PodCount   int            `json:"pod_count"`
+}
+
+// RegistryUsage counts what is pulled from a registry
+type RegistryUsage struct {
+	Registry     string `json:"registry"`
+	Repositories int    `json:"repositories"`
+	Containers   int    `json:"containers"`
+}
+
+// DigestDrift is a workload container whose replicas run different digests
+// of the same image reference, e.g. after a mutable tag was re-pushed
+type DigestDrift struct {
+	Namespace    string              `json:"namespace"`
+	WorkloadKind string              `json:"workload_kind"`
+	Workload     string              `json:"workload"`
+	Container    string              `json:"container"`
+	Image        string              `json:"image"`
+	Digests      map[string][]string `json:"digests"` // Digest to pod names
+}
+
+// ImageInventory lists running images grouped by repository
+type ImageInventory struct {
+	Repositories []ImageRepository `json:"repositories"`
+	Registries   []RegistryUsage   `json:"registries"`
+	Drift        []DigestDrift     `json:"drift"`
+	Count        int               `json:"count"` // Distinct image references
+}
+
+// GetImageInventory lists pods in namespace (all when empty) and builds the
+// image inventory
+func GetImageInventory(ctx context.Context, clientset kubernetes.Interface, namespace string) (*ImageInventory, error) {
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	return BuildImageInventory(pods.Items), nil
+}
+
+// BuildImageInventory groups the images of non-terminal pods by repository
+// and version and detects digest drift between replicas
+func BuildImageInventory(pods []corev1.Pod) *ImageInventory {
+	inventory := &ImageInventory{
+		Repositories: make([]ImageRepository, 0),
+		Registries:   make([]RegistryUsage, 0),
+		Drift:        make([]DigestDrift, 0),
+	}
+
+	type workloadKey struct{ namespace, kind, name, container, image string }
+	versions := make(map[string]*ImageVersion)
+	refs := make(map[string]ImageRef)
+	replicas := make(map[workloadKey][]ImagePod)
+
+	for i := range pods {
+		pod := &pods[i]
+		if podIsTerminal(pod) {
+			continue
+		}
+		kind, workload := podWorkload(pod)
+		digests := statusDigests(pod)
+
+		for _, container := range podContainers(pod) {
+			version, ok := versions[container.Image]
+			if !ok {
+				ref := ParseImageRef(container.Image)
+				version = &ImageVersion{Image: container.Image, Tag: ref.Tag, Digest: ref.Digest}
+				versions[container.Image] = version
+				refs[container.Image] = ref
+			}
+			imagePod := ImagePod{
+				Namespace:    pod.Namespace,
+				Pod:          pod.Name,
+				Node:         pod.Spec.NodeName,
+				Container:    container.Name,
+				WorkloadKind: kind,
+				Workload:     workload,
+				Digest:       digests[container.Name],
+			}
+			version.Pods = append(version.Pods, imagePod)
+			if kind != "Pod" {
+				key := workloadKey{pod.Namespace, kind, workload, container.Name, container.Image}
+				replicas[key] = append(replicas[key], imagePod)
+			}
+		}
+	}
+
+	// Replicas off the most common digest are flagged as drifted
+	drifted := make(map[string]bool)
+	for key, group := range replicas {
+		byDigest := make(map[string][]string)
+		for _, p := range group {
+			if p.Digest != "" {
+				byDigest[p.Digest] = append(byDigest[p.Digest], p.Pod)
+			}
+		}
+		if len(byDigest) < 2 {
+			continue
+		}
+
+		majority := ""
+		for digest, names := range byDigest {
+			if majority == "" || len(names) > len(byDigest[majority]) || (len(names) == len(byDigest[majority]) && digest < majority) {
+				majority = digest
+			}
+		}
+		for digest, names := range byDigest {
+			sort.Strings(names)
+			if digest == majority {
+				continue
+			}
+			for _, name := range names {
+				drifted[key.namespace+"/"+name+"/"+key.container] = true
+			}
+		}
+		inventory.Drift = append(inventory.Drift, DigestDrift{
+			Namespace:    key.namespace,
+			WorkloadKind: key.kind,
+			Workload:     key.name,
+			Container:    key.container,
+			Image:        key.image,
+			Digests:      byDigest,
+		})
+	}
+
+	repositories := make(map[string]*ImageRepository)
+	registries := make(map[string]*RegistryUsage)
+	for image, version := range versions {
+		ref := refs[image]
+		namespaces := make(map[string]bool)
+		nodes := make(map[string]bool)
+		digests := make(map[string]bool)
+		for i := range version.Pods {
+			p := &version.Pods[i]
+			p.Drifted = drifted[p.Namespace+"/"+p.Pod+"/"+p.Container]
+			namespaces[p.Namespace] = true
+			if p.Node != "" {
+				nodes[p.Node] = true
+			}
+			if p.Digest != "" {
+				digests[p.Digest] = true
+			}
+		}
+		sort.Slice(version.Pods, func(i, j int) bool {
+			a, b := version.Pods[i], version.Pods[j]
+			if a.Namespace != b.Namespace {
+				return a.Namespace < b.Namespace
+			}
+			if a.Pod != b.Pod {
+				return a.Pod < b.Pod
+			}
+			return a.Container < b.Container
+		})
+		version.Namespaces = setKeys(namespaces)
+		version.Nodes = setKeys(nodes)
+		version.Digests = setKeys(digests)
+		version.PodCount = len(version.Pods)
+
+		repo, ok := repositories[ref.FullRepository()]
+		if !ok {
+			repo = &ImageRepository{Repository: ref.FullRepository(), Registry: ref.Registry}
+			repositories[ref.FullRepository()] = repo
+		}
+		repo.Versions = append(repo.Versions, *version)
+		repo.PodCount += version.PodCount
+
+		registry, ok := registries[ref.Registry]
+		if !ok {
+			registry = &RegistryUsage{Registry: ref.Registry}
+			registries[ref.Registry] = registry
+		}
+		registry.Containers += version.PodCount
+	}
+
+	for _, repo := range repositories {
+		sort.Slice(repo.Versions, func(i, j int) bool { return repo.Versions[i].Image < repo.Versions[j].Image })
+		registries[repo.Registry].Repositories++
+		inventory.Repositories = append(inventory.Repositories, *repo)
+	}
+	sort.Slice(inventory.Repositories, func(i, j int) bool {
+		return inventory.Repositories[i].Repository < inventory.Repositories[j].Repository
+	})
+	for _, registry := range registries {
+		inventory.Registries = append(inventory.Registries, *registry)
+	}
+	sort.Slice(inventory.Registries, func(i, j int) bool {
+		return inventory.Registries[i].Registry < inventory.Registries[j].Registry
+	})
+	sort.Slice(inventory.Drift, func(i, j int) bool {
+		a, b := inventory.Drift[i], inventory.Drift[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Workload != b.Workload {
+			return a.Workload < b.Workload
+		}
+		return a.Container < b.Container
+	})
+	inventory.Count = len(versions)
+	return inventory
+}
+
+// statusDigests maps container names to the digest the runtime resolved,
+// taken from imageID values such as docker-pullable://nginx@sha256:...
+func statusDigests(pod *corev1.Pod) map[string]string {
+	digests := make(map[string]string)
+	for _, statuses := range [][]corev1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses} {
+		for _, status := range statuses {
+			if digest := imageIDDigest(status.ImageID); digest != "" {
+				digests[status.Name] = digest
+			}
+		}
+	}
+	return digests
+}
+
+func imageIDDigest(imageID string) string {
+	if at := strings.LastIndex(imageID, "@"); at >= 0 {
+		return imageID[at+1:]
+	}
+	if strings.HasPrefix(imageID, "sha256:") {
+		return imageID
+	}
+	return ""
+}
+
+// setKeys returns the keys of a set, sorted
+func setKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
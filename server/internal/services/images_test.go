@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParseImageRef(t *testing.T) {
+	tests := []struct {
+		image string
+		want  ImageRef
+	}{
+		{"nginx", ImageRef{Registry: "docker.io", Repository: "library/nginx", Tag: "latest", ImplicitTag: true}},
+		{"bitnami/redis:7.2", ImageRef{Registry: "docker.io", Repository: "bitnami/redis", Tag: "7.2"}},
+		{"registry.local:5000/team/api:1.0", ImageRef{Registry: "registry.local:5000", Repository: "team/api", Tag: "1.0"}},
+		{"localhost/dev", ImageRef{Registry: "localhost", Repository: "dev", Tag: "latest", ImplicitTag: true}},
+		{"nginx:latest", ImageRef{Registry: "docker.io", Repository: "library/nginx", Tag: "latest"}},
+		{"ghcr.io/org/app@sha256:abc", ImageRef{Registry: "ghcr.io", Repository: "org/app", Digest: "sha256:abc"}},
+		{"quay.io/app:2@sha256:def", ImageRef{Registry: "quay.io", Repository: "app", Tag: "2", Digest: "sha256:def"}},
+	}
+	for _, tt := range tests {
+		if got := ParseImageRef(tt.image); got != tt.want {
+			t.Errorf("ParseImageRef(%q) = %+v, want %+v", tt.image, got, tt.want)
+		}
+	}
+}
+
+func TestGetImageInventory(t *testing.T) {
+	controller := true
+	replica := func(name, node, imageID string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       "shop",
+				Labels:          map[string]string{"pod-template-hash": "abc"},
+				OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web-abc", Controller: &controller}},
+			},
+			Spec: corev1.PodSpec{
+				NodeName:   node,
+				Containers: []corev1.Container{{Name: "web", Image: "nginx:1.25"}},
+			},
+			Status: corev1.PodStatus{
+				Phase:             corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "web", ImageID: imageID}},
+			},
+		}
+	}
+	sidecar := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "debug", Namespace: "tools"},
+		Spec: corev1.PodSpec{
+			NodeName:   "node-2",
+			Containers: []corev1.Container{{Name: "nginx", Image: "docker.io/library/nginx:1.24"}, {Name: "sh", Image: "ghcr.io/org/tools:1"}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	finished := replica("web-old", "node-3", "docker-pullable://nginx@sha256:000")
+	finished.Status.Phase = corev1.PodSucceeded
+
+	clientset := fake.NewSimpleClientset(
+		replica("web-1", "node-1", "docker-pullable://nginx@sha256:aaa"),
+		replica("web-2", "node-2", "docker-pullable://nginx@sha256:aaa"),
+		replica("web-3", "node-2", "sha256:bbb"),
+		sidecar,
+		finished,
+	)
+
+	inventory, err := GetImageInventory(context.Background(), clientset, "")
+	if err != nil {
+		t.Fatalf("GetImageInventory failed: %v", err)
+	}
+
+	if inventory.Count != 3 || len(inventory.Repositories) != 2 {
+		t.Fatalf("expected 3 images in 2 repositories, got %+v", inventory)
+	}
+	nginx := inventory.Repositories[0]
+	if nginx.Repository != "docker.io/library/nginx" || len(nginx.Versions) != 2 || nginx.PodCount != 4 {
+		t.Fatalf("unexpected nginx repository %+v", nginx)
+	}
+	v125 := nginx.Versions[1]
+	if v125.Tag != "1.25" || v125.PodCount != 3 || len(v125.Nodes) != 2 || len(v125.Digests) != 2 {
+		t.Errorf("unexpected nginx:1.25 version %+v", v125)
+	}
+	for _, p := range v125.Pods {
+		if p.Workload != "web" || p.WorkloadKind != "Deployment" {
+			t.Errorf("unexpected workload for %s: %s/%s", p.Pod, p.WorkloadKind, p.Workload)
+		}
+		if p.Drifted != (p.Pod == "web-3") {
+			t.Errorf("unexpected drift flag for %s: %v", p.Pod, p.Drifted)
+		}
+	}
+
+	if len(inventory.Drift) != 1 {
+		t.Fatalf("expected one drift entry, got %+v", inventory.Drift)
+	}
+	drift := inventory.Drift[0]
+	if drift.Workload != "web" || len(drift.Digests["sha256:aaa"]) != 2 || drift.Digests["sha256:bbb"][0] != "web-3" {
+		t.Errorf("unexpected drift %+v", drift)
+	}
+
+	if len(inventory.Registries) != 2 || inventory.Registries[0].Registry != "docker.io" || inventory.Registries[0].Containers != 4 {
+		t.Errorf("unexpected registries %+v", inventory.Registries)
+	}
+}
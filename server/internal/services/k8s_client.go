@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/mugayoshi/k8s-visualizer/server/internal/telemetry"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -20,6 +21,9 @@ import (
 type K8sClient struct {
 	clientset kubernetes.Interface
 	config    *rest.Config
+
+	claimErrors errorLog
+	quotaErrors errorLog
 }
 
 // K8sClientInterface defines the subset of methods used by handlers and tests.
@@ -72,6 +76,10 @@ func NewK8sClient() (*K8sClient, error) {
 		}
 	}
 
+	// Observe the latency of every API call and trace it as a child span
+	config.Wrap(telemetry.InstrumentRoundTripper)
+	config.Wrap(telemetry.TraceRoundTripper)
+
 	// Create clientset
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
@@ -0,0 +1,476 @@
+// internal/services/lint.go
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/mugayoshi/k8s-visualizer/server/internal/models"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+// LintTarget is one workload or bare pod being linted
+type LintTarget struct {
+	Kind       string
+	Namespace  string
+	Name       string
+	PodSpec    *corev1.PodSpec
+	Deployment *appsv1.Deployment // Set for Deployments
+	Pod        *corev1.Pod        // Set for pods not owned by a controller
+	HasPDB     bool               // A PodDisruptionBudget selects the pod template
+}
+
+// LintViolation is one problem a rule found on a target
+type LintViolation struct {
+	Container string
+	Message   string
+}
+
+// LintRule is a best-practice check. Rules are run against every target and
+// report nothing for targets they do not apply to.
+type LintRule struct {
+	ID          string                            `json:"id"`
+	Name        string                            `json:"name"`
+	Severity    string                            `json:"severity"`
+	Description string                            `json:"description"`
+	Check       func(*LintTarget) []LintViolation `json:"-"`
+}
+
+// LintFinding is a rule violation on a specific object
+type LintFinding struct {
+	RuleID    string `json:"rule_id"`
+	Severity  string `json:"severity"`
+	Namespace string `json:"namespace"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Container string `json:"container,omitempty"`
+	Message   string `json:"message"`
+}
+
+// LintNamespaceSummary counts findings by severity in one namespace
+type LintNamespaceSummary struct {
+	Namespace string `json:"namespace"`
+	Critical  int    `json:"critical"`
+	Warning   int    `json:"warning"`
+	Info      int    `json:"info"`
+	Total     int    `json:"total"`
+}
+
+// LintReport is the result of running the lint rules
+type LintReport struct {
+	Findings   []LintFinding          `json:"findings"`
+	Count      int                    `json:"count"`
+	Namespaces []LintNamespaceSummary `json:"namespaces"`
+	Rules      []LintRule             `json:"rules"`
+}
+
+// lintRules are the registered rules, in report order
+var lintRules = []LintRule{
+	{
+		ID:          "KV001",
+		Name:        "container-missing-requests",
+		Severity:    models.SeverityWarning,
+		Description: "Containers should set CPU and memory requests so the scheduler can place them",
+		Check:       eachContainer(missingResources("requests", func(c *corev1.Container) corev1.ResourceList { return c.Resources.Requests })),
+	},
+	{
+		ID:          "KV002",
+		Name:        "container-missing-limits",
+		Severity:    models.SeverityWarning,
+		Description: "Containers should set CPU and memory limits so one workload cannot starve a node",
+		Check:       eachContainer(missingResources("limits", func(c *corev1.Container) corev1.ResourceList { return c.Resources.Limits })),
+	},
+	{
+		ID:          "KV003",
+		Name:        "image-latest-tag",
+		Severity:    models.SeverityWarning,
+		Description: "Images should be pinned to a tag or digest other than latest",
+		Check: eachContainer(func(c *corev1.Container) string {
+			ref := ParseImageRef(c.Image)
+			switch {
+			case ref.ImplicitTag:
+				return fmt.Sprintf("image %s has no tag and resolves to latest", c.Image)
+			case ref.Tag == "latest" && ref.Digest == "":
+				return fmt.Sprintf("image %s uses the latest tag", c.Image)
+			}
+			return ""
+		}),
+	},
+	{
+		ID:          "KV004",
+		Name:        "missing-liveness-probe",
+		Severity:    models.SeverityInfo,
+		Description: "Containers should define a liveness probe so hung processes are restarted",
+		Check: eachContainer(func(c *corev1.Container) string {
+			if c.LivenessProbe == nil {
+				return "no liveness probe"
+			}
+			return ""
+		}),
+	},
+	{
+		ID:          "KV005",
+		Name:        "missing-readiness-probe",
+		Severity:    models.SeverityWarning,
+		Description: "Containers should define a readiness probe so traffic waits until they can serve",
+		Check: eachContainer(func(c *corev1.Container) string {
+			if c.ReadinessProbe == nil {
+				return "no readiness probe"
+			}
+			return ""
+		}),
+	},
+	{
+		ID:          "KV006",
+		Name:        "single-replica-without-pdb",
+		Severity:    models.SeverityWarning,
+		Description: "Single-replica deployments without a PodDisruptionBudget go down on every node drain",
+		Check: func(t *LintTarget) []LintViolation {
+			if t.Deployment == nil || t.HasPDB {
+				return nil
+			}
+			if replicas := t.Deployment.Spec.Replicas; replicas == nil || *replicas == 1 {
+				return []LintViolation{{Message: "single replica and no PodDisruptionBudget"}}
+			}
+			return nil
+		},
+	},
+	{
+		ID:          "KV007",
+		Name:        "deployment-replicas-unset",
+		Severity:    models.SeverityInfo,
+		Description: "Deployments should set spec.replicas explicitly instead of relying on the default of 1",
+		Check: func(t *LintTarget) []LintViolation {
+			if t.Deployment != nil && t.Deployment.Spec.Replicas == nil {
+				return []LintViolation{{Message: "spec.replicas is not set"}}
+			}
+			return nil
+		},
+	},
+	{
+		ID:          "KV008",
+		Name:        "unowned-pod",
+		Severity:    models.SeverityWarning,
+		Description: "Pods should be managed by a controller so they are recreated when lost",
+		Check: func(t *LintTarget) []LintViolation {
+			if t.Pod != nil {
+				return []LintViolation{{Message: "pod is not owned by any controller"}}
+			}
+			return nil
+		},
+	},
+}
+
+// LintRules returns the registered lint rules
+func LintRules() []LintRule {
+	return lintRules
+}
+
+// RegisterLintRule adds a rule. It is meant to be called from init functions
+// and fails if the ID is already taken.
+func RegisterLintRule(rule LintRule) error {
+	if rule.ID == "" || rule.Check == nil {
+		return fmt.Errorf("lint rule needs an ID and a check")
+	}
+	for _, existing := range lintRules {
+		if existing.ID == rule.ID {
+			return fmt.Errorf("lint rule %s is already registered", rule.ID)
+		}
+	}
+	lintRules = append(lintRules, rule)
+	return nil
+}
+
+// eachContainer adapts a per-container check returning a message, or "" when
+// the container passes, into a rule check
+func eachContainer(check func(*corev1.Container) string) func(*LintTarget) []LintViolation {
+	return func(t *LintTarget) []LintViolation {
+		var violations []LintViolation
+		for i := range t.PodSpec.Containers {
+			container := &t.PodSpec.Containers[i]
+			if message := check(container); message != "" {
+				violations = append(violations, LintViolation{Container: container.Name, Message: message})
+			}
+		}
+		return violations
+	}
+}
+
+// missingResources reports containers without a CPU or memory entry in the
+// requests or limits returned by get
+func missingResources(what string, get func(*corev1.Container) corev1.ResourceList) func(*corev1.Container) string {
+	return func(c *corev1.Container) string {
+		var missing []string
+		for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			if _, ok := get(c)[name]; !ok {
+				missing = append(missing, string(name))
+			}
+		}
+		if len(missing) == 0 {
+			return ""
+		}
+		return fmt.Sprintf("no %s %s", strings.Join(missing, " or "), what)
+	}
+}
+
+// GetLintReport lists workloads in namespace (all when empty) and lints them
+func GetLintReport(ctx context.Context, clientset kubernetes.Interface, namespace string) (*LintReport, error) {
+	deployments, err := clientset.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	statefulSets, err := clientset.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statefulsets: %w", err)
+	}
+	daemonSets, err := clientset.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list daemonsets: %w", err)
+	}
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	pdbs, err := clientset.PolicyV1().PodDisruptionBudgets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pod disruption budgets: %w", err)
+	}
+
+	targets := LintTargets(deployments.Items, statefulSets.Items, daemonSets.Items, pods.Items, pdbs.Items)
+	return BuildLintReport(targets, LintRules()), nil
+}
+
+// LintTargets turns workloads into lint targets. Pods are only linted when no
+// controller owns them; owned pods are covered by their workload's template.
+func LintTargets(deployments []appsv1.Deployment, statefulSets []appsv1.StatefulSet, daemonSets []appsv1.DaemonSet, pods []corev1.Pod, pdbs []policyv1.PodDisruptionBudget) []LintTarget {
+	targets := make([]LintTarget, 0, len(deployments)+len(statefulSets)+len(daemonSets))
+
+	for i := range deployments {
+		d := &deployments[i]
+		targets = append(targets, LintTarget{
+			Kind:       "Deployment",
+			Namespace:  d.Namespace,
+			Name:       d.Name,
+			PodSpec:    &d.Spec.Template.Spec,
+			Deployment: d,
+			HasPDB:     pdbSelects(pdbs, d.Namespace, d.Spec.Template.Labels),
+		})
+	}
+	for i := range statefulSets {
+		s := &statefulSets[i]
+		targets = append(targets, LintTarget{
+			Kind:      "StatefulSet",
+			Namespace: s.Namespace,
+			Name:      s.Name,
+			PodSpec:   &s.Spec.Template.Spec,
+			HasPDB:    pdbSelects(pdbs, s.Namespace, s.Spec.Template.Labels),
+		})
+	}
+	for i := range daemonSets {
+		ds := &daemonSets[i]
+		targets = append(targets, LintTarget{
+			Kind:      "DaemonSet",
+			Namespace: ds.Namespace,
+			Name:      ds.Name,
+			PodSpec:   &ds.Spec.Template.Spec,
+		})
+	}
+	for i := range pods {
+		pod := &pods[i]
+		if metav1.GetControllerOf(pod) != nil || podIsTerminal(pod) {
+			continue
+		}
+		targets = append(targets, LintTarget{
+			Kind:      "Pod",
+			Namespace: pod.Namespace,
+			Name:      pod.Name,
+			PodSpec:   &pod.Spec,
+			Pod:       pod,
+			HasPDB:    pdbSelects(pdbs, pod.Namespace, pod.Labels),
+		})
+	}
+	return targets
+}
+
+// pdbSelects reports whether any PodDisruptionBudget in namespace selects
+// pods with the given labels
+func pdbSelects(pdbs []policyv1.PodDisruptionBudget, namespace string, podLabels map[string]string) bool {
+	for i := range pdbs {
+		pdb := &pdbs[i]
+		if pdb.Namespace != namespace || pdb.Spec.Selector == nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil || selector.Empty() {
+			continue
+		}
+		if selector.Matches(labels.Set(podLabels)) {
+			return true
+		}
+	}
+	return false
+}
+
+// BuildLintReport runs rules against targets and summarizes the findings
+// per namespace
+func BuildLintReport(targets []LintTarget, rules []LintRule) *LintReport {
+	report := &LintReport{
+		Findings:   make([]LintFinding, 0),
+		Namespaces: make([]LintNamespaceSummary, 0),
+		Rules:      rules,
+	}
+
+	summaries := make(map[string]*LintNamespaceSummary)
+	for i := range targets {
+		target := &targets[i]
+		for _, rule := range rules {
+			for _, violation := range rule.Check(target) {
+				report.Findings = append(report.Findings, LintFinding{
+					RuleID:    rule.ID,
+					Severity:  rule.Severity,
+					Namespace: target.Namespace,
+					Kind:      target.Kind,
+					Name:      target.Name,
+					Container: violation.Container,
+					Message:   violation.Message,
+				})
+
+				summary, ok := summaries[target.Namespace]
+				if !ok {
+					summary = &LintNamespaceSummary{Namespace: target.Namespace}
+					summaries[target.Namespace] = summary
+				}
+				switch rule.Severity {
+				case models.SeverityCritical:
+					summary.Critical++
+				case models.SeverityWarning:
+					summary.Warning++
+				default:
+					summary.Info++
+				}
+				summary.Total++
+			}
+		}
+	}
+
+	sort.Slice(report.Findings, func(i, j int) bool {
+		a, b := report.Findings[i], report.Findings[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.RuleID != b.RuleID {
+			return a.RuleID < b.RuleID
+		}
+		return a.Container < b.Container
+	})
+	for _, summary := range summaries {
+		report.Namespaces = append(report.Namespaces, *summary)
+	}
+	sort.Slice(report.Namespaces, func(i, j int) bool { return report.Namespaces[i].Namespace < report.Namespaces[j].Namespace })
+	report.Count = len(report.Findings)
+	return report
+}
+
+// sarifLevel maps a severity to a SARIF result level
+func sarifLevel(severity string) string {
+	switch severity {
+	case models.SeverityCritical:
+		return "error"
+	case models.SeverityWarning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// WriteLintSARIF writes the report as a SARIF 2.1.0 log. Findings refer to
+// Kubernetes objects, so they use logical rather than file locations.
+func WriteLintSARIF(w io.Writer, report *LintReport) error {
+	type message struct {
+		Text string `json:"text"`
+	}
+	type logicalLocation struct {
+		Name               string `json:"name"`
+		Kind               string `json:"kind"`
+		FullyQualifiedName string `json:"fullyQualifiedName"`
+	}
+	type location struct {
+		LogicalLocations []logicalLocation `json:"logicalLocations"`
+	}
+	type result struct {
+		RuleID    string     `json:"ruleId"`
+		Level     string     `json:"level"`
+		Message   message    `json:"message"`
+		Locations []location `json:"locations"`
+	}
+	type rule struct {
+		ID                   string            `json:"id"`
+		Name                 string            `json:"name"`
+		ShortDescription     message           `json:"shortDescription"`
+		DefaultConfiguration map[string]string `json:"defaultConfiguration"`
+	}
+
+	rules := make([]rule, 0, len(report.Rules))
+	for _, r := range report.Rules {
+		rules = append(rules, rule{
+			ID:                   r.ID,
+			Name:                 r.Name,
+			ShortDescription:     message{Text: r.Description},
+			DefaultConfiguration: map[string]string{"level": sarifLevel(r.Severity)},
+		})
+	}
+
+	results := make([]result, 0, len(report.Findings))
+	for _, f := range report.Findings {
+		name := f.Namespace + "/" + f.Kind + "/" + f.Name
+		text := fmt.Sprintf("%s %s: %s", f.Kind, name, f.Message)
+		if f.Container != "" {
+			name += "/" + f.Container
+			text = fmt.Sprintf("%s %s/%s container %s: %s", f.Kind, f.Namespace, f.Name, f.Container, f.Message)
+		}
+		results = append(results, result{
+			RuleID:  f.RuleID,
+			Level:   sarifLevel(f.Severity),
+			Message: message{Text: text},
+			Locations: []location{{LogicalLocations: []logicalLocation{{
+				Name:               f.Name,
+				Kind:               strings.ToLower(f.Kind),
+				FullyQualifiedName: name,
+			}}}},
+		})
+	}
+
+	sarif := map[string]interface{}{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []interface{}{map[string]interface{}{
+			"tool": map[string]interface{}{
+				"driver": map[string]interface{}{
+					"name":           "k8s-visualizer",
+					"informationUri": "https://github.com/mugayoshi/k8s-visualizer",
+					"rules":          rules,
+				},
+			},
+			"results": results,
+		}},
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarif)
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func lintRuleIDs(report *LintReport, name string) map[string]int {
+	ids := make(map[string]int)
+	for _, f := range report.Findings {
+		if f.Name == name {
+			ids[f.RuleID]++
+		}
+	}
+	return ids
+}
+
+func TestGetLintReport(t *testing.T) {
+	labels := map[string]string{"app": "api"}
+	resources := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("100m"),
+		corev1.ResourceMemory: resource.MustParse("128Mi"),
+	}
+	probe := &corev1.Probe{}
+	good := corev1.Container{
+		Name:           "api",
+		Image:          "registry.local:5000/api:1.2.3",
+		Resources:      corev1.ResourceRequirements{Requests: resources, Limits: resources},
+		LivenessProbe:  probe,
+		ReadinessProbe: probe,
+	}
+
+	one := int32(1)
+	protected := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "shop"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &one,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{good}},
+			},
+		},
+	}
+	unset := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "web", Image: "nginx"}}},
+			},
+		},
+	}
+	pdb := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "shop"},
+		Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: labels}},
+	}
+	bare := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "debug", Namespace: "tools"},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "sh", Image: "busybox:latest"}}},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	controller := true
+	owned := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "web-abc",
+			Namespace:       "shop",
+			OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web-1", Controller: &controller}},
+		},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "web", Image: "nginx"}}},
+	}
+
+	clientset := fake.NewSimpleClientset(protected, unset, pdb, bare, owned)
+	report, err := GetLintReport(context.Background(), clientset, "")
+	if err != nil {
+		t.Fatalf("GetLintReport failed: %v", err)
+	}
+
+	if ids := lintRuleIDs(report, "api"); len(ids) != 0 {
+		t.Errorf("expected no findings for api, got %v", ids)
+	}
+	web := lintRuleIDs(report, "web")
+	for _, id := range []string{"KV001", "KV002", "KV003", "KV004", "KV005", "KV006", "KV007"} {
+		if web[id] != 1 {
+			t.Errorf("expected %s for web, got %v", id, web)
+		}
+	}
+	debug := lintRuleIDs(report, "debug")
+	if debug["KV003"] != 1 || debug["KV008"] != 1 {
+		t.Errorf("expected latest tag and unowned findings for debug, got %v", debug)
+	}
+	if ids := lintRuleIDs(report, "web-abc"); len(ids) != 0 {
+		t.Errorf("owned pods should be linted through their workload, got %v", ids)
+	}
+
+	if len(report.Namespaces) != 2 || report.Namespaces[0].Namespace != "shop" || report.Namespaces[0].Total != 7 {
+		t.Errorf("unexpected namespace summaries: %+v", report.Namespaces)
+	}
+	if report.Count != len(report.Findings) {
+		t.Errorf("count %d does not match %d findings", report.Count, len(report.Findings))
+	}
+
+	scoped, err := GetLintReport(context.Background(), clientset, "tools")
+	if err != nil {
+		t.Fatalf("GetLintReport failed: %v", err)
+	}
+	if scoped.Count != 6 || len(scoped.Namespaces) != 1 {
+		t.Errorf("expected 6 findings in tools, got %+v", scoped.Findings)
+	}
+}
+
+func TestWriteLintSARIF(t *testing.T) {
+	targets := []LintTarget{{
+		Kind:      "Pod",
+		Namespace: "tools",
+		Name:      "debug",
+		PodSpec:   &corev1.PodSpec{Containers: []corev1.Container{{Name: "sh", Image: "busybox"}}},
+		Pod:       &corev1.Pod{},
+	}}
+	var rules []LintRule
+	for _, rule := range LintRules() {
+		if rule.ID == "KV003" || rule.ID == "KV008" {
+			rules = append(rules, rule)
+		}
+	}
+	report := BuildLintReport(targets, rules)
+
+	var buf bytes.Buffer
+	if err := WriteLintSARIF(&buf, report); err != nil {
+		t.Fatalf("WriteLintSARIF failed: %v", err)
+	}
+
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Rules []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				Level     string `json:"level"`
+				Locations []struct {
+					LogicalLocations []struct {
+						FullyQualifiedName string `json:"fullyQualifiedName"`
+					} `json:"logicalLocations"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("invalid SARIF JSON: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 || len(log.Runs[0].Tool.Driver.Rules) != 2 {
+		t.Fatalf("unexpected SARIF log: %s", buf.String())
+	}
+	results := log.Runs[0].Results
+	if len(results) != 2 || results[0].RuleID != "KV003" || results[0].Level != "warning" {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	if name := results[0].Locations[0].LogicalLocations[0].FullyQualifiedName; name != "tools/Pod/debug/sh" {
+		t.Errorf("unexpected location %q", name)
+	}
+}
+
+func TestRegisterLintRuleRejectsDuplicates(t *testing.T) {
+	if err := RegisterLintRule(LintRule{ID: "KV001", Check: func(*LintTarget) []LintViolation { return nil }}); err == nil {
+		t.Error("expected duplicate ID to be rejected")
+	}
+	if err := RegisterLintRule(LintRule{ID: "X"}); err == nil {
+		t.Error("expected rule without check to be rejected")
+	}
+}
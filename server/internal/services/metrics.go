@@ -4,8 +4,14 @@ package services
 import (
 	"context"
 	"fmt"
+	"log"
+	"sync"
 	"time"
 
+	"github.com/mugayoshi/k8s-visualizer/server/internal/models"
+	"github.com/mugayoshi/k8s-visualizer/server/internal/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -13,15 +19,16 @@ import (
 
 // ClusterMetrics represents overall cluster metrics
 type ClusterMetrics struct {
-	TotalNodes       int                `json:"total_nodes"`
-	TotalPods        int                `json:"total_pods"`
-	TotalNamespaces  int                `json:"total_namespaces"`
-	CPUCapacity      string             `json:"cpu_capacity"`
-	MemoryCapacity   string             `json:"memory_capacity"`
-	CPUUsage         string             `json:"cpu_usage,omitempty"`
-	MemoryUsage      string             `json:"memory_usage,omitempty"`
-	NodeMetrics      []NodeMetrics      `json:"node_metrics"`
-	NamespaceMetrics []NamespaceMetrics `json:"namespace_metrics,omitempty"`
+	TotalNodes          int                `json:"total_nodes"`
+	TotalPods           int                `json:"total_pods"`
+	TotalNamespaces     int                `json:"total_namespaces"`
+	CPUCapacity         string             `json:"cpu_capacity"`
+	MemoryCapacity      string             `json:"memory_capacity"`
+	CPUUsage            string             `json:"cpu_usage,omitempty"`
+	MemoryUsage         string             `json:"memory_usage,omitempty"`
+	NodeMetrics         []NodeMetrics      `json:"node_metrics"`
+	NamespaceMetrics    []NamespaceMetrics `json:"namespace_metrics,omitempty"`
+	NamespacesNearQuota []string           `json:"namespaces_near_quota,omitempty"`
 }
 
 // NodeMetrics represents metrics for a single node
@@ -35,6 +42,10 @@ type NodeMetrics struct {
 	PodCount          int               `json:"pod_count"`
 	Status            string            `json:"status"`
 	Labels            map[string]string `json:"labels"`
+	CPURequests       string            `json:"cpu_requests,omitempty"`
+	CPULimits         string            `json:"cpu_limits,omitempty"`
+	MemoryRequests    string            `json:"memory_requests,omitempty"`
+	MemoryLimits      string            `json:"memory_limits,omitempty"`
 }
 
 // NamespaceMetrics represents metrics for a namespace
@@ -43,26 +54,38 @@ type NamespaceMetrics struct {
 	PodCount    int    `json:"pod_count"`
 	CPUUsage    string `json:"cpu_usage,omitempty"`
 	MemoryUsage string `json:"memory_usage,omitempty"`
+	// StorageRequests sums the storage requested by the namespace's claims
+	StorageRequests string `json:"storage_requests,omitempty"`
+	ClaimCount      int    `json:"claim_count,omitempty"`
+	// Quota fields describe the most used resource across the namespace's
+	// ResourceQuotas
+	QuotaResource    string  `json:"quota_resource,omitempty"`
+	QuotaUsedPercent float64 `json:"quota_used_percent,omitempty"`
+	NearQuota        bool    `json:"near_quota,omitempty"`
 }
 
 // PodMetrics represents metrics for a single pod
 type PodMetrics struct {
-	Name            string `json:"name"`
-	Namespace       string `json:"namespace"`
-	Status          string `json:"status"`
-	StatusDetail    string `json:"status_detail,omitempty"`
-	ReadyContainers string `json:"ready_containers"`
-	CPURequest      string `json:"cpu_request,omitempty"`
-	CPULimit        string `json:"cpu_limit,omitempty"`
-	MemoryRequest   string `json:"memory_request,omitempty"`
-	MemoryLimit     string `json:"memory_limit,omitempty"`
-	ContainerCount  int    `json:"container_count"`
-	Age             string `json:"age,omitempty"`
-	RestartCount    int32  `json:"restart_count"`
+	Name            string             `json:"name"`
+	Namespace       string             `json:"namespace"`
+	Status          string             `json:"status"`
+	StatusDetail    string             `json:"status_detail,omitempty"`
+	ReadyContainers string             `json:"ready_containers"`
+	Diagnoses       []models.Diagnosis `json:"diagnoses,omitempty"`
+	CPURequest      string             `json:"cpu_request,omitempty"`
+	CPULimit        string             `json:"cpu_limit,omitempty"`
+	MemoryRequest   string             `json:"memory_request,omitempty"`
+	MemoryLimit     string             `json:"memory_limit,omitempty"`
+	ContainerCount  int                `json:"container_count"`
+	Age             string             `json:"age,omitempty"`
+	RestartCount    int32              `json:"restart_count"`
 }
 
 // GetClusterMetrics retrieves overall cluster metrics
-func (k *K8sClient) GetClusterMetrics(ctx context.Context) (*ClusterMetrics, error) {
+func (k *K8sClient) GetClusterMetrics(ctx context.Context) (_ *ClusterMetrics, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "GetClusterMetrics")
+	defer func() { telemetry.EndSpan(span, err) }()
+
 	clientset := k.GetClientset()
 
 	// Get nodes
@@ -83,10 +106,18 @@ func (k *K8sClient) GetClusterMetrics(ctx context.Context) (*ClusterMetrics, err
 		return nil, fmt.Errorf("failed to list namespaces: %w", err)
 	}
 
+	// Get claims for namespace storage requests. They are optional, the
+	// storage fields stay empty when claims cannot be listed.
+	claims := k.listClaimsOptional(ctx, "")
+
+	// Get quotas for namespace quota usage, optional like the claims
+	quotas := k.listQuotasOptional(ctx, "")
+
 	// Calculate total capacity
 	totalCPU := resource.NewQuantity(0, resource.DecimalSI)
 	totalMemory := resource.NewQuantity(0, resource.BinarySI)
 	nodeMetrics := make([]NodeMetrics, 0, len(nodes.Items))
+	byNode := podsByNode(pods.Items)
 
 	for _, node := range nodes.Items {
 		// Add to totals
@@ -96,27 +127,12 @@ func (k *K8sClient) GetClusterMetrics(ctx context.Context) (*ClusterMetrics, err
 		totalMemory.Add(*memory)
 
 		// Count pods on this node
-		podCount := 0
-		for _, pod := range pods.Items {
-			if pod.Spec.NodeName == node.Name {
-				podCount++
-			}
-		}
+		podCount := len(byNode[node.Name])
 
 		// Get node status
-		status := "Unknown"
-		for _, condition := range node.Status.Conditions {
-			if condition.Type == corev1.NodeReady {
-				if condition.Status == corev1.ConditionTrue {
-					status = "Ready"
-				} else {
-					status = "NotReady"
-				}
-				break
-			}
-		}
+		status := nodeReadyStatus(&node)
 
-		nodeMetrics = append(nodeMetrics, NodeMetrics{
+		metrics := NodeMetrics{
 			Name:              node.Name,
 			CPUCapacity:       node.Status.Capacity.Cpu().String(),
 			MemoryCapacity:    node.Status.Capacity.Memory().String(),
@@ -126,32 +142,54 @@ func (k *K8sClient) GetClusterMetrics(ctx context.Context) (*ClusterMetrics, err
 			PodCount:          podCount,
 			Status:            status,
 			Labels:            node.Labels,
-		})
+		}
+		nodeResources(byNode[node.Name]).apply(&metrics.CPURequests, &metrics.CPULimits, &metrics.MemoryRequests, &metrics.MemoryLimits)
+		nodeMetrics = append(nodeMetrics, metrics)
 	}
 
 	// Calculate namespace metrics
 	namespaceMetrics := make([]NamespaceMetrics, 0, len(namespaces.Items))
 	namespacePodCount := make(map[string]int)
 
+	namespaceClaimCount := make(map[string]int)
+	storageRequests := namespaceStorageRequests(claims)
+	quotaUsage := namespaceQuotaUsage(quotas)
+	var nearQuota []string
+
 	for _, pod := range pods.Items {
 		namespacePodCount[pod.Namespace]++
 	}
+	for _, claim := range claims {
+		namespaceClaimCount[claim.Namespace]++
+	}
 
 	for _, ns := range namespaces.Items {
-		namespaceMetrics = append(namespaceMetrics, NamespaceMetrics{
-			Name:     ns.Name,
-			PodCount: namespacePodCount[ns.Name],
-		})
+		metrics := NamespaceMetrics{
+			Name:       ns.Name,
+			PodCount:   namespacePodCount[ns.Name],
+			ClaimCount: namespaceClaimCount[ns.Name],
+		}
+		if requested, ok := storageRequests[ns.Name]; ok {
+			metrics.StorageRequests = requested.String()
+		}
+		if usage, ok := quotaUsage[ns.Name]; ok {
+			metrics.applyQuota(usage)
+			if metrics.NearQuota {
+				nearQuota = append(nearQuota, ns.Name)
+			}
+		}
+		namespaceMetrics = append(namespaceMetrics, metrics)
 	}
 
 	return &ClusterMetrics{
-		TotalNodes:       len(nodes.Items),
-		TotalPods:        len(pods.Items),
-		TotalNamespaces:  len(namespaces.Items),
-		CPUCapacity:      totalCPU.String(),
-		MemoryCapacity:   totalMemory.String(),
-		NodeMetrics:      nodeMetrics,
-		NamespaceMetrics: namespaceMetrics,
+		TotalNodes:          len(nodes.Items),
+		TotalPods:           len(pods.Items),
+		TotalNamespaces:     len(namespaces.Items),
+		CPUCapacity:         totalCPU.String(),
+		MemoryCapacity:      totalMemory.String(),
+		NodeMetrics:         nodeMetrics,
+		NamespaceMetrics:    namespaceMetrics,
+		NamespacesNearQuota: nearQuota,
 	}, nil
 }
 
@@ -172,19 +210,9 @@ func (k *K8sClient) GetNodeMetrics(ctx context.Context, nodeName string) (*NodeM
 		return nil, fmt.Errorf("failed to list pods: %w", err)
 	}
 
-	status := "Unknown"
-	for _, condition := range node.Status.Conditions {
-		if condition.Type == corev1.NodeReady {
-			if condition.Status == corev1.ConditionTrue {
-				status = "Ready"
-			} else {
-				status = "NotReady"
-			}
-			break
-		}
-	}
+	status := nodeReadyStatus(node)
 
-	return &NodeMetrics{
+	metrics := &NodeMetrics{
 		Name:              node.Name,
 		CPUCapacity:       node.Status.Capacity.Cpu().String(),
 		MemoryCapacity:    node.Status.Capacity.Memory().String(),
@@ -194,7 +222,9 @@ func (k *K8sClient) GetNodeMetrics(ctx context.Context, nodeName string) (*NodeM
 		PodCount:          len(pods.Items),
 		Status:            status,
 		Labels:            node.Labels,
-	}, nil
+	}
+	nodeResources(podsByNode(pods.Items)[node.Name]).apply(&metrics.CPURequests, &metrics.CPULimits, &metrics.MemoryRequests, &metrics.MemoryLimits)
+	return metrics, nil
 }
 
 // GetNamespaceMetrics retrieves metrics for a specific namespace
@@ -206,15 +236,82 @@ func (k *K8sClient) GetNamespaceMetrics(ctx context.Context, namespace string) (
 		return nil, fmt.Errorf("failed to list pods: %w", err)
 	}
 
-	return &NamespaceMetrics{
-		Name:     namespace,
-		PodCount: len(pods.Items),
-	}, nil
+	claims := k.listClaimsOptional(ctx, namespace)
+	metrics := &NamespaceMetrics{
+		Name:       namespace,
+		PodCount:   len(pods.Items),
+		ClaimCount: len(claims),
+	}
+	if requested, ok := namespaceStorageRequests(claims)[namespace]; ok {
+		metrics.StorageRequests = requested.String()
+	}
+
+	if usage, ok := namespaceQuotaUsage(k.listQuotasOptional(ctx, namespace))[namespace]; ok {
+		metrics.applyQuota(usage)
+	}
+	return metrics, nil
+}
+
+// listClaimsOptional lists claims for the storage fields of namespace
+// metrics. Failures, such as RBAC without PVC access, are logged and yield
+// no claims rather than failing the metrics.
+func (k *K8sClient) listClaimsOptional(ctx context.Context, namespace string) []corev1.PersistentVolumeClaim {
+	claims, err := k.clientset.CoreV1().PersistentVolumeClaims(namespace).List(ctx, metav1.ListOptions{})
+	k.claimErrors.report("Failed to list persistent volume claims", err)
+	if err != nil {
+		return nil
+	}
+	return claims.Items
+}
+
+// errorLog logs an error only when it differs from the previous one.
+// Metrics are read on every WebSocket tick and by the background samplers,
+// so a lasting failure would otherwise flood the log.
+type errorLog struct {
+	mu   sync.Mutex
+	last string
+}
+
+// report logs err with msg if it changed; a nil err resets the state
+func (l *errorLog) report(msg string, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err != nil && err.Error() != l.last {
+		log.Printf("%s: %v", msg, err)
+	}
+	l.last = ""
+	if err != nil {
+		l.last = err.Error()
+	}
+}
+
+// listQuotasOptional lists quotas for the quota fields of namespace
+// metrics, logging failures and yielding no quotas like listClaimsOptional
+func (k *K8sClient) listQuotasOptional(ctx context.Context, namespace string) []corev1.ResourceQuota {
+	quotas, err := k.clientset.CoreV1().ResourceQuotas(namespace).List(ctx, metav1.ListOptions{})
+	k.quotaErrors.report("Failed to list resource quotas", err)
+	if err != nil {
+		return nil
+	}
+	return quotas.Items
+}
+
+// applyQuota records the namespace's most used quota resource
+func (m *NamespaceMetrics) applyQuota(usage QuotaResource) {
+	m.QuotaResource = usage.Resource
+	m.QuotaUsedPercent = usage.PercentUsed
+	m.NearQuota = usage.PercentUsed >= QuotaWarningPercent
 }
 
 // GetPodMetrics retrieves metrics for a specific pod
 // Note: This requires metrics-server to be installed in the cluster
-func (k *K8sClient) GetPodMetrics(ctx context.Context, namespace, podName string) (*PodMetrics, error) {
+func (k *K8sClient) GetPodMetrics(ctx context.Context, namespace, podName string) (_ *PodMetrics, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "GetPodMetrics", trace.WithAttributes(
+		attribute.String("k8s.namespace.name", namespace),
+		attribute.String("k8s.pod.name", podName),
+	))
+	defer func() { telemetry.EndSpan(span, err) }()
+
 	clientset := k.GetClientset()
 
 	// Try to get metrics from metrics API
@@ -227,53 +324,102 @@ func (k *K8sClient) GetPodMetrics(ctx context.Context, namespace, podName string
 	metrics := &PodMetrics{
 		Name:           pod.Name,
 		Namespace:      pod.Namespace,
+		Status:         string(pod.Status.Phase),
 		ContainerCount: len(pod.Spec.Containers),
 	}
 
-	// Calculate total restarts
+	// Calculate total restarts and ready containers
+	ready := 0
 	for _, containerStatus := range pod.Status.ContainerStatuses {
 		metrics.RestartCount += containerStatus.RestartCount
+		if containerStatus.Ready {
+			ready++
+		}
 	}
+	metrics.ReadyContainers = fmt.Sprintf("%d/%d", ready, len(pod.Spec.Containers))
+
+	// Explain anything that is wrong with the pod from its status alone;
+	// this runs for every watch event, so events are not listed here
+	diagnoses := AnalyzePod(pod, nil)
+	metrics.Diagnoses = diagnoses
+	metrics.StatusDetail = StatusDetail(diagnoses)
 
 	// Calculate total CPU and memory requests/limits
-	var totalCPURequest, totalCPULimit, totalMemoryRequest, totalMemoryLimit resource.Quantity
+	totals := podResources(pod)
+	totals.apply(&metrics.CPURequest, &metrics.CPULimit, &metrics.MemoryRequest, &metrics.MemoryLimit)
 
-	for _, container := range pod.Spec.Containers {
-		if cpuReq := container.Resources.Requests.Cpu(); cpuReq != nil {
-			totalCPURequest.Add(*cpuReq)
-		}
-		if cpuLim := container.Resources.Limits.Cpu(); cpuLim != nil {
-			totalCPULimit.Add(*cpuLim)
-		}
-		if memReq := container.Resources.Requests.Memory(); memReq != nil {
-			totalMemoryRequest.Add(*memReq)
-		}
-		if memLim := container.Resources.Limits.Memory(); memLim != nil {
-			totalMemoryLimit.Add(*memLim)
-		}
+	// Calculate pod age
+	if !pod.CreationTimestamp.IsZero() {
+		age := metav1.Now().Sub(pod.CreationTimestamp.Time)
+		metrics.Age = formatAge(age)
 	}
 
-	// Set metrics if values exist
-	if !totalCPURequest.IsZero() {
-		metrics.CPURequest = totalCPURequest.String()
+	return metrics, nil
+}
+
+// resourceTotals holds summed container requests and limits
+type resourceTotals struct {
+	CPURequest, CPULimit, MemoryRequest, MemoryLimit resource.Quantity
+}
+
+// add sums other into t
+func (t *resourceTotals) add(other resourceTotals) {
+	t.CPURequest.Add(other.CPURequest)
+	t.CPULimit.Add(other.CPULimit)
+	t.MemoryRequest.Add(other.MemoryRequest)
+	t.MemoryLimit.Add(other.MemoryLimit)
+}
+
+// apply sets the string fields for the totals that are non-zero
+func (t resourceTotals) apply(cpuRequest, cpuLimit, memoryRequest, memoryLimit *string) {
+	if !t.CPURequest.IsZero() {
+		*cpuRequest = t.CPURequest.String()
 	}
-	if !totalCPULimit.IsZero() {
-		metrics.CPULimit = totalCPULimit.String()
+	if !t.CPULimit.IsZero() {
+		*cpuLimit = t.CPULimit.String()
 	}
-	if !totalMemoryRequest.IsZero() {
-		metrics.MemoryRequest = totalMemoryRequest.String()
+	if !t.MemoryRequest.IsZero() {
+		*memoryRequest = t.MemoryRequest.String()
 	}
-	if !totalMemoryLimit.IsZero() {
-		metrics.MemoryLimit = totalMemoryLimit.String()
+	if !t.MemoryLimit.IsZero() {
+		*memoryLimit = t.MemoryLimit.String()
 	}
+}
 
-	// Calculate pod age
-	if !pod.CreationTimestamp.IsZero() {
-		age := metav1.Now().Sub(pod.CreationTimestamp.Time)
-		metrics.Age = formatAge(age)
+// podResources returns the pod's effective CPU and memory requests and
+// limits, counting init containers and overhead as the scheduler does
+func podResources(pod *corev1.Pod) resourceTotals {
+	requests, limits := PodRequests(pod), PodLimits(pod)
+	return resourceTotals{
+		CPURequest:    *requests.Cpu(),
+		CPULimit:      *limits.Cpu(),
+		MemoryRequest: *requests.Memory(),
+		MemoryLimit:   *limits.Memory(),
 	}
+}
 
-	return metrics, nil
+// nodeResources sums podResources over the non-terminal pods on a node
+func nodeResources(pods []*corev1.Pod) resourceTotals {
+	var totals resourceTotals
+	for _, pod := range pods {
+		if !podIsTerminal(pod) {
+			totals.add(podResources(pod))
+		}
+	}
+	return totals
+}
+
+// nodeReadyStatus returns "Ready", "NotReady" or "Unknown" from the node's Ready condition
+func nodeReadyStatus(node *corev1.Node) string {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			if condition.Status == corev1.ConditionTrue {
+				return "Ready"
+			}
+			return "NotReady"
+		}
+	}
+	return "Unknown"
 }
 
 // formatAge formats a duration into a human-readable age string
@@ -0,0 +1,708 @@
+// internal/services/netpol.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Namespace graph edge statuses
+const (
+	TrafficAllowed = "allowed"
+	TrafficPartial = "partial"
+	TrafficDenied  = "denied"
+)
+
+// NetworkPolicyInfo is a NetworkPolicy with the pods it selects
+type NetworkPolicyInfo struct {
+	Name         string   `json:"name"`
+	Namespace    string   `json:"namespace"`
+	PodSelector  string   `json:"pod_selector"`
+	PolicyTypes  []string `json:"policy_types"`
+	IngressRules int      `json:"ingress_rules"`
+	EgressRules  int      `json:"egress_rules"`
+	SelectedPods []string `json:"selected_pods"`
+}
+
+// PodNetworkPolicies lists the policies selecting a pod per direction. A pod
+// is isolated in a direction once any policy of that type selects it.
+type PodNetworkPolicies struct {
+	Pod             string   `json:"pod"`
+	Namespace       string   `json:"namespace"`
+	IngressIsolated bool     `json:"ingress_isolated"`
+	EgressIsolated  bool     `json:"egress_isolated"`
+	IngressPolicies []string `json:"ingress_policies"`
+	EgressPolicies  []string `json:"egress_policies"`
+}
+
+// DirectionVerdict is the outcome of one direction of a reachability check.
+// Allowing lists policies with a matching rule, Blocking the policies that
+// isolate the pod without one.
+type DirectionVerdict struct {
+	Isolated bool     `json:"isolated"`
+	Allowed  bool     `json:"allowed"`
+	Allowing []string `json:"allowing"`
+	Blocking []string `json:"blocking"`
+}
+
+// ReachabilityResult answers whether From can open a connection to To.
+// Traffic must be allowed by the source's egress and the destination's
+// ingress.
+type ReachabilityResult struct {
+	From     string           `json:"from"`
+	To       string           `json:"to"`
+	Port     string           `json:"port,omitempty"`
+	Protocol string           `json:"protocol"`
+	Allowed  bool             `json:"allowed"`
+	Egress   DirectionVerdict `json:"egress"`
+	Ingress  DirectionVerdict `json:"ingress"`
+}
+
+// NamespaceTrafficEdge summarizes reachability between pods of two namespaces
+type NamespaceTrafficEdge struct {
+	From         string `json:"from"`
+	To           string `json:"to"`
+	AllowedPairs int    `json:"allowed_pairs"`
+	TotalPairs   int    `json:"total_pairs"`
+	Status       string `json:"status"`
+}
+
+// NetworkPolicyGraph is the namespace-level allowed-traffic graph
+type NetworkPolicyGraph struct {
+	Namespaces []string               `json:"namespaces"`
+	Edges      []NamespaceTrafficEdge `json:"edges"`
+}
+
+// NetworkPolicySet evaluates NetworkPolicies against a snapshot of pods and
+// namespaces
+type NetworkPolicySet struct {
+	policies        []networkingv1.NetworkPolicy
+	compiled        []compiledPolicy
+	pods            []corev1.Pod
+	namespaceLabels map[string]labels.Set
+}
+
+// compiledPolicy is a policy with its selectors parsed once, indexed like
+// NetworkPolicySet.policies
+type compiledPolicy struct {
+	name         string
+	namespace    string
+	ingress      bool
+	egress       bool
+	podSelector  labels.Selector
+	ingressRules []compiledRule
+	egressRules  []compiledRule
+}
+
+// compiledRule is an ingress or egress rule. An empty peer list matches
+// everything.
+type compiledRule struct {
+	peers []compiledPeer
+	ports []networkingv1.NetworkPolicyPort
+}
+
+// compiledPeer is a rule peer. A nil namespaceSelector limits the peer to
+// the policy's namespace, a nil podSelector admits every pod.
+type compiledPeer struct {
+	ipBlock           *networkingv1.IPBlock
+	namespaceSelector labels.Selector
+	podSelector       labels.Selector
+}
+
+// portRange is an inclusive range of port numbers
+type portRange struct {
+	start, end int
+}
+
+// portSet is the ports a direction admits to a destination
+type portSet struct {
+	all    bool
+	ranges []portRange
+}
+
+// GetNetworkPolicySet lists the policies, pods and namespaces the evaluator
+// needs. Policies and namespace selectors span namespaces, so everything is
+// listed cluster-wide.
+func GetNetworkPolicySet(ctx context.Context, clientset kubernetes.Interface) (*NetworkPolicySet, error) {
+	policies, err := clientset.NetworkingV1().NetworkPolicies("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list network policies: %w", err)
+	}
+	pods, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	namespaces, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	return NewNetworkPolicySet(policies.Items, pods.Items, namespaces.Items), nil
+}
+
+// NewNetworkPolicySet builds an evaluator. Terminal and hostNetwork pods are
+// dropped since policies do not apply to them.
+func NewNetworkPolicySet(policies []networkingv1.NetworkPolicy, pods []corev1.Pod, namespaces []corev1.Namespace) *NetworkPolicySet {
+	set := &NetworkPolicySet{policies: policies, namespaceLabels: make(map[string]labels.Set, len(namespaces))}
+	for i := range policies {
+		set.compiled = append(set.compiled, compilePolicy(&policies[i]))
+	}
+	for i := range pods {
+		if !podIsTerminal(&pods[i]) && !pods[i].Spec.HostNetwork {
+			set.pods = append(set.pods, pods[i])
+		}
+	}
+	for _, namespace := range namespaces {
+		set.namespaceLabels[namespace.Name] = namespaceLabelSet(namespace.Name, namespace.Labels)
+	}
+	return set
+}
+
+// namespaceLabelSet adds the immutable kubernetes.io/metadata.name label,
+// which older clusters and fixtures may lack
+func namespaceLabelSet(name string, namespaceLabels map[string]string) labels.Set {
+	set := labels.Set{corev1.LabelMetadataName: name}
+	for key, value := range namespaceLabels {
+		set[key] = value
+	}
+	return set
+}
+
+// Pod finds a pod by namespace and name
+func (s *NetworkPolicySet) Pod(namespace, name string) (*corev1.Pod, bool) {
+	for i := range s.pods {
+		if s.pods[i].Namespace == namespace && s.pods[i].Name == name {
+			return &s.pods[i], true
+		}
+	}
+	return nil, false
+}
+
+// Policies returns every policy with its selected pods, filtered to
+// namespace when set
+func (s *NetworkPolicySet) Policies(namespace string) []NetworkPolicyInfo {
+	infos := make([]NetworkPolicyInfo, 0, len(s.policies))
+	for i := range s.policies {
+		policy := &s.policies[i]
+		if namespace != "" && policy.Namespace != namespace {
+			continue
+		}
+		info := NetworkPolicyInfo{
+			Name:         policy.Name,
+			Namespace:    policy.Namespace,
+			PodSelector:  metav1.FormatLabelSelector(&policy.Spec.PodSelector),
+			IngressRules: len(policy.Spec.Ingress),
+			EgressRules:  len(policy.Spec.Egress),
+			SelectedPods: make([]string, 0),
+		}
+		if s.compiled[i].ingress {
+			info.PolicyTypes = append(info.PolicyTypes, string(networkingv1.PolicyTypeIngress))
+		}
+		if s.compiled[i].egress {
+			info.PolicyTypes = append(info.PolicyTypes, string(networkingv1.PolicyTypeEgress))
+		}
+		for j := range s.pods {
+			if s.compiled[i].selects(&s.pods[j]) {
+				info.SelectedPods = append(info.SelectedPods, s.pods[j].Name)
+			}
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Namespace != infos[j].Namespace {
+			return infos[i].Namespace < infos[j].Namespace
+		}
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
+
+// PodPolicies returns the policies selecting each pod, filtered to
+// namespace when set
+func (s *NetworkPolicySet) PodPolicies(namespace string) []PodNetworkPolicies {
+	result := make([]PodNetworkPolicies, 0, len(s.pods))
+	for i := range s.pods {
+		pod := &s.pods[i]
+		if namespace != "" && pod.Namespace != namespace {
+			continue
+		}
+		entry := PodNetworkPolicies{
+			Pod:             pod.Name,
+			Namespace:       pod.Namespace,
+			IngressPolicies: make([]string, 0),
+			EgressPolicies:  make([]string, 0),
+		}
+		for j := range s.compiled {
+			policy := &s.compiled[j]
+			if !policy.selects(pod) {
+				continue
+			}
+			if policy.ingress {
+				entry.IngressPolicies = append(entry.IngressPolicies, s.policies[j].Name)
+			}
+			if policy.egress {
+				entry.EgressPolicies = append(entry.EgressPolicies, s.policies[j].Name)
+			}
+		}
+		entry.IngressIsolated = len(entry.IngressPolicies) > 0
+		entry.EgressIsolated = len(entry.EgressPolicies) > 0
+		result = append(result, entry)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Namespace != result[j].Namespace {
+			return result[i].Namespace < result[j].Namespace
+		}
+		return result[i].Pod < result[j].Pod
+	})
+	return result
+}
+
+// CanReach evaluates whether src can connect to dst on port, a number or a
+// named container port of dst. An empty port asks whether any port is
+// reachable, which needs a port admitted by both directions. protocol
+// defaults to TCP.
+func (s *NetworkPolicySet) CanReach(src, dst *corev1.Pod, port string, protocol corev1.Protocol) ReachabilityResult {
+	if protocol == "" {
+		protocol = corev1.ProtocolTCP
+	}
+	result := ReachabilityResult{
+		From:     src.Namespace + "/" + src.Name,
+		To:       dst.Namespace + "/" + dst.Name,
+		Port:     port,
+		Protocol: string(protocol),
+		Egress:   DirectionVerdict{Allowed: true, Allowing: make([]string, 0), Blocking: make([]string, 0)},
+		Ingress:  DirectionVerdict{Allowed: true, Allowing: make([]string, 0), Blocking: make([]string, 0)},
+	}
+
+	egressPorts, ingressPorts := portSet{all: true}, portSet{all: true}
+	for i := range s.compiled {
+		policy := &s.compiled[i]
+
+		if policy.egress && policy.selects(src) {
+			if !result.Egress.Isolated {
+				result.Egress.Isolated, egressPorts = true, portSet{}
+			}
+			if s.rulesAllow(policy.egressRules, policy.namespace, dst, dst, port, protocol, &egressPorts) {
+				result.Egress.Allowing = append(result.Egress.Allowing, policy.name)
+			} else {
+				result.Egress.Blocking = append(result.Egress.Blocking, policy.name)
+			}
+		}
+
+		if policy.ingress && policy.selects(dst) {
+			if !result.Ingress.Isolated {
+				result.Ingress.Isolated, ingressPorts = true, portSet{}
+			}
+			if s.rulesAllow(policy.ingressRules, policy.namespace, src, dst, port, protocol, &ingressPorts) {
+				result.Ingress.Allowing = append(result.Ingress.Allowing, policy.name)
+			} else {
+				result.Ingress.Blocking = append(result.Ingress.Blocking, policy.name)
+			}
+		}
+	}
+
+	// Policies are additive: an isolated pod is reachable when any
+	// selecting policy allows the traffic
+	result.Egress.Allowed = !result.Egress.Isolated || len(result.Egress.Allowing) > 0
+	result.Ingress.Allowed = !result.Ingress.Isolated || len(result.Ingress.Allowing) > 0
+	result.Allowed = result.Egress.Allowed && result.Ingress.Allowed
+	if port == "" {
+		result.Allowed = result.Allowed && egressPorts.intersects(ingressPorts)
+	}
+	return result
+}
+
+// rulesAllow reports whether any rule admits peer and port on dst. With an
+// empty port the ports of every rule matching peer are added to ports.
+func (s *NetworkPolicySet) rulesAllow(rules []compiledRule, policyNamespace string, peer, dst *corev1.Pod, port string, protocol corev1.Protocol, ports *portSet) bool {
+	allowed := false
+	for _, rule := range rules {
+		if !s.peersMatch(rule.peers, policyNamespace, peer) {
+			continue
+		}
+		if port != "" {
+			if portsMatch(rule.ports, dst, port, protocol) {
+				return true
+			}
+			continue
+		}
+		admitted := admittedPorts(rule.ports, dst, protocol)
+		ports.add(admitted)
+		allowed = allowed || !admitted.empty()
+	}
+	return allowed
+}
+
+// Graph evaluates every pod pair on any TCP port and summarizes the result
+// per source and destination namespace. Selectors are matched once per pod
+// up front, so each pair only walks the rules of the policies selecting it.
+func (s *NetworkPolicySet) Graph() NetworkPolicyGraph {
+	podsByNamespace := make(map[string][]int)
+	for i := range s.pods {
+		podsByNamespace[s.pods[i].Namespace] = append(podsByNamespace[s.pods[i].Namespace], i)
+	}
+
+	// Per policy, the pods each rule admits as peers
+	egressOf := make([][]int, len(s.pods))
+	ingressOf := make([][]int, len(s.pods))
+	egressPeers := make([][][]bool, len(s.compiled))
+	ingressPeers := make([][][]bool, len(s.compiled))
+	for p := range s.compiled {
+		policy := &s.compiled[p]
+		selected := false
+		for _, i := range podsByNamespace[policy.namespace] {
+			if !policy.selects(&s.pods[i]) {
+				continue
+			}
+			selected = true
+			if policy.egress {
+				egressOf[i] = append(egressOf[i], p)
+			}
+			if policy.ingress {
+				ingressOf[i] = append(ingressOf[i], p)
+			}
+		}
+		if !selected {
+			continue
+		}
+		if policy.egress {
+			egressPeers[p] = s.rulePeers(policy.egressRules, policy.namespace)
+		}
+		if policy.ingress {
+			ingressPeers[p] = s.rulePeers(policy.ingressRules, policy.namespace)
+		}
+	}
+
+	edges := make(map[[2]string]*NamespaceTrafficEdge)
+	namespaces := make(map[string]bool)
+	for i := range s.pods {
+		src := &s.pods[i]
+		namespaces[src.Namespace] = true
+		for j := range s.pods {
+			if i == j {
+				continue
+			}
+			dst := &s.pods[j]
+			key := [2]string{src.Namespace, dst.Namespace}
+			edge, ok := edges[key]
+			if !ok {
+				edge = &NamespaceTrafficEdge{From: src.Namespace, To: dst.Namespace}
+				edges[key] = edge
+			}
+			edge.TotalPairs++
+
+			egress := s.directionPorts(egressOf[i], egressPeers, true, j, dst)
+			ingress := s.directionPorts(ingressOf[j], ingressPeers, false, i, dst)
+			if egress.intersects(ingress) {
+				edge.AllowedPairs++
+			}
+		}
+	}
+
+	graph := NetworkPolicyGraph{Namespaces: setKeys(namespaces), Edges: make([]NamespaceTrafficEdge, 0, len(edges))}
+	for _, edge := range edges {
+		switch edge.AllowedPairs {
+		case edge.TotalPairs:
+			edge.Status = TrafficAllowed
+		case 0:
+			edge.Status = TrafficDenied
+		default:
+			edge.Status = TrafficPartial
+		}
+		graph.Edges = append(graph.Edges, *edge)
+	}
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		if graph.Edges[i].From != graph.Edges[j].From {
+			return graph.Edges[i].From < graph.Edges[j].From
+		}
+		return graph.Edges[i].To < graph.Edges[j].To
+	})
+	return graph
+}
+
+// rulePeers returns, per rule, which pods of the set the rule admits
+func (s *NetworkPolicySet) rulePeers(rules []compiledRule, policyNamespace string) [][]bool {
+	peers := make([][]bool, len(rules))
+	for r, rule := range rules {
+		peers[r] = make([]bool, len(s.pods))
+		for i := range s.pods {
+			peers[r][i] = s.peersMatch(rule.peers, policyNamespace, &s.pods[i])
+		}
+	}
+	return peers
+}
+
+// directionPorts returns the TCP ports on dst that the selecting policies
+// admit from or to the peer pod. A pod no policy selects admits all ports.
+func (s *NetworkPolicySet) directionPorts(selecting []int, rulePeers [][][]bool, egress bool, peer int, dst *corev1.Pod) portSet {
+	if len(selecting) == 0 {
+		return portSet{all: true}
+	}
+	var ports portSet
+	for _, p := range selecting {
+		rules := s.compiled[p].ingressRules
+		if egress {
+			rules = s.compiled[p].egressRules
+		}
+		for r, rule := range rules {
+			if rulePeers[p][r][peer] {
+				ports.add(admittedPorts(rule.ports, dst, corev1.ProtocolTCP))
+			}
+		}
+	}
+	return ports
+}
+
+// policyTypes returns the directions a policy applies to. Without explicit
+// policyTypes it always covers ingress, and egress only when it has rules.
+func policyTypes(policy *networkingv1.NetworkPolicy) (ingress, egress bool) {
+	if len(policy.Spec.PolicyTypes) == 0 {
+		return true, len(policy.Spec.Egress) > 0
+	}
+	for _, policyType := range policy.Spec.PolicyTypes {
+		switch policyType {
+		case networkingv1.PolicyTypeIngress:
+			ingress = true
+		case networkingv1.PolicyTypeEgress:
+			egress = true
+		}
+	}
+	return ingress, egress
+}
+
+// compilePolicy parses a policy's selectors
+func compilePolicy(policy *networkingv1.NetworkPolicy) compiledPolicy {
+	compiled := compiledPolicy{
+		name:        policy.Namespace + "/" + policy.Name,
+		namespace:   policy.Namespace,
+		podSelector: parseSelector(&policy.Spec.PodSelector),
+	}
+	compiled.ingress, compiled.egress = policyTypes(policy)
+	for _, rule := range policy.Spec.Ingress {
+		compiled.ingressRules = append(compiled.ingressRules, compiledRule{peers: compilePeers(rule.From), ports: rule.Ports})
+	}
+	for _, rule := range policy.Spec.Egress {
+		compiled.egressRules = append(compiled.egressRules, compiledRule{peers: compilePeers(rule.To), ports: rule.Ports})
+	}
+	return compiled
+}
+
+func compilePeers(peers []networkingv1.NetworkPolicyPeer) []compiledPeer {
+	compiled := make([]compiledPeer, 0, len(peers))
+	for _, peer := range peers {
+		entry := compiledPeer{ipBlock: peer.IPBlock}
+		if peer.NamespaceSelector != nil {
+			entry.namespaceSelector = parseSelector(peer.NamespaceSelector)
+		}
+		if peer.PodSelector != nil {
+			entry.podSelector = parseSelector(peer.PodSelector)
+		}
+		compiled = append(compiled, entry)
+	}
+	return compiled
+}
+
+// parseSelector treats invalid selectors as matching nothing
+func parseSelector(selector *metav1.LabelSelector) labels.Selector {
+	parsed, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return labels.Nothing()
+	}
+	return parsed
+}
+
+func (p *compiledPolicy) selects(pod *corev1.Pod) bool {
+	return p.namespace == pod.Namespace && p.podSelector.Matches(labels.Set(pod.Labels))
+}
+
+// peersMatch reports whether pod is one of the rule's peers. An empty peer
+// list matches everything.
+func (s *NetworkPolicySet) peersMatch(peers []compiledPeer, policyNamespace string, pod *corev1.Pod) bool {
+	if len(peers) == 0 {
+		return true
+	}
+	for i := range peers {
+		if s.peerMatches(&peers[i], policyNamespace, pod) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *NetworkPolicySet) peerMatches(peer *compiledPeer, policyNamespace string, pod *corev1.Pod) bool {
+	if peer.ipBlock != nil {
+		return ipBlockContains(peer.ipBlock, pod.Status.PodIP)
+	}
+
+	if peer.namespaceSelector == nil {
+		if pod.Namespace != policyNamespace {
+			return false
+		}
+	} else {
+		namespaceLabels, ok := s.namespaceLabels[pod.Namespace]
+		if !ok {
+			namespaceLabels = namespaceLabelSet(pod.Namespace, nil)
+		}
+		if !peer.namespaceSelector.Matches(namespaceLabels) {
+			return false
+		}
+	}
+
+	return peer.podSelector == nil || peer.podSelector.Matches(labels.Set(pod.Labels))
+}
+
+// ipBlockContains reports whether ip falls in the block's CIDR and outside
+// its exceptions
+func ipBlockContains(block *networkingv1.IPBlock, ip string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	_, cidr, err := net.ParseCIDR(block.CIDR)
+	if err != nil || !cidr.Contains(addr) {
+		return false
+	}
+	for _, except := range block.Except {
+		if _, excluded, err := net.ParseCIDR(except); err == nil && excluded.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// portsMatch reports whether a rule's ports admit port/protocol on dst.
+// Named ports, in the rule or the request, resolve against dst's
+// containers.
+func portsMatch(ports []networkingv1.NetworkPolicyPort, dst *corev1.Pod, port string, protocol corev1.Protocol) bool {
+	if len(ports) == 0 {
+		return true
+	}
+
+	number, numeric := 0, false
+	if n, err := strconv.Atoi(port); err == nil {
+		number, numeric = n, true
+	} else if n, ok := containerPort(dst, port, protocol); ok {
+		number, numeric = n, true
+	}
+
+	for _, rulePort := range ports {
+		ruleProtocol := corev1.ProtocolTCP
+		if rulePort.Protocol != nil {
+			ruleProtocol = *rulePort.Protocol
+		}
+		if ruleProtocol != protocol {
+			continue
+		}
+		if rulePort.Port == nil {
+			return true
+		}
+
+		if rulePort.Port.Type == intstr.String {
+			if rulePort.Port.String() == port {
+				return true
+			}
+			if n, ok := containerPort(dst, rulePort.Port.String(), protocol); ok && numeric && n == number {
+				return true
+			}
+			continue
+		}
+
+		if !numeric {
+			continue
+		}
+		start, end := rulePort.Port.IntValue(), rulePort.Port.IntValue()
+		if rulePort.EndPort != nil {
+			end = int(*rulePort.EndPort)
+		}
+		if number >= start && number <= end {
+			return true
+		}
+	}
+	return false
+}
+
+// admittedPorts returns the ports a rule admits on dst for protocol. Named
+// rule ports that dst does not define admit nothing.
+func admittedPorts(ports []networkingv1.NetworkPolicyPort, dst *corev1.Pod, protocol corev1.Protocol) portSet {
+	if len(ports) == 0 {
+		return portSet{all: true}
+	}
+	var set portSet
+	for _, rulePort := range ports {
+		ruleProtocol := corev1.ProtocolTCP
+		if rulePort.Protocol != nil {
+			ruleProtocol = *rulePort.Protocol
+		}
+		if ruleProtocol != protocol {
+			continue
+		}
+		if rulePort.Port == nil {
+			return portSet{all: true}
+		}
+
+		if rulePort.Port.Type == intstr.String {
+			if n, ok := containerPort(dst, rulePort.Port.String(), protocol); ok {
+				set.ranges = append(set.ranges, portRange{start: n, end: n})
+			}
+			continue
+		}
+		start, end := rulePort.Port.IntValue(), rulePort.Port.IntValue()
+		if rulePort.EndPort != nil {
+			end = int(*rulePort.EndPort)
+		}
+		set.ranges = append(set.ranges, portRange{start: start, end: end})
+	}
+	return set
+}
+
+func (p portSet) empty() bool {
+	return !p.all && len(p.ranges) == 0
+}
+
+func (p *portSet) add(other portSet) {
+	p.all = p.all || other.all
+	p.ranges = append(p.ranges, other.ranges...)
+}
+
+// intersects reports whether a port is in both sets
+func (p portSet) intersects(other portSet) bool {
+	if p.empty() || other.empty() {
+		return false
+	}
+	if p.all || other.all {
+		return true
+	}
+	for _, a := range p.ranges {
+		for _, b := range other.ranges {
+			if a.start <= b.end && b.start <= a.end {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// containerPort resolves a named port on the pod's containers
+func containerPort(pod *corev1.Pod, name string, protocol corev1.Protocol) (int, bool) {
+	for _, container := range pod.Spec.Containers {
+		for _, port := range container.Ports {
+			portProtocol := port.Protocol
+			if portProtocol == "" {
+				portProtocol = corev1.ProtocolTCP
+			}
+			if port.Name == name && portProtocol == protocol {
+				return int(port.ContainerPort), true
+			}
+		}
+	}
+	return 0, false
+}
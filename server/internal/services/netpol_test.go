@@ -0,0 +1,207 @@
+package services
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func netpolFixture() *NetworkPolicySet {
+	pod := func(namespace, name, app, ip string) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: map[string]string{"app": app}},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name:  "main",
+				Ports: []corev1.ContainerPort{{Name: "pg", ContainerPort: 5432}},
+			}}},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning, PodIP: ip},
+		}
+	}
+	pods := []corev1.Pod{
+		pod("shop", "web-0", "web", "10.0.1.10"),
+		pod("shop", "worker-0", "worker", "10.0.1.11"),
+		pod("db", "postgres-0", "postgres", "10.0.2.10"),
+		pod("batch", "job-0", "job", "10.0.3.10"),
+	}
+	namespaces := []corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "shop", Labels: map[string]string{"team": "shop"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "db"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "batch"}},
+	}
+
+	tcp := corev1.ProtocolTCP
+	pgPort := intstr.FromString("pg")
+	endPort := int32(9100)
+	metricsPort := intstr.FromInt(9000)
+	policies := []networkingv1.NetworkPolicy{
+		{
+			// Default deny ingress in db
+			ObjectMeta: metav1.ObjectMeta{Name: "default-deny", Namespace: "db"},
+			Spec:       networkingv1.NetworkPolicySpec{PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "allow-shop-web", Namespace: "db"},
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "postgres"}},
+				Ingress: []networkingv1.NetworkPolicyIngressRule{{
+					From: []networkingv1.NetworkPolicyPeer{{
+						NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "shop"}},
+						PodSelector:       &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+					}},
+					Ports: []networkingv1.NetworkPolicyPort{{Protocol: &tcp, Port: &pgPort}},
+				}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "allow-batch-ips", Namespace: "db"},
+			Spec: networkingv1.NetworkPolicySpec{
+				Ingress: []networkingv1.NetworkPolicyIngressRule{{
+					From:  []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "10.0.0.0/16", Except: []string{"10.0.1.0/24"}}}},
+					Ports: []networkingv1.NetworkPolicyPort{{Port: &metricsPort, EndPort: &endPort}},
+				}},
+			},
+		},
+		{
+			// Workers may only talk to their own namespace
+			ObjectMeta: metav1.ObjectMeta{Name: "worker-egress", Namespace: "shop"},
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "worker"}},
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+				Egress:      []networkingv1.NetworkPolicyEgressRule{{To: []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{}}}}},
+			},
+		},
+	}
+	return NewNetworkPolicySet(policies, pods, namespaces)
+}
+
+func TestNetworkPolicySet_CanReach(t *testing.T) {
+	set := netpolFixture()
+	get := func(ref string) *corev1.Pod {
+		for i := range set.pods {
+			if set.pods[i].Namespace+"/"+set.pods[i].Name == ref {
+				return &set.pods[i]
+			}
+		}
+		t.Fatalf("pod %s not found", ref)
+		return nil
+	}
+
+	tests := []struct {
+		name, from, to, port string
+		protocol             corev1.Protocol
+		allowed              bool
+		allowing, blocking   []string
+	}{
+		{"unisolated", "db/postgres-0", "shop/web-0", "80", "", true, nil, nil},
+		{"named port", "shop/web-0", "db/postgres-0", "5432", "", true, []string{"db/allow-shop-web"}, []string{"db/default-deny", "db/allow-batch-ips"}},
+		{"named port by name", "shop/web-0", "db/postgres-0", "pg", "", true, []string{"db/allow-shop-web"}, []string{"db/default-deny", "db/allow-batch-ips"}},
+		{"wrong port", "shop/web-0", "db/postgres-0", "80", "", false, nil, []string{"db/default-deny", "db/allow-shop-web", "db/allow-batch-ips"}},
+		{"wrong protocol", "shop/web-0", "db/postgres-0", "5432", corev1.ProtocolUDP, false, nil, []string{"db/default-deny", "db/allow-shop-web", "db/allow-batch-ips"}},
+		{"ip block port range", "batch/job-0", "db/postgres-0", "9050", "", true, []string{"db/allow-batch-ips"}, []string{"db/default-deny", "db/allow-shop-web"}},
+		{"ip block except", "shop/web-0", "db/postgres-0", "9050", "", false, nil, []string{"db/default-deny", "db/allow-shop-web", "db/allow-batch-ips"}},
+		{"any port", "batch/job-0", "db/postgres-0", "", "", true, []string{"db/allow-batch-ips"}, []string{"db/default-deny", "db/allow-shop-web"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := set.CanReach(get(tt.from), get(tt.to), tt.port, tt.protocol)
+			if result.Allowed != tt.allowed || !equalStrings(result.Ingress.Allowing, tt.allowing) || !equalStrings(result.Ingress.Blocking, tt.blocking) {
+				t.Errorf("unexpected result %+v", result)
+			}
+		})
+	}
+
+	// Egress isolation blocks the worker before ingress is considered
+	result := set.CanReach(get("shop/worker-0"), get("batch/job-0"), "80", "")
+	if result.Allowed || !result.Egress.Isolated || result.Egress.Blocking[0] != "shop/worker-egress" || !result.Ingress.Allowed {
+		t.Errorf("expected egress block, got %+v", result)
+	}
+	if result := set.CanReach(get("shop/worker-0"), get("shop/web-0"), "80", ""); !result.Allowed || result.Egress.Allowing[0] != "shop/worker-egress" {
+		t.Errorf("expected same-namespace egress, got %+v", result)
+	}
+}
+
+func TestNetworkPolicySet_PoliciesAndGraph(t *testing.T) {
+	set := netpolFixture()
+
+	policies := set.Policies("db")
+	if len(policies) != 3 || policies[0].Name != "allow-batch-ips" || len(policies[0].SelectedPods) != 1 {
+		t.Errorf("unexpected policies %+v", policies)
+	}
+	pods := set.PodPolicies("shop")
+	if len(pods) != 2 || pods[1].Pod != "worker-0" || !pods[1].EgressIsolated || pods[1].IngressIsolated || pods[0].EgressIsolated {
+		t.Errorf("unexpected pod policies %+v", pods)
+	}
+
+	graph := set.Graph()
+	status := make(map[string]string)
+	for _, edge := range graph.Edges {
+		status[edge.From+">"+edge.To] = edge.Status
+	}
+	// web reaches postgres, worker does not
+	if status["shop>db"] != TrafficPartial || status["batch>db"] != TrafficAllowed || status["db>shop"] != TrafficAllowed {
+		t.Errorf("unexpected graph %+v", graph.Edges)
+	}
+	if status["shop>batch"] != TrafficPartial || status["shop>shop"] != TrafficAllowed {
+		t.Errorf("unexpected graph %+v", graph.Edges)
+	}
+}
+
+func TestNetworkPolicySet_AnyPortNeedsCommonPort(t *testing.T) {
+	pods := []corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "client-0", Namespace: "app", Labels: map[string]string{"app": "client"}}, Status: corev1.PodStatus{Phase: corev1.PodRunning}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "server-0", Namespace: "app", Labels: map[string]string{"app": "server"}}, Status: corev1.PodStatus{Phase: corev1.PodRunning}},
+	}
+	pgPort, httpPort := intstr.FromInt(5432), intstr.FromInt(80)
+	policies := []networkingv1.NetworkPolicy{
+		{
+			// The client may only send to 5432
+			ObjectMeta: metav1.ObjectMeta{Name: "client-egress", Namespace: "app"},
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "client"}},
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+				Egress:      []networkingv1.NetworkPolicyEgressRule{{Ports: []networkingv1.NetworkPolicyPort{{Port: &pgPort}}}},
+			},
+		},
+		{
+			// The server only accepts 80
+			ObjectMeta: metav1.ObjectMeta{Name: "server-ingress", Namespace: "app"},
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "server"}},
+				Ingress:     []networkingv1.NetworkPolicyIngressRule{{Ports: []networkingv1.NetworkPolicyPort{{Port: &httpPort}}}},
+			},
+		},
+	}
+	set := NewNetworkPolicySet(policies, pods, nil)
+	client, _ := set.Pod("app", "client-0")
+	server, _ := set.Pod("app", "server-0")
+
+	result := set.CanReach(client, server, "", "")
+	if result.Allowed || !result.Egress.Allowed || !result.Ingress.Allowed {
+		t.Errorf("expected disjoint ports to deny, got %+v", result)
+	}
+	for _, port := range []string{"80", "5432"} {
+		if result := set.CanReach(client, server, port, ""); result.Allowed {
+			t.Errorf("expected port %s to be denied, got %+v", port, result)
+		}
+	}
+
+	graph := set.Graph()
+	if len(graph.Edges) != 1 || graph.Edges[0].AllowedPairs != 1 || graph.Edges[0].Status != TrafficPartial {
+		t.Errorf("expected only server to client, got %+v", graph.Edges)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,214 @@
+// internal/services/quotas.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// QuotaWarningPercent is the usage at which a namespace is reported as
+// approaching its quota
+const QuotaWarningPercent = 80.0
+
+// QuotaResource is the usage of one resource tracked by a ResourceQuota
+type QuotaResource struct {
+	Resource    string  `json:"resource"`
+	Hard        string  `json:"hard"`
+	Used        string  `json:"used"`
+	PercentUsed float64 `json:"percent_used"`
+}
+
+// QuotaInfo is a ResourceQuota with per-resource usage, most used first
+type QuotaInfo struct {
+	Name           string          `json:"name"`
+	Namespace      string          `json:"namespace"`
+	Scopes         []string        `json:"scopes,omitempty"`
+	Resources      []QuotaResource `json:"resources"`
+	MaxPercentUsed float64         `json:"max_percent_used"`
+}
+
+// LimitRangeItem is the constraints a LimitRange applies to one resource of
+// a Container, Pod or PersistentVolumeClaim
+type LimitRangeItem struct {
+	Type                 string `json:"type"`
+	Resource             string `json:"resource"`
+	Default              string `json:"default,omitempty"`
+	DefaultRequest       string `json:"default_request,omitempty"`
+	Min                  string `json:"min,omitempty"`
+	Max                  string `json:"max,omitempty"`
+	MaxLimitRequestRatio string `json:"max_limit_request_ratio,omitempty"`
+}
+
+// LimitRangeInfo is a LimitRange flattened per type and resource
+type LimitRangeInfo struct {
+	Name   string           `json:"name"`
+	Limits []LimitRangeItem `json:"limits"`
+}
+
+// NamespaceQuotas is the quotas and limit ranges of a namespace. NearLimit
+// is set when any quota resource reaches QuotaWarningPercent.
+type NamespaceQuotas struct {
+	Namespace      string           `json:"namespace"`
+	Quotas         []QuotaInfo      `json:"quotas"`
+	LimitRanges    []LimitRangeInfo `json:"limit_ranges"`
+	MaxPercentUsed float64          `json:"max_percent_used"`
+	NearLimit      bool             `json:"near_limit"`
+}
+
+// ListNamespaceQuotas returns quota usage and LimitRange defaults for
+// namespace (all when empty). Namespaces with neither are omitted.
+func ListNamespaceQuotas(ctx context.Context, clientset kubernetes.Interface, namespace string) ([]NamespaceQuotas, error) {
+	quotas, err := clientset.CoreV1().ResourceQuotas(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resource quotas: %w", err)
+	}
+	limitRanges, err := clientset.CoreV1().LimitRanges(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list limit ranges: %w", err)
+	}
+	return BuildNamespaceQuotas(quotas.Items, limitRanges.Items), nil
+}
+
+// BuildNamespaceQuotas groups quotas and limit ranges by namespace
+func BuildNamespaceQuotas(quotas []corev1.ResourceQuota, limitRanges []corev1.LimitRange) []NamespaceQuotas {
+	byNamespace := make(map[string]*NamespaceQuotas)
+	entry := func(namespace string) *NamespaceQuotas {
+		if e, ok := byNamespace[namespace]; ok {
+			return e
+		}
+		e := &NamespaceQuotas{Namespace: namespace, Quotas: make([]QuotaInfo, 0), LimitRanges: make([]LimitRangeInfo, 0)}
+		byNamespace[namespace] = e
+		return e
+	}
+
+	for i := range quotas {
+		info := NewQuotaInfo(&quotas[i])
+		e := entry(info.Namespace)
+		e.Quotas = append(e.Quotas, info)
+		if info.MaxPercentUsed > e.MaxPercentUsed {
+			e.MaxPercentUsed = info.MaxPercentUsed
+		}
+	}
+	for i := range limitRanges {
+		e := entry(limitRanges[i].Namespace)
+		e.LimitRanges = append(e.LimitRanges, newLimitRangeInfo(&limitRanges[i]))
+	}
+
+	result := make([]NamespaceQuotas, 0, len(byNamespace))
+	for _, e := range byNamespace {
+		e.NearLimit = e.MaxPercentUsed >= QuotaWarningPercent
+		sort.Slice(e.Quotas, func(i, j int) bool { return e.Quotas[i].Name < e.Quotas[j].Name })
+		sort.Slice(e.LimitRanges, func(i, j int) bool { return e.LimitRanges[i].Name < e.LimitRanges[j].Name })
+		result = append(result, *e)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Namespace < result[j].Namespace })
+	return result
+}
+
+// NewQuotaInfo computes usage against every hard limit of a quota. Used is
+// taken from the quota status, which the quota controller keeps current.
+func NewQuotaInfo(quota *corev1.ResourceQuota) QuotaInfo {
+	info := QuotaInfo{Name: quota.Name, Namespace: quota.Namespace, Resources: make([]QuotaResource, 0, len(quota.Status.Hard))}
+	for _, scope := range quota.Spec.Scopes {
+		info.Scopes = append(info.Scopes, string(scope))
+	}
+
+	hard := quota.Status.Hard
+	if len(hard) == 0 {
+		// Not yet reconciled by the quota controller
+		hard = quota.Spec.Hard
+	}
+	for name, limit := range hard {
+		used := quota.Status.Used[name]
+		usage := QuotaResource{
+			Resource:    string(name),
+			Hard:        limit.String(),
+			Used:        used.String(),
+			PercentUsed: quotaPercent(used, limit),
+		}
+		if usage.PercentUsed > info.MaxPercentUsed {
+			info.MaxPercentUsed = usage.PercentUsed
+		}
+		info.Resources = append(info.Resources, usage)
+	}
+	sort.Slice(info.Resources, func(i, j int) bool {
+		if info.Resources[i].PercentUsed != info.Resources[j].PercentUsed {
+			return info.Resources[i].PercentUsed > info.Resources[j].PercentUsed
+		}
+		return info.Resources[i].Resource < info.Resources[j].Resource
+	})
+	return info
+}
+
+// quotaPercent returns used as a percentage of hard. A zero hard limit
+// forbids the resource, so any use of it is reported as fully used.
+func quotaPercent(used, hard resource.Quantity) float64 {
+	if used.IsZero() {
+		return 0
+	}
+	if hard.IsZero() {
+		return 100
+	}
+	return round2(used.AsApproximateFloat64() / hard.AsApproximateFloat64() * 100)
+}
+
+func newLimitRangeInfo(limitRange *corev1.LimitRange) LimitRangeInfo {
+	info := LimitRangeInfo{Name: limitRange.Name, Limits: make([]LimitRangeItem, 0)}
+	for _, limit := range limitRange.Spec.Limits {
+		resources := make(map[corev1.ResourceName]bool)
+		for _, list := range []corev1.ResourceList{limit.Default, limit.DefaultRequest, limit.Min, limit.Max, limit.MaxLimitRequestRatio} {
+			for name := range list {
+				resources[name] = true
+			}
+		}
+		names := make([]string, 0, len(resources))
+		for name := range resources {
+			names = append(names, string(name))
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			resourceName := corev1.ResourceName(name)
+			info.Limits = append(info.Limits, LimitRangeItem{
+				Type:                 string(limit.Type),
+				Resource:             name,
+				Default:              quantityString(limit.Default, resourceName),
+				DefaultRequest:       quantityString(limit.DefaultRequest, resourceName),
+				Min:                  quantityString(limit.Min, resourceName),
+				Max:                  quantityString(limit.Max, resourceName),
+				MaxLimitRequestRatio: quantityString(limit.MaxLimitRequestRatio, resourceName),
+			})
+		}
+	}
+	return info
+}
+
+// quantityString returns the quantity for name, or "" when unset
+func quantityString(list corev1.ResourceList, name corev1.ResourceName) string {
+	if quantity, ok := list[name]; ok {
+		return quantity.String()
+	}
+	return ""
+}
+
+// namespaceQuotaUsage returns, per namespace, the highest percent used of
+// any quota resource and which resource it is
+func namespaceQuotaUsage(quotas []corev1.ResourceQuota) map[string]QuotaResource {
+	usage := make(map[string]QuotaResource)
+	for i := range quotas {
+		info := NewQuotaInfo(&quotas[i])
+		if len(info.Resources) == 0 {
+			continue
+		}
+		if current, ok := usage[info.Namespace]; !ok || info.Resources[0].PercentUsed > current.PercentUsed {
+			usage[info.Namespace] = info.Resources[0]
+		}
+	}
+	return usage
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestNamespaceQuotas(t *testing.T) {
+	quota := func(namespace, name string, hard, used corev1.ResourceList) *corev1.ResourceQuota {
+		return &corev1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       corev1.ResourceQuotaSpec{Hard: hard},
+			Status:     corev1.ResourceQuotaStatus{Hard: hard, Used: used},
+		}
+	}
+	list := func(pairs ...string) corev1.ResourceList {
+		result := corev1.ResourceList{}
+		for i := 0; i < len(pairs); i += 2 {
+			result[corev1.ResourceName(pairs[i])] = resource.MustParse(pairs[i+1])
+		}
+		return result
+	}
+
+	clientset := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "batch"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "free"}},
+		quota("shop", "compute",
+			list("requests.cpu", "4", "requests.memory", "8Gi", "pods", "10"),
+			list("requests.cpu", "3500m", "requests.memory", "2Gi", "pods", "6")),
+		quota("shop", "objects",
+			list("services", "5", "count/configmaps", "20"),
+			list("services", "1", "count/configmaps", "2")),
+		quota("batch", "compute", list("pods", "20", "services.loadbalancers", "0"), list("pods", "5", "services.loadbalancers", "1")),
+		// A zero limit nothing uses is not a warning
+		quota("free", "no-lb", list("services.loadbalancers", "0"), list("services.loadbalancers", "0")),
+		&corev1.LimitRange{
+			ObjectMeta: metav1.ObjectMeta{Name: "defaults", Namespace: "free"},
+			Spec: corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{{
+				Type:           corev1.LimitTypeContainer,
+				Default:        list("cpu", "500m", "memory", "512Mi"),
+				DefaultRequest: list("cpu", "100m"),
+				Max:            list("memory", "2Gi"),
+			}}},
+		},
+	)
+	ctx := context.Background()
+
+	namespaces, err := ListNamespaceQuotas(ctx, clientset, "")
+	if err != nil {
+		t.Fatalf("ListNamespaceQuotas failed: %v", err)
+	}
+	if len(namespaces) != 3 || namespaces[0].Namespace != "batch" || namespaces[2].Namespace != "shop" {
+		t.Fatalf("unexpected namespaces %+v", namespaces)
+	}
+
+	batch, free, shop := namespaces[0], namespaces[1], namespaces[2]
+	if !shop.NearLimit || shop.MaxPercentUsed != 87.5 || len(shop.Quotas) != 2 {
+		t.Errorf("unexpected shop quotas %+v", shop)
+	}
+	compute := shop.Quotas[0].Resources
+	if compute[0].Resource != "requests.cpu" || compute[0].Used != "3500m" || compute[1].PercentUsed != 60 || compute[2].PercentUsed != 25 {
+		t.Errorf("unexpected compute usage %+v", compute)
+	}
+	if !batch.NearLimit || batch.Quotas[0].Resources[0].Resource != "services.loadbalancers" || batch.Quotas[0].Resources[0].PercentUsed != 100 {
+		t.Errorf("zero hard limit should count as exhausted: %+v", batch)
+	}
+	if free.NearLimit || free.MaxPercentUsed != 0 || len(free.Quotas) != 1 || free.Quotas[0].Resources[0].PercentUsed != 0 || len(free.LimitRanges) != 1 {
+		t.Fatalf("unexpected free namespace %+v", free)
+	}
+	limits := free.LimitRanges[0].Limits
+	if len(limits) != 2 || limits[0].Resource != "cpu" || limits[0].Default != "500m" || limits[0].DefaultRequest != "100m" || limits[1].Max != "2Gi" || limits[1].DefaultRequest != "" {
+		t.Errorf("unexpected limit range items %+v", limits)
+	}
+
+	client := &K8sClient{clientset: clientset}
+	metrics, err := client.GetClusterMetrics(ctx)
+	if err != nil {
+		t.Fatalf("GetClusterMetrics failed: %v", err)
+	}
+	if len(metrics.NamespacesNearQuota) != 2 || metrics.NamespacesNearQuota[0] != "batch" || metrics.NamespacesNearQuota[1] != "shop" {
+		t.Errorf("unexpected namespaces near quota %v", metrics.NamespacesNearQuota)
+	}
+	for _, ns := range metrics.NamespaceMetrics {
+		if ns.Name == "shop" && (ns.QuotaResource != "requests.cpu" || ns.QuotaUsedPercent != 87.5 || !ns.NearQuota) {
+			t.Errorf("unexpected shop metrics %+v", ns)
+		}
+		if ns.Name == "free" && (ns.NearQuota || ns.QuotaUsedPercent != 0) {
+			t.Errorf("unexpected free metrics %+v", ns)
+		}
+	}
+
+	shopMetrics, err := client.GetNamespaceMetrics(ctx, "shop")
+	if err != nil || !shopMetrics.NearQuota || shopMetrics.QuotaUsedPercent != 87.5 {
+		t.Errorf("unexpected namespace metrics %+v, %v", shopMetrics, err)
+	}
+}
+
+func TestMetrics_QuotaListErrorLeavesQuotaUnset(t *testing.T) {
+	clientset := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}})
+	clientset.PrependReactor("list", "resourcequotas", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("forbidden")
+	})
+	ctx := context.Background()
+	client := &K8sClient{clientset: clientset}
+
+	metrics, err := client.GetClusterMetrics(ctx)
+	if err != nil {
+		t.Fatalf("GetClusterMetrics failed: %v", err)
+	}
+	if len(metrics.NamespacesNearQuota) != 0 || len(metrics.NamespaceMetrics) != 1 || metrics.NamespaceMetrics[0].QuotaResource != "" {
+		t.Errorf("unexpected metrics %+v", metrics)
+	}
+	namespace, err := client.GetNamespaceMetrics(ctx, "shop")
+	if err != nil || namespace.QuotaResource != "" || namespace.NearQuota {
+		t.Errorf("unexpected namespace metrics %+v, %v", namespace, err)
+	}
+}
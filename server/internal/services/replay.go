@@ -0,0 +1,72 @@
+// internal/services/replay.go
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// ReplayClient serves the API from a recorded ClusterSnapshot instead of a
+// live cluster. It is backed by a fake clientset seeded with the snapshot's
+// objects, so every existing handler works unchanged.
+type ReplayClient struct {
+	*K8sClient
+	snapshot *ClusterSnapshot
+}
+
+// NewReplayClient loads a snapshot file written by /api/state/export or
+// /api/snapshots/:id
+func NewReplayClient(path string) (*ReplayClient, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot file: %w", err)
+	}
+
+	var snapshot ClusterSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot file: %w", err)
+	}
+
+	return &ReplayClient{
+		K8sClient: &K8sClient{clientset: fake.NewSimpleClientset(snapshot.Objects()...)},
+		snapshot:  &snapshot,
+	}, nil
+}
+
+// Snapshot returns the snapshot being replayed
+func (r *ReplayClient) Snapshot() *ClusterSnapshot {
+	return r.snapshot
+}
+
+// GetPodLogs fails because logs are not part of a snapshot
+func (r *ReplayClient) GetPodLogs(ctx context.Context, namespace, podName string, opts *corev1.PodLogOptions) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("logs are not available when replaying a snapshot taken at %s", r.snapshot.Time.Format("2006-01-02 15:04:05 MST"))
+}
+
+// Objects returns every object in the snapshot for seeding a clientset
+func (s *ClusterSnapshot) Objects() []runtime.Object {
+	objects := make([]runtime.Object, 0, len(s.Namespaces)+len(s.Nodes)+len(s.Pods)+len(s.Deployments)+len(s.Events))
+	for i := range s.Namespaces {
+		objects = append(objects, &s.Namespaces[i])
+	}
+	for i := range s.Nodes {
+		objects = append(objects, &s.Nodes[i])
+	}
+	for i := range s.Pods {
+		objects = append(objects, &s.Pods[i])
+	}
+	for i := range s.Deployments {
+		objects = append(objects, &s.Deployments[i])
+	}
+	for i := range s.Events {
+		objects = append(objects, &s.Events[i])
+	}
+	return objects
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReplayClient_ServesSnapshot(t *testing.T) {
+	snapshot := ClusterSnapshot{
+		Time:       time.Date(2024, 5, 1, 3, 0, 0, 0, time.UTC),
+		Namespaces: []corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "default", ResourceVersion: "10"}}},
+		Nodes:      []corev1.Node{{ObjectMeta: metav1.ObjectMeta{Name: "node-1", ResourceVersion: "11"}}},
+		Pods: []corev1.Pod{
+			{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default", ResourceVersion: "12"}, Spec: corev1.PodSpec{NodeName: "node-1"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "web-2", Namespace: "default", ResourceVersion: "13"}, Spec: corev1.PodSpec{NodeName: "node-1"}},
+		},
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	client, err := NewReplayClient(path)
+	if err != nil {
+		t.Fatalf("NewReplayClient failed: %v", err)
+	}
+
+	var _ K8sClientInterface = client
+	if !client.IsHealthy() {
+		t.Fatalf("expected replay client to be healthy")
+	}
+
+	metrics, err := client.GetClusterMetrics(context.Background())
+	if err != nil {
+		t.Fatalf("GetClusterMetrics failed: %v", err)
+	}
+	if metrics.TotalPods != 2 || metrics.TotalNodes != 1 || metrics.NodeMetrics[0].PodCount != 2 {
+		t.Fatalf("unexpected metrics: %+v", metrics)
+	}
+
+	if _, err := client.GetPodLogs(context.Background(), "default", "web-1", &corev1.PodLogOptions{}); err == nil {
+		t.Fatalf("expected logs to be unavailable in replay mode")
+	}
+}
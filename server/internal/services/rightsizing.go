@@ -0,0 +1,491 @@
+// internal/services/rightsizing.go
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Recommendation statuses
+const (
+	RightsizingOK               = "ok"
+	RightsizingOverProvisioned  = "over-provisioned"
+	RightsizingUnderProvisioned = "under-provisioned"
+	RightsizingInsufficientData = "insufficient-data"
+)
+
+const (
+	rightsizingMinSamples = 10
+	rightsizingHeadroom   = 1.15  // Added on top of observed usage
+	rightsizingOverFactor = 1.5   // Request this much above the recommendation is wasteful
+	rightsizingMaxPoints  = 20000 // Hard cap per series
+	minCPURecommendation  = 10    // millicores
+	minMemRecommendation  = 16 << 20
+)
+
+// ResourceRecommendation compares observed usage to the configured request
+// and limit. Savings is in cores for CPU and GiB for memory, across all
+// replicas; negative savings mean more should be requested.
+type ResourceRecommendation struct {
+	Request     string  `json:"request"`
+	Limit       string  `json:"limit"`
+	P50         string  `json:"p50"`
+	P95         string  `json:"p95"`
+	Max         string  `json:"max"`
+	Recommended string  `json:"recommended"`
+	Savings     float64 `json:"savings"`
+}
+
+// ContainerRecommendation is the right-sizing result for one container of a workload
+type ContainerRecommendation struct {
+	Namespace    string                 `json:"namespace"`
+	WorkloadKind string                 `json:"workload_kind"`
+	Workload     string                 `json:"workload"`
+	Container    string                 `json:"container"`
+	Replicas     int                    `json:"replicas"`
+	Samples      int                    `json:"samples"` // Sampling rounds, not points pooled across replicas
+	CPU          ResourceRecommendation `json:"cpu"`
+	Memory       ResourceRecommendation `json:"memory"`
+	Status       string                 `json:"status"`
+	Reasons      []string               `json:"reasons"`
+}
+
+// RightsizingRollup sums recommendations for a workload or namespace. Only
+// positive savings are counted.
+type RightsizingRollup struct {
+	Namespace        string  `json:"namespace"`
+	WorkloadKind     string  `json:"workload_kind,omitempty"`
+	Workload         string  `json:"workload,omitempty"`
+	Containers       int     `json:"containers"`
+	OverProvisioned  int     `json:"over_provisioned"`
+	UnderProvisioned int     `json:"under_provisioned"`
+	CPUSavingsCores  float64 `json:"cpu_savings_cores"`
+	MemorySavingsGiB float64 `json:"memory_savings_gib"`
+}
+
+// RightsizingReport holds recommendations and their rollups
+type RightsizingReport struct {
+	Window          string                    `json:"window"`
+	LastSample      time.Time                 `json:"last_sample,omitempty"`
+	LastError       string                    `json:"last_error,omitempty"`
+	Recommendations []ContainerRecommendation `json:"recommendations"`
+	Workloads       []RightsizingRollup       `json:"workloads"`
+	Namespaces      []RightsizingRollup       `json:"namespaces"`
+}
+
+type usagePoint struct {
+	time     time.Time
+	cpuMilli int64
+	memBytes int64
+}
+
+// usageSeries holds samples of one container across the pods of a workload
+type usageSeries struct {
+	namespace, workloadKind, workload, container string
+
+	points                                     []usagePoint
+	cpuRequest, cpuLimit, memRequest, memLimit int64
+	replicas                                   int
+	lastSeen                                   time.Time
+}
+
+// RightsizingSampler periodically samples metrics-server and keeps usage
+// for the configured window
+type RightsizingSampler struct {
+	clientset kubernetes.Interface
+	interval  time.Duration
+	window    time.Duration
+
+	mu         sync.RWMutex
+	series     map[string]*usageSeries
+	lastErr    error
+	lastSample time.Time
+}
+
+// NewRightsizingSampler creates a sampler. Call Run to start sampling.
+func NewRightsizingSampler(clientset kubernetes.Interface, interval, window time.Duration) *RightsizingSampler {
+	return &RightsizingSampler{
+		clientset: clientset,
+		interval:  interval,
+		window:    window,
+		series:    make(map[string]*usageSeries),
+	}
+}
+
+// Run samples every interval until ctx is cancelled
+func (s *RightsizingSampler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	var lastErr string
+	for {
+		err := s.Sample(ctx)
+		// Log only changes so a missing metrics-server does not flood the log
+		if err != nil && err.Error() != lastErr {
+			log.Printf("Failed to sample container usage: %v", err)
+		}
+		lastErr = ""
+		if err != nil {
+			lastErr = err.Error()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sample fetches current usage and pod specs and records them
+func (s *RightsizingSampler) Sample(ctx context.Context) error {
+	usage, err := FetchContainerUsage(ctx, s.clientset)
+	if err == nil {
+		var pods *corev1.PodList
+		pods, err = s.clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+		if err == nil {
+			s.Record(time.Now(), pods.Items, usage)
+		}
+	}
+
+	s.mu.Lock()
+	s.lastErr = err
+	s.mu.Unlock()
+	return err
+}
+
+// Record adds usage samples taken at now, attributing each container to
+// its pod's workload
+func (s *RightsizingSampler) Record(now time.Time, pods []corev1.Pod, usage []ContainerUsage) {
+	podIndex := make(map[string]*corev1.Pod, len(pods))
+	for i := range pods {
+		podIndex[pods[i].Namespace+"/"+pods[i].Name] = &pods[i]
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	replicas := make(map[string]map[string]bool)
+	for _, u := range usage {
+		pod, ok := podIndex[u.Namespace+"/"+u.Pod]
+		if !ok {
+			continue
+		}
+		kind, workload := podWorkload(pod)
+		key := strings.Join([]string{u.Namespace, kind, workload, u.Container}, "/")
+
+		series, ok := s.series[key]
+		if !ok {
+			series = &usageSeries{namespace: u.Namespace, workloadKind: kind, workload: workload, container: u.Container}
+			s.series[key] = series
+		}
+		for _, container := range pod.Spec.Containers {
+			if container.Name == u.Container {
+				series.cpuRequest = container.Resources.Requests.Cpu().MilliValue()
+				series.cpuLimit = container.Resources.Limits.Cpu().MilliValue()
+				series.memRequest = container.Resources.Requests.Memory().Value()
+				series.memLimit = container.Resources.Limits.Memory().Value()
+			}
+		}
+		series.points = append(series.points, usagePoint{time: now, cpuMilli: u.CPUMilli, memBytes: u.MemoryBytes})
+		series.lastSeen = now
+
+		if replicas[key] == nil {
+			replicas[key] = make(map[string]bool)
+		}
+		replicas[key][u.Pod] = true
+	}
+
+	cutoff := now.Add(-s.window)
+	for key, series := range s.series {
+		if pods, ok := replicas[key]; ok {
+			series.replicas = len(pods)
+		}
+		if series.lastSeen.Before(cutoff) {
+			delete(s.series, key)
+			continue
+		}
+		drop := 0
+		for drop < len(series.points) && series.points[drop].time.Before(cutoff) {
+			drop++
+		}
+		if over := len(series.points) - drop - rightsizingMaxPoints; over > 0 {
+			drop += over
+		}
+		if drop > 0 {
+			series.points = append([]usagePoint(nil), series.points[drop:]...)
+		}
+	}
+	s.lastSample = now
+}
+
+// Report builds recommendations from the samples in the window, optionally
+// limited to one namespace
+func (s *RightsizingSampler) Report(namespace string) *RightsizingReport {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	report := &RightsizingReport{
+		Window:          s.window.String(),
+		LastSample:      s.lastSample,
+		Recommendations: make([]ContainerRecommendation, 0, len(s.series)),
+	}
+	if s.lastErr != nil {
+		report.LastError = s.lastErr.Error()
+	}
+
+	for _, series := range s.series {
+		if namespace != "" && series.namespace != namespace {
+			continue
+		}
+		report.Recommendations = append(report.Recommendations, recommend(series))
+	}
+	sort.Slice(report.Recommendations, func(i, j int) bool {
+		a, b := report.Recommendations[i], report.Recommendations[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Workload != b.Workload {
+			return a.Workload < b.Workload
+		}
+		return a.Container < b.Container
+	})
+
+	report.Workloads, report.Namespaces = rollupRecommendations(report.Recommendations)
+	return report
+}
+
+// distinctSampleTimes counts the sampling rounds in points. Each round adds
+// one point per replica at the same time, and points are in time order.
+func distinctSampleTimes(points []usagePoint) int {
+	count := 0
+	for i, point := range points {
+		if i == 0 || !point.time.Equal(points[i-1].time) {
+			count++
+		}
+	}
+	return count
+}
+
+// recommend computes percentiles and flags a series. CPU is sized from p95
+// since it is compressible; memory from max since running out kills the
+// container.
+func recommend(series *usageSeries) ContainerRecommendation {
+	rec := ContainerRecommendation{
+		Namespace:    series.namespace,
+		WorkloadKind: series.workloadKind,
+		Workload:     series.workload,
+		Container:    series.container,
+		Replicas:     series.replicas,
+		Samples:      distinctSampleTimes(series.points),
+		Reasons:      make([]string, 0),
+	}
+
+	cpu := make([]int64, 0, len(series.points))
+	memory := make([]int64, 0, len(series.points))
+	for _, point := range series.points {
+		cpu = append(cpu, point.cpuMilli)
+		memory = append(memory, point.memBytes)
+	}
+	sort.Slice(cpu, func(i, j int) bool { return cpu[i] < cpu[j] })
+	sort.Slice(memory, func(i, j int) bool { return memory[i] < memory[j] })
+
+	cpuP95, cpuMax := percentile(cpu, 95), percentile(cpu, 100)
+	memP95, memMax := percentile(memory, 95), percentile(memory, 100)
+	cpuRecommended := maxInt64(int64(float64(cpuP95)*rightsizingHeadroom), minCPURecommendation)
+	memRecommended := maxInt64(int64(float64(memMax)*rightsizingHeadroom), minMemRecommendation)
+
+	rec.CPU = ResourceRecommendation{
+		Request:     milliString(series.cpuRequest),
+		Limit:       milliString(series.cpuLimit),
+		P50:         milliString(percentile(cpu, 50)),
+		P95:         milliString(cpuP95),
+		Max:         milliString(cpuMax),
+		Recommended: milliString(cpuRecommended),
+	}
+	rec.Memory = ResourceRecommendation{
+		Request:     bytesString(series.memRequest),
+		Limit:       bytesString(series.memLimit),
+		P50:         bytesString(percentile(memory, 50)),
+		P95:         bytesString(memP95),
+		Max:         bytesString(memMax),
+		Recommended: bytesString(memRecommended),
+	}
+
+	if rec.Samples < rightsizingMinSamples {
+		rec.Status = RightsizingInsufficientData
+		rec.Reasons = append(rec.Reasons, fmt.Sprintf("only %d of %d samples collected", rec.Samples, rightsizingMinSamples))
+		return rec
+	}
+
+	replicas := float64(maxInt64(int64(series.replicas), 1))
+	if series.cpuRequest > 0 {
+		rec.CPU.Savings = round2(float64(series.cpuRequest-cpuRecommended) * replicas / 1000)
+	}
+	if series.memRequest > 0 {
+		rec.Memory.Savings = round2(float64(series.memRequest-memRecommended) * replicas / (1 << 30))
+	}
+
+	under := make([]string, 0)
+	over := make([]string, 0)
+	switch {
+	case series.cpuRequest == 0:
+		under = append(under, "no CPU request set")
+	case cpuP95 > series.cpuRequest:
+		under = append(under, fmt.Sprintf("CPU p95 %s is above the request %s", rec.CPU.P95, rec.CPU.Request))
+	case float64(series.cpuRequest) > float64(cpuRecommended)*rightsizingOverFactor && series.cpuRequest-cpuRecommended >= 50:
+		over = append(over, fmt.Sprintf("CPU request %s could be %s", rec.CPU.Request, rec.CPU.Recommended))
+	}
+	if series.cpuLimit > 0 && float64(cpuP95) > float64(series.cpuLimit)*0.9 {
+		under = append(under, fmt.Sprintf("CPU p95 %s is close to the limit %s, expect throttling", rec.CPU.P95, rec.CPU.Limit))
+	}
+	switch {
+	case series.memRequest == 0:
+		under = append(under, "no memory request set")
+	case memMax > series.memRequest:
+		under = append(under, fmt.Sprintf("memory max %s is above the request %s", rec.Memory.Max, rec.Memory.Request))
+	case float64(series.memRequest) > float64(memRecommended)*rightsizingOverFactor && series.memRequest-memRecommended >= 64<<20:
+		over = append(over, fmt.Sprintf("memory request %s could be %s", rec.Memory.Request, rec.Memory.Recommended))
+	}
+	if series.memLimit > 0 && float64(memMax) > float64(series.memLimit)*0.9 {
+		under = append(under, fmt.Sprintf("memory max %s is within 10%% of the limit %s, risk of OOMKill", rec.Memory.Max, rec.Memory.Limit))
+	}
+
+	switch {
+	case len(under) > 0:
+		rec.Status = RightsizingUnderProvisioned
+	case len(over) > 0:
+		rec.Status = RightsizingOverProvisioned
+	default:
+		rec.Status = RightsizingOK
+	}
+	rec.Reasons = append(under, over...)
+	return rec
+}
+
+func rollupRecommendations(recs []ContainerRecommendation) ([]RightsizingRollup, []RightsizingRollup) {
+	workloads := make(map[string]*RightsizingRollup)
+	namespaces := make(map[string]*RightsizingRollup)
+	workloadOrder := make([]string, 0)
+	namespaceOrder := make([]string, 0)
+
+	for _, rec := range recs {
+		workloadKey := rec.Namespace + "/" + rec.WorkloadKind + "/" + rec.Workload
+		if _, ok := workloads[workloadKey]; !ok {
+			workloads[workloadKey] = &RightsizingRollup{Namespace: rec.Namespace, WorkloadKind: rec.WorkloadKind, Workload: rec.Workload}
+			workloadOrder = append(workloadOrder, workloadKey)
+		}
+		if _, ok := namespaces[rec.Namespace]; !ok {
+			namespaces[rec.Namespace] = &RightsizingRollup{Namespace: rec.Namespace}
+			namespaceOrder = append(namespaceOrder, rec.Namespace)
+		}
+		for _, rollup := range []*RightsizingRollup{workloads[workloadKey], namespaces[rec.Namespace]} {
+			rollup.Containers++
+			switch rec.Status {
+			case RightsizingOverProvisioned:
+				rollup.OverProvisioned++
+			case RightsizingUnderProvisioned:
+				rollup.UnderProvisioned++
+			}
+			if rec.CPU.Savings > 0 {
+				rollup.CPUSavingsCores = round2(rollup.CPUSavingsCores + rec.CPU.Savings)
+			}
+			if rec.Memory.Savings > 0 {
+				rollup.MemorySavingsGiB = round2(rollup.MemorySavingsGiB + rec.Memory.Savings)
+			}
+		}
+	}
+
+	workloadRollups := make([]RightsizingRollup, 0, len(workloadOrder))
+	for _, key := range workloadOrder {
+		workloadRollups = append(workloadRollups, *workloads[key])
+	}
+	namespaceRollups := make([]RightsizingRollup, 0, len(namespaceOrder))
+	for _, key := range namespaceOrder {
+		namespaceRollups = append(namespaceRollups, *namespaces[key])
+	}
+	return workloadRollups, namespaceRollups
+}
+
+// WriteRightsizingCSV writes one row per container recommendation
+func WriteRightsizingCSV(w io.Writer, report *RightsizingReport) error {
+	writer := csv.NewWriter(w)
+	header := []string{
+		"namespace", "workload_kind", "workload", "container", "replicas", "samples", "status",
+		"cpu_request", "cpu_limit", "cpu_p50", "cpu_p95", "cpu_max", "cpu_recommended", "cpu_savings_cores",
+		"memory_request", "memory_limit", "memory_p50", "memory_p95", "memory_max", "memory_recommended", "memory_savings_gib",
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, rec := range report.Recommendations {
+		row := []string{
+			rec.Namespace, rec.WorkloadKind, rec.Workload, rec.Container, strconv.Itoa(rec.Replicas), strconv.Itoa(rec.Samples), rec.Status,
+			rec.CPU.Request, rec.CPU.Limit, rec.CPU.P50, rec.CPU.P95, rec.CPU.Max, rec.CPU.Recommended, strconv.FormatFloat(rec.CPU.Savings, 'f', 2, 64),
+			rec.Memory.Request, rec.Memory.Limit, rec.Memory.P50, rec.Memory.P95, rec.Memory.Max, rec.Memory.Recommended, strconv.FormatFloat(rec.Memory.Savings, 'f', 2, 64),
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// podWorkload returns the workload that owns a pod. Pods of a ReplicaSet
+// created by a Deployment are attributed to the Deployment.
+func podWorkload(pod *corev1.Pod) (string, string) {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return "Pod", pod.Name
+	}
+	if hash := pod.Labels["pod-template-hash"]; owner.Kind == "ReplicaSet" && hash != "" && strings.HasSuffix(owner.Name, "-"+hash) {
+		return "Deployment", strings.TrimSuffix(owner.Name, "-"+hash)
+	}
+	return owner.Kind, owner.Name
+}
+
+// percentile returns the nearest-rank percentile of sorted values
+func percentile(sorted []int64, p int) int64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func milliString(milli int64) string {
+	return resource.NewMilliQuantity(milli, resource.DecimalSI).String()
+}
+
+func bytesString(bytes int64) string {
+	if bytes == 0 {
+		return "0"
+	}
+	// Round to MiB so recommendations read like values people set
+	mib := (bytes + (1 << 20) - 1) >> 20
+	return strconv.FormatInt(mib, 10) + "Mi"
+}
+
+func maxInt64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParsePodMetrics(t *testing.T) {
+	raw := []byte(`{"items":[{"metadata":{"name":"web-1","namespace":"default"},
+		"containers":[{"name":"web","usage":{"cpu":"12500000n","memory":"64Mi"}}]}]}`)
+	usage, err := parsePodMetrics(raw)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if len(usage) != 1 || usage[0].CPUMilli != 13 || usage[0].MemoryBytes != 64<<20 {
+		t.Fatalf("unexpected usage: %+v", usage)
+	}
+}
+
+func TestRightsizingSampler_Recommendations(t *testing.T) {
+	controller := true
+	pod := func(name string) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name, Namespace: "shop",
+				Labels:          map[string]string{"pod-template-hash": "5d8f7"},
+				OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "api-5d8f7", Controller: &controller}},
+			},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name: "api",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("128Mi")},
+					Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("256Mi")},
+				},
+			}}},
+		}
+	}
+	pods := []corev1.Pod{pod("api-5d8f7-a"), pod("api-5d8f7-b")}
+
+	sampler := NewRightsizingSampler(nil, time.Minute, time.Hour)
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 20; i++ {
+		sampler.Record(start.Add(time.Duration(i)*time.Minute), pods, []ContainerUsage{
+			{Namespace: "shop", Pod: "api-5d8f7-a", Container: "api", CPUMilli: 100, MemoryBytes: 200 << 20},
+			{Namespace: "shop", Pod: "api-5d8f7-b", Container: "api", CPUMilli: 120, MemoryBytes: 180 << 20},
+		})
+	}
+
+	report := sampler.Report("")
+	if len(report.Recommendations) != 1 {
+		t.Fatalf("expected one recommendation for the deployment container, got %+v", report.Recommendations)
+	}
+	rec := report.Recommendations[0]
+	if rec.WorkloadKind != "Deployment" || rec.Workload != "api" || rec.Replicas != 2 || rec.Samples != 20 {
+		t.Fatalf("unexpected attribution: %+v", rec)
+	}
+	if rec.CPU.P95 != "120m" || rec.CPU.Recommended != "138m" || rec.CPU.Savings != 1.72 {
+		t.Fatalf("unexpected cpu recommendation: %+v", rec.CPU)
+	}
+	// Memory max is above the request, so the container is under-provisioned
+	// even though CPU is far over
+	if rec.Status != RightsizingUnderProvisioned || rec.Memory.Max != "200Mi" {
+		t.Fatalf("expected under-provisioned memory, got %s %+v", rec.Status, rec.Memory)
+	}
+
+	if len(report.Namespaces) != 1 || report.Namespaces[0].CPUSavingsCores != 1.72 || report.Namespaces[0].MemorySavingsGiB != 0 {
+		t.Fatalf("unexpected namespace rollup: %+v", report.Namespaces)
+	}
+	if other := sampler.Report("other"); len(other.Recommendations) != 0 {
+		t.Fatalf("expected namespace filter to exclude shop")
+	}
+
+	var buf bytes.Buffer
+	if err := WriteRightsizingCSV(&buf, report); err != nil {
+		t.Fatalf("csv failed: %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil || len(rows) != 2 || rows[1][2] != "api" || rows[1][13] != "1.72" {
+		t.Fatalf("unexpected csv: %v %v", rows, err)
+	}
+
+	// Samples age out of the window
+	sampler.Record(start.Add(3*time.Hour), pods, nil)
+	if report := sampler.Report(""); len(report.Recommendations) != 0 {
+		t.Fatalf("expected stale series to be dropped, got %+v", report.Recommendations)
+	}
+
+	// Replicas do not count as extra samples: 6 rounds of 2 pods is still
+	// short of rightsizingMinSamples
+	fresh := start.Add(4 * time.Hour)
+	for i := 0; i < 6; i++ {
+		sampler.Record(fresh.Add(time.Duration(i)*time.Minute), pods, []ContainerUsage{
+			{Namespace: "shop", Pod: "api-5d8f7-a", Container: "api", CPUMilli: 100, MemoryBytes: 100 << 20},
+			{Namespace: "shop", Pod: "api-5d8f7-b", Container: "api", CPUMilli: 100, MemoryBytes: 100 << 20},
+		})
+	}
+	report = sampler.Report("")
+	if len(report.Recommendations) != 1 || report.Recommendations[0].Samples != 6 || report.Recommendations[0].Status != RightsizingInsufficientData {
+		t.Fatalf("expected 6 samples and insufficient data, got %+v", report.Recommendations)
+	}
+}
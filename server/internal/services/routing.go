@@ -0,0 +1,569 @@
+// internal/services/routing.go
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ErrGatewayAPIUnavailable is returned when HTTPRoutes cannot be listed
+// because the Gateway API CRDs are not installed or the API is unreachable
+var ErrGatewayAPIUnavailable = errors.New("gateway.networking.k8s.io API is not available")
+
+// gatewayAPIVersions are tried in order when listing Gateway API resources
+var gatewayAPIVersions = []string{"v1", "v1beta1"}
+
+// gatewayAPIGroup is the API group of HTTPRoutes and ReferenceGrants
+const gatewayAPIGroup = "gateway.networking.k8s.io"
+
+// Backend problems
+const (
+	BackendServiceNotFound = "service not found"
+	BackendPortNotFound    = "service port not found"
+	BackendNoEndpoints     = "no ready endpoints"
+	BackendUnsupported     = "unsupported backend kind"
+	BackendRefNotPermitted = "cross-namespace reference not permitted by a ReferenceGrant"
+)
+
+// RoutePod is a pod behind a backend endpoint
+type RoutePod struct {
+	Name  string `json:"name"`
+	Node  string `json:"node,omitempty"`
+	IP    string `json:"ip"`
+	Ready bool   `json:"ready"`
+}
+
+// RouteBackend is a Service a route sends traffic to and the pods behind it
+type RouteBackend struct {
+	Kind              string     `json:"kind"`
+	Service           string     `json:"service"`
+	Namespace         string     `json:"namespace"`
+	Port              string     `json:"port,omitempty"`
+	Weight            *int32     `json:"weight,omitempty"`
+	ExternalName      string     `json:"external_name,omitempty"`
+	ReadyEndpoints    int        `json:"ready_endpoints"`
+	NotReadyEndpoints int        `json:"not_ready_endpoints"`
+	Pods              []RoutePod `json:"pods"`
+	Problem           string     `json:"problem,omitempty"`
+}
+
+// RoutePath is one host and path match of a route
+type RoutePath struct {
+	Host     string         `json:"host"`
+	Path     string         `json:"path"`
+	PathType string         `json:"path_type,omitempty"`
+	URL      string         `json:"url"`
+	Backends []RouteBackend `json:"backends"`
+	Broken   bool           `json:"broken"`
+}
+
+// Route is an Ingress or HTTPRoute resolved down to pods
+type Route struct {
+	Kind      string      `json:"kind"`
+	Name      string      `json:"name"`
+	Namespace string      `json:"namespace"`
+	Class     string      `json:"class,omitempty"`   // Ingress class
+	Parents   []string    `json:"parents,omitempty"` // Gateways an HTTPRoute attaches to
+	Paths     []RoutePath `json:"paths"`
+	Broken    bool        `json:"broken"`
+}
+
+// backendResolver resolves Service backends against services and
+// EndpointSlices listed once per request
+type backendResolver struct {
+	services map[string]*corev1.Service
+	slices   map[string][]*discoveryv1.EndpointSlice // By namespace/service
+}
+
+// newBackendResolver lists services and EndpointSlices in each of
+// namespaces; "" lists every namespace
+func newBackendResolver(ctx context.Context, clientset kubernetes.Interface, namespaces []string) (*backendResolver, error) {
+	var services []corev1.Service
+	var slices []discoveryv1.EndpointSlice
+	for _, namespace := range namespaces {
+		serviceList, err := clientset.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list services: %w", err)
+		}
+		sliceList, err := clientset.DiscoveryV1().EndpointSlices(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list endpoint slices: %w", err)
+		}
+		services = append(services, serviceList.Items...)
+		slices = append(slices, sliceList.Items...)
+	}
+	return buildBackendResolver(services, slices), nil
+}
+
+func buildBackendResolver(services []corev1.Service, slices []discoveryv1.EndpointSlice) *backendResolver {
+	r := &backendResolver{
+		services: make(map[string]*corev1.Service, len(services)),
+		slices:   make(map[string][]*discoveryv1.EndpointSlice),
+	}
+	for i := range services {
+		r.services[services[i].Namespace+"/"+services[i].Name] = &services[i]
+	}
+	for i := range slices {
+		slice := &slices[i]
+		if service := slice.Labels[discoveryv1.LabelServiceName]; service != "" {
+			key := slice.Namespace + "/" + service
+			r.slices[key] = append(r.slices[key], slice)
+		}
+	}
+	return r
+}
+
+// resolve finds the service port and the endpoints serving it. port is a
+// port number or name; empty means every port.
+func (r *backendResolver) resolve(namespace, name, port string) RouteBackend {
+	backend := RouteBackend{Kind: "Service", Service: name, Namespace: namespace, Port: port, Pods: make([]RoutePod, 0)}
+
+	service, ok := r.services[namespace+"/"+name]
+	if !ok {
+		backend.Problem = BackendServiceNotFound
+		return backend
+	}
+	if service.Spec.Type == corev1.ServiceTypeExternalName {
+		backend.ExternalName = service.Spec.ExternalName
+		return backend
+	}
+
+	portName, found := "", port == ""
+	for _, servicePort := range service.Spec.Ports {
+		if port == servicePort.Name || port == strconv.Itoa(int(servicePort.Port)) {
+			portName, found = servicePort.Name, true
+			break
+		}
+	}
+	if !found {
+		backend.Problem = BackendPortNotFound
+		return backend
+	}
+
+	seen := make(map[string]bool)
+	for _, slice := range r.slices[namespace+"/"+name] {
+		if port != "" && !slicePortMatches(slice, portName) {
+			continue
+		}
+		for _, endpoint := range slice.Endpoints {
+			ready := endpoint.Conditions.Ready == nil || *endpoint.Conditions.Ready
+			for _, address := range endpoint.Addresses {
+				if seen[address] {
+					continue
+				}
+				seen[address] = true
+				if ready {
+					backend.ReadyEndpoints++
+				} else {
+					backend.NotReadyEndpoints++
+				}
+				pod := RoutePod{IP: address, Ready: ready}
+				if endpoint.TargetRef != nil && endpoint.TargetRef.Kind == "Pod" {
+					pod.Name = endpoint.TargetRef.Name
+				}
+				if endpoint.NodeName != nil {
+					pod.Node = *endpoint.NodeName
+				}
+				backend.Pods = append(backend.Pods, pod)
+			}
+		}
+	}
+	sort.Slice(backend.Pods, func(i, j int) bool { return backend.Pods[i].Name < backend.Pods[j].Name })
+
+	if backend.ReadyEndpoints == 0 {
+		backend.Problem = BackendNoEndpoints
+	}
+	return backend
+}
+
+// slicePortMatches reports whether an EndpointSlice serves the named service
+// port. Slices name their ports after the service port.
+func slicePortMatches(slice *discoveryv1.EndpointSlice, portName string) bool {
+	if len(slice.Ports) == 0 {
+		return true
+	}
+	for _, port := range slice.Ports {
+		if port.Name != nil && *port.Name == portName {
+			return true
+		}
+	}
+	return false
+}
+
+// GetIngressRoutes lists Ingresses in namespace (all when empty) and
+// resolves each rule to the pods that serve it
+func GetIngressRoutes(ctx context.Context, clientset kubernetes.Interface, namespace string) ([]Route, error) {
+	ingresses, err := clientset.NetworkingV1().Ingresses(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ingresses: %w", err)
+	}
+	resolver, err := newBackendResolver(ctx, clientset, []string{namespace})
+	if err != nil {
+		return nil, err
+	}
+	return BuildIngressRoutes(ingresses.Items, resolver), nil
+}
+
+// BuildIngressRoutes resolves Ingress rules and default backends
+func BuildIngressRoutes(ingresses []networkingv1.Ingress, resolver *backendResolver) []Route {
+	routes := make([]Route, 0, len(ingresses))
+	for i := range ingresses {
+		ingress := &ingresses[i]
+		route := Route{Kind: "Ingress", Name: ingress.Name, Namespace: ingress.Namespace, Paths: make([]RoutePath, 0)}
+		if ingress.Spec.IngressClassName != nil {
+			route.Class = *ingress.Spec.IngressClassName
+		}
+
+		tlsHosts := make(map[string]bool)
+		for _, tls := range ingress.Spec.TLS {
+			for _, host := range tls.Hosts {
+				tlsHosts[host] = true
+			}
+		}
+
+		addPath := func(host, path, pathType string, backend networkingv1.IngressBackend) {
+			routePath := RoutePath{Host: host, Path: path, PathType: pathType, URL: routeURL(host, path, tlsHosts[host])}
+			routePath.Backends = []RouteBackend{resolveIngressBackend(resolver, ingress.Namespace, backend)}
+			routePath.Broken = routePath.Backends[0].Problem != ""
+			route.Broken = route.Broken || routePath.Broken
+			route.Paths = append(route.Paths, routePath)
+		}
+
+		if ingress.Spec.DefaultBackend != nil {
+			addPath("*", "/", "", *ingress.Spec.DefaultBackend)
+		}
+		for _, rule := range ingress.Spec.Rules {
+			host := rule.Host
+			if host == "" {
+				host = "*"
+			}
+			if rule.HTTP == nil {
+				continue
+			}
+			for _, path := range rule.HTTP.Paths {
+				pathType := ""
+				if path.PathType != nil {
+					pathType = string(*path.PathType)
+				}
+				value := path.Path
+				if value == "" {
+					value = "/"
+				}
+				addPath(host, value, pathType, path.Backend)
+			}
+		}
+		routes = append(routes, route)
+	}
+	sortRoutes(routes)
+	return routes
+}
+
+func resolveIngressBackend(resolver *backendResolver, namespace string, backend networkingv1.IngressBackend) RouteBackend {
+	if backend.Service == nil {
+		kind := "Resource"
+		if backend.Resource != nil {
+			kind = backend.Resource.Kind
+		}
+		return RouteBackend{Kind: kind, Namespace: namespace, Pods: make([]RoutePod, 0), Problem: BackendUnsupported}
+	}
+
+	port := backend.Service.Port.Name
+	if backend.Service.Port.Number != 0 {
+		port = strconv.Itoa(int(backend.Service.Port.Number))
+	}
+	return resolver.resolve(namespace, backend.Service.Name, port)
+}
+
+// httpRouteList mirrors the parts of gateway.networking.k8s.io HTTPRouteList
+// we read, so the Gateway API client library is not needed
+type httpRouteList struct {
+	Items []struct {
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		Spec struct {
+			ParentRefs []struct {
+				Name        string  `json:"name"`
+				Namespace   *string `json:"namespace"`
+				SectionName *string `json:"sectionName"`
+			} `json:"parentRefs"`
+			Hostnames []string `json:"hostnames"`
+			Rules     []struct {
+				Matches []struct {
+					Path *struct {
+						Type  string `json:"type"`
+						Value string `json:"value"`
+					} `json:"path"`
+				} `json:"matches"`
+				BackendRefs []httpBackendRef `json:"backendRefs"`
+			} `json:"rules"`
+		} `json:"spec"`
+	} `json:"items"`
+}
+
+// httpBackendRef is one backendRef of an HTTPRoute rule
+type httpBackendRef struct {
+	Group     *string `json:"group"`
+	Kind      *string `json:"kind"`
+	Name      string  `json:"name"`
+	Namespace *string `json:"namespace"`
+	Port      *int32  `json:"port"`
+	Weight    *int32  `json:"weight"`
+}
+
+// isService reports whether the ref is a core Service, the default kind
+func (r httpBackendRef) isService() bool {
+	return (r.Kind == nil || *r.Kind == "Service") && (r.Group == nil || *r.Group == "")
+}
+
+// namespace is the ref's namespace, defaulting to the route's
+func (r httpBackendRef) namespace(routeNamespace string) string {
+	if r.Namespace != nil {
+		return *r.Namespace
+	}
+	return routeNamespace
+}
+
+// referenceGrantList mirrors the parts of gateway.networking.k8s.io
+// ReferenceGrantList we read
+type referenceGrantList struct {
+	Items []struct {
+		Metadata struct {
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		Spec struct {
+			From []struct {
+				Group     string `json:"group"`
+				Kind      string `json:"kind"`
+				Namespace string `json:"namespace"`
+			} `json:"from"`
+			To []struct {
+				Group string  `json:"group"`
+				Kind  string  `json:"kind"`
+				Name  *string `json:"name"`
+			} `json:"to"`
+		} `json:"spec"`
+	} `json:"items"`
+}
+
+// allowsService reports whether a ReferenceGrant in toNamespace lets
+// HTTPRoutes in fromNamespace reference the named Service
+func (l referenceGrantList) allowsService(fromNamespace, toNamespace, service string) bool {
+	for _, grant := range l.Items {
+		if grant.Metadata.Namespace != toNamespace {
+			continue
+		}
+		fromAllowed := false
+		for _, from := range grant.Spec.From {
+			if from.Group == gatewayAPIGroup && from.Kind == "HTTPRoute" && from.Namespace == fromNamespace {
+				fromAllowed = true
+			}
+		}
+		if !fromAllowed {
+			continue
+		}
+		for _, to := range grant.Spec.To {
+			if to.Group == "" && to.Kind == "Service" && (to.Name == nil || *to.Name == "" || *to.Name == service) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// listGatewayResource gets a Gateway API list, trying each version in
+// gatewayAPIVersions. It returns ErrGatewayAPIUnavailable when the resource
+// is not served.
+func listGatewayResource(ctx context.Context, clientset kubernetes.Interface, resource, namespace string, into interface{}) error {
+	restClient := clientset.Discovery().RESTClient()
+	if restClient == nil {
+		return ErrGatewayAPIUnavailable
+	}
+
+	var raw []byte
+	var err error
+	for _, version := range gatewayAPIVersions {
+		path := "/apis/" + gatewayAPIGroup + "/" + version + "/" + resource
+		if namespace != "" {
+			path = "/apis/" + gatewayAPIGroup + "/" + version + "/namespaces/" + namespace + "/" + resource
+		}
+		raw, err = restClient.Get().AbsPath(path).Do(ctx).Raw()
+		if !apierrors.IsNotFound(err) {
+			break
+		}
+	}
+	if apierrors.IsNotFound(err) {
+		return ErrGatewayAPIUnavailable
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list %s: %w", resource, err)
+	}
+	if err := json.Unmarshal(raw, into); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", resource, err)
+	}
+	return nil
+}
+
+// GetHTTPRoutes lists Gateway API HTTPRoutes in namespace (all when empty)
+// and resolves each rule to the pods that serve it. It returns
+// ErrGatewayAPIUnavailable when the CRDs are not installed.
+func GetHTTPRoutes(ctx context.Context, clientset kubernetes.Interface, namespace string) ([]Route, error) {
+	var list httpRouteList
+	if err := listGatewayResource(ctx, clientset, "httproutes", namespace, &list); err != nil {
+		return nil, err
+	}
+
+	// Cross-namespace backends need a ReferenceGrant in the backend's
+	// namespace; without the ReferenceGrant CRD none are permitted
+	routeNamespaces := make(map[string]bool)
+	targetNamespaces := make(map[string]bool)
+	for _, item := range list.Items {
+		routeNamespaces[item.Metadata.Namespace] = true
+		for _, rule := range item.Spec.Rules {
+			for _, ref := range rule.BackendRefs {
+				if backendNamespace := ref.namespace(item.Metadata.Namespace); ref.isService() && backendNamespace != item.Metadata.Namespace {
+					targetNamespaces[backendNamespace] = true
+				}
+			}
+		}
+	}
+	var grants referenceGrantList
+	for target := range targetNamespaces {
+		var namespaceGrants referenceGrantList
+		err := listGatewayResource(ctx, clientset, "referencegrants", target, &namespaceGrants)
+		if errors.Is(err, ErrGatewayAPIUnavailable) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		grants.Items = append(grants.Items, namespaceGrants.Items...)
+	}
+
+	// Only the namespaces the routes may reach are listed
+	reachable := routeNamespaces
+	for _, grant := range grants.Items {
+		reachable[grant.Metadata.Namespace] = true
+	}
+	resolver, err := newBackendResolver(ctx, clientset, setKeys(reachable))
+	if err != nil {
+		return nil, err
+	}
+	return buildHTTPRoutes(list, grants, resolver), nil
+}
+
+func buildHTTPRoutes(list httpRouteList, grants referenceGrantList, resolver *backendResolver) []Route {
+	routes := make([]Route, 0, len(list.Items))
+	for _, item := range list.Items {
+		namespace := item.Metadata.Namespace
+		route := Route{Kind: "HTTPRoute", Name: item.Metadata.Name, Namespace: namespace, Paths: make([]RoutePath, 0)}
+
+		for _, parent := range item.Spec.ParentRefs {
+			name := namespace + "/" + parent.Name
+			if parent.Namespace != nil {
+				name = *parent.Namespace + "/" + parent.Name
+			}
+			if parent.SectionName != nil {
+				name += "/" + *parent.SectionName
+			}
+			route.Parents = append(route.Parents, name)
+		}
+
+		hosts := item.Spec.Hostnames
+		if len(hosts) == 0 {
+			hosts = []string{"*"}
+		}
+		for _, rule := range item.Spec.Rules {
+			backends := make([]RouteBackend, 0, len(rule.BackendRefs))
+			for _, ref := range rule.BackendRefs {
+				backendNamespace := ref.namespace(namespace)
+				var backend RouteBackend
+				switch {
+				case !ref.isService():
+					kind := "Service"
+					if ref.Kind != nil {
+						kind = *ref.Kind
+					}
+					backend = RouteBackend{Kind: kind, Service: ref.Name, Namespace: backendNamespace, Pods: make([]RoutePod, 0), Problem: BackendUnsupported}
+				case backendNamespace != namespace && !grants.allowsService(namespace, backendNamespace, ref.Name):
+					backend = RouteBackend{Kind: "Service", Service: ref.Name, Namespace: backendNamespace, Pods: make([]RoutePod, 0), Problem: BackendRefNotPermitted}
+				default:
+					port := ""
+					if ref.Port != nil {
+						port = strconv.Itoa(int(*ref.Port))
+					}
+					backend = resolver.resolve(backendNamespace, ref.Name, port)
+				}
+				backend.Weight = ref.Weight
+				backends = append(backends, backend)
+			}
+
+			// A rule without matches matches every path
+			type pathMatch struct{ value, kind string }
+			matches := []pathMatch{{"/", "PathPrefix"}}
+			if len(rule.Matches) > 0 {
+				matches = matches[:0]
+				for _, match := range rule.Matches {
+					if match.Path != nil {
+						matches = append(matches, pathMatch{match.Path.Value, match.Path.Type})
+					} else {
+						matches = append(matches, pathMatch{"/", "PathPrefix"})
+					}
+				}
+			}
+
+			for _, host := range hosts {
+				for _, match := range matches {
+					routePath := RoutePath{Host: host, Path: match.value, PathType: match.kind, URL: routeURL(host, match.value, false), Backends: backends}
+					routePath.Broken = routeBroken(backends)
+					route.Broken = route.Broken || routePath.Broken
+					route.Paths = append(route.Paths, routePath)
+				}
+			}
+		}
+		routes = append(routes, route)
+	}
+	sortRoutes(routes)
+	return routes
+}
+
+// routeBroken reports whether no backend can serve traffic
+func routeBroken(backends []RouteBackend) bool {
+	for _, backend := range backends {
+		if backend.Problem == "" && (backend.Weight == nil || *backend.Weight > 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// routeURL renders the external URL for a host and path. Gateway listener
+// protocols are not resolved, so HTTPRoute URLs always use http.
+func routeURL(host, path string, tls bool) string {
+	scheme := "http"
+	if tls {
+		scheme = "https"
+	}
+	return scheme + "://" + host + path
+}
+
+func sortRoutes(routes []Route) {
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Namespace != routes[j].Namespace {
+			return routes[i].Namespace < routes[j].Namespace
+		}
+		return routes[i].Name < routes[j].Name
+	})
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func routingFixtures() []runtime.Object {
+	ready, notReady := true, false
+	node := "node-1"
+	portName := "http"
+	return []runtime.Object{
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+			Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "http", Port: 80}}},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "idle", Namespace: "shop"},
+			Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "http", Port: 80}}},
+		},
+		&discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-abc", Namespace: "shop", Labels: map[string]string{discoveryv1.LabelServiceName: "web"}},
+			Ports:      []discoveryv1.EndpointPort{{Name: &portName}},
+			Endpoints: []discoveryv1.Endpoint{
+				{Addresses: []string{"10.0.0.1"}, Conditions: discoveryv1.EndpointConditions{Ready: &ready}, NodeName: &node, TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "web-1"}},
+				{Addresses: []string{"10.0.0.2"}, Conditions: discoveryv1.EndpointConditions{Ready: &notReady}, TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "web-2"}},
+			},
+		},
+		&discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{Name: "idle-abc", Namespace: "shop", Labels: map[string]string{discoveryv1.LabelServiceName: "idle"}},
+			Ports:      []discoveryv1.EndpointPort{{Name: &portName}},
+			Endpoints:  []discoveryv1.Endpoint{{Addresses: []string{"10.0.0.3"}, Conditions: discoveryv1.EndpointConditions{Ready: &notReady}}},
+		},
+	}
+}
+
+func TestGetIngressRoutes(t *testing.T) {
+	prefix := networkingv1.PathTypePrefix
+	class := "nginx"
+	service := func(name string, port networkingv1.ServiceBackendPort) networkingv1.IngressBackend {
+		return networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{Name: name, Port: port}}
+	}
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "shop"},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: &class,
+			TLS:              []networkingv1.IngressTLS{{Hosts: []string{"shop.example.com"}}},
+			Rules: []networkingv1.IngressRule{{
+				Host: "shop.example.com",
+				IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{Paths: []networkingv1.HTTPIngressPath{
+					{Path: "/", PathType: &prefix, Backend: service("web", networkingv1.ServiceBackendPort{Name: "http"})},
+					{Path: "/idle", PathType: &prefix, Backend: service("idle", networkingv1.ServiceBackendPort{Number: 80})},
+					{Path: "/gone", PathType: &prefix, Backend: service("gone", networkingv1.ServiceBackendPort{Number: 80})},
+					{Path: "/port", PathType: &prefix, Backend: service("web", networkingv1.ServiceBackendPort{Number: 8080})},
+				}}},
+			}},
+		},
+	}
+
+	objects := append(routingFixtures(), ingress)
+	clientset := fake.NewSimpleClientset(objects...)
+	routes, err := GetIngressRoutes(context.Background(), clientset, "shop")
+	if err != nil {
+		t.Fatalf("GetIngressRoutes failed: %v", err)
+	}
+	if len(routes) != 1 || routes[0].Class != "nginx" || !routes[0].Broken || len(routes[0].Paths) != 4 {
+		t.Fatalf("unexpected routes %+v", routes)
+	}
+
+	paths := routes[0].Paths
+	web := paths[0].Backends[0]
+	if paths[0].Broken || paths[0].URL != "https://shop.example.com/" || web.ReadyEndpoints != 1 || web.NotReadyEndpoints != 1 {
+		t.Errorf("unexpected web path %+v", paths[0])
+	}
+	if len(web.Pods) != 2 || web.Pods[0].Name != "web-1" || web.Pods[0].Node != "node-1" || !web.Pods[0].Ready {
+		t.Errorf("unexpected web pods %+v", web.Pods)
+	}
+	for i, want := range []string{BackendNoEndpoints, BackendServiceNotFound, BackendPortNotFound} {
+		path := paths[i+1]
+		if !path.Broken || path.Backends[0].Problem != want {
+			t.Errorf("%s: expected problem %q, got %+v", path.Path, want, path.Backends[0])
+		}
+	}
+}
+
+func TestBuildHTTPRoutes(t *testing.T) {
+	raw := `{"items": [{
+	  "metadata": {"name": "shop", "namespace": "shop"},
+	  "spec": {
+	    "parentRefs": [{"name": "public", "namespace": "gateways", "sectionName": "https"}],
+	    "hostnames": ["shop.example.com"],
+	    "rules": [
+	      {"matches": [{"path": {"type": "PathPrefix", "value": "/api"}}],
+	       "backendRefs": [{"name": "web", "port": 80, "weight": 90}, {"name": "idle", "port": 80, "weight": 10}]},
+	      {"backendRefs": [{"name": "gone", "port": 80}]},
+	      {"matches": [{"path": {"type": "Exact", "value": "/bucket"}}],
+	       "backendRefs": [{"group": "storage.example.com", "kind": "Bucket", "name": "assets"}]}
+	    ]
+	  }
+	}]}`
+	var list httpRouteList
+	if err := json.Unmarshal([]byte(raw), &list); err != nil {
+		t.Fatal(err)
+	}
+
+	var services []corev1.Service
+	var slices []discoveryv1.EndpointSlice
+	for _, object := range routingFixtures() {
+		switch o := object.(type) {
+		case *corev1.Service:
+			services = append(services, *o)
+		case *discoveryv1.EndpointSlice:
+			slices = append(slices, *o)
+		}
+	}
+
+	routes := buildHTTPRoutes(list, referenceGrantList{}, buildBackendResolver(services, slices))
+	if len(routes) != 1 || len(routes[0].Paths) != 3 || !routes[0].Broken {
+		t.Fatalf("unexpected routes %+v", routes)
+	}
+	if len(routes[0].Parents) != 1 || routes[0].Parents[0] != "gateways/public/https" {
+		t.Errorf("unexpected parents %v", routes[0].Parents)
+	}
+
+	api, fallback, bucket := routes[0].Paths[0], routes[0].Paths[1], routes[0].Paths[2]
+	if api.Broken || api.URL != "http://shop.example.com/api" || len(api.Backends) != 2 || *api.Backends[0].Weight != 90 || api.Backends[1].Problem != BackendNoEndpoints {
+		t.Errorf("unexpected api path %+v", api)
+	}
+	if !fallback.Broken || fallback.Path != "/" || fallback.Backends[0].Problem != BackendServiceNotFound {
+		t.Errorf("unexpected fallback path %+v", fallback)
+	}
+	if !bucket.Broken || bucket.Backends[0].Kind != "Bucket" || bucket.Backends[0].Problem != BackendUnsupported {
+		t.Errorf("unexpected bucket path %+v", bucket)
+	}
+}
+
+func TestBuildHTTPRoutes_CrossNamespaceNeedsReferenceGrant(t *testing.T) {
+	raw := `{"items": [{
+	  "metadata": {"name": "storefront", "namespace": "frontend"},
+	  "spec": {
+	    "rules": [{"backendRefs": [
+	      {"name": "web", "namespace": "shop", "port": 80},
+	      {"name": "idle", "namespace": "shop", "port": 80}
+	    ]}]
+	  }
+	}]}`
+	var list httpRouteList
+	if err := json.Unmarshal([]byte(raw), &list); err != nil {
+		t.Fatal(err)
+	}
+	var grants referenceGrantList
+	if err := json.Unmarshal([]byte(`{"items": [{
+	  "metadata": {"name": "frontend-web", "namespace": "shop"},
+	  "spec": {
+	    "from": [{"group": "gateway.networking.k8s.io", "kind": "HTTPRoute", "namespace": "frontend"}],
+	    "to": [{"group": "", "kind": "Service", "name": "web"}]
+	  }
+	}]}`), &grants); err != nil {
+		t.Fatal(err)
+	}
+
+	var services []corev1.Service
+	var slices []discoveryv1.EndpointSlice
+	for _, object := range routingFixtures() {
+		switch o := object.(type) {
+		case *corev1.Service:
+			services = append(services, *o)
+		case *discoveryv1.EndpointSlice:
+			slices = append(slices, *o)
+		}
+	}
+	resolver := buildBackendResolver(services, slices)
+
+	backends := buildHTTPRoutes(list, grants, resolver)[0].Paths[0].Backends
+	if backends[0].Problem != "" || backends[0].ReadyEndpoints != 1 {
+		t.Errorf("expected the granted web backend to resolve, got %+v", backends[0])
+	}
+	if backends[1].Problem != BackendRefNotPermitted {
+		t.Errorf("expected idle, which the grant does not name, to be refused, got %+v", backends[1])
+	}
+
+	backends = buildHTTPRoutes(list, referenceGrantList{}, resolver)[0].Paths[0].Backends
+	if backends[0].Problem != BackendRefNotPermitted {
+		t.Errorf("expected web to be refused without a ReferenceGrant, got %+v", backends[0])
+	}
+}
+
+func TestGetHTTPRoutes_Unavailable(t *testing.T) {
+	_, err := GetHTTPRoutes(context.Background(), fake.NewSimpleClientset(), "")
+	if !errors.Is(err, ErrGatewayAPIUnavailable) {
+		t.Errorf("expected ErrGatewayAPIUnavailable, got %v", err)
+	}
+}
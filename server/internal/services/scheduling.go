@@ -0,0 +1,526 @@
+// internal/services/scheduling.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Scheduling predicates, named after the scheduler plugins they imitate
+const (
+	PredicateNodeUnschedulable = "NodeUnschedulable"
+	PredicateNodeResourcesFit  = "NodeResourcesFit"
+	PredicateNodeSelector      = "NodeSelector"
+	PredicateNodeAffinity      = "NodeAffinity"
+	PredicateTaintToleration   = "TaintToleration"
+	PredicateTopologySpread    = "PodTopologySpread"
+	PredicatePodAntiAffinity   = "InterPodAntiAffinity"
+)
+
+// PredicateResult is the outcome of one predicate on one node
+type PredicateResult struct {
+	Predicate string `json:"predicate"`
+	Passed    bool   `json:"passed"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// NodeFit is one row of the scheduling table
+type NodeFit struct {
+	Node       string            `json:"node"`
+	Fits       bool              `json:"fits"`
+	Predicates []PredicateResult `json:"predicates"`
+}
+
+// SchedulingExplanation evaluates every node against a pod
+type SchedulingExplanation struct {
+	Pod              string            `json:"pod"`
+	Namespace        string            `json:"namespace"`
+	Phase            string            `json:"phase"`
+	Requests         map[string]string `json:"requests"`
+	SchedulerMessage string            `json:"scheduler_message,omitempty"`
+	FeasibleNodes    int               `json:"feasible_nodes"`
+	Nodes            []NodeFit         `json:"nodes"`
+}
+
+// podsByNode groups pods by the node they are bound to; unscheduled pods
+// are left out
+func podsByNode(pods []corev1.Pod) map[string][]*corev1.Pod {
+	result := make(map[string][]*corev1.Pod)
+	for i := range pods {
+		if node := pods[i].Spec.NodeName; node != "" {
+			result[node] = append(result[node], &pods[i])
+		}
+	}
+	return result
+}
+
+// podIsTerminal reports whether a pod no longer holds node resources
+func podIsTerminal(pod *corev1.Pod) bool {
+	return pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+}
+
+// PodRequests returns the pod's effective requests the way the scheduler
+// counts them: the sum over containers, raised to the largest init
+// container request, plus pod overhead
+func PodRequests(pod *corev1.Pod) corev1.ResourceList {
+	requests := effectiveContainerResources(pod, func(resources corev1.ResourceRequirements) corev1.ResourceList {
+		return resources.Requests
+	})
+	for name, quantity := range pod.Spec.Overhead {
+		total := requests[name]
+		total.Add(quantity)
+		requests[name] = total
+	}
+	return requests
+}
+
+// PodLimits returns the pod's effective limits by the same rule as
+// PodRequests. Overhead only raises limits that are set, since an unset
+// limit is unbounded.
+func PodLimits(pod *corev1.Pod) corev1.ResourceList {
+	limits := effectiveContainerResources(pod, func(resources corev1.ResourceRequirements) corev1.ResourceList {
+		return resources.Limits
+	})
+	for name, quantity := range pod.Spec.Overhead {
+		if total, ok := limits[name]; ok {
+			total.Add(quantity)
+			limits[name] = total
+		}
+	}
+	return limits
+}
+
+// effectiveContainerResources sums list over the app containers and raises
+// each resource to the largest init container value, since init containers
+// run one at a time before the app containers start
+func effectiveContainerResources(pod *corev1.Pod, list func(corev1.ResourceRequirements) corev1.ResourceList) corev1.ResourceList {
+	result := corev1.ResourceList{}
+	for _, container := range pod.Spec.Containers {
+		for name, quantity := range list(container.Resources) {
+			total := result[name]
+			total.Add(quantity)
+			result[name] = total
+		}
+	}
+	for _, container := range pod.Spec.InitContainers {
+		for name, quantity := range list(container.Resources) {
+			if current, ok := result[name]; !ok || quantity.Cmp(current) > 0 {
+				result[name] = quantity.DeepCopy()
+			}
+		}
+	}
+	return result
+}
+
+// nodeRequested sums the requests of the non-terminal pods on a node
+func nodeRequested(pods []*corev1.Pod) corev1.ResourceList {
+	requested := corev1.ResourceList{}
+	for _, pod := range pods {
+		if podIsTerminal(pod) {
+			continue
+		}
+		for name, quantity := range PodRequests(pod) {
+			total := requested[name]
+			total.Add(quantity)
+			requested[name] = total
+		}
+	}
+	return requested
+}
+
+// ExplainScheduling evaluates every node against the pod's scheduling
+// constraints
+func ExplainScheduling(ctx context.Context, clientset kubernetes.Interface, namespace, name string) (*SchedulingExplanation, error) {
+	pod, err := clientset.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pod: %w", err)
+	}
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	pods, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	namespaces, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	return explainScheduling(pod, nodes.Items, pods.Items, namespaces.Items), nil
+}
+
+func explainScheduling(pod *corev1.Pod, nodes []corev1.Node, pods []corev1.Pod, namespaces []corev1.Namespace) *SchedulingExplanation {
+	requests := PodRequests(pod)
+	explanation := &SchedulingExplanation{
+		Pod:       pod.Name,
+		Namespace: pod.Namespace,
+		Phase:     string(pod.Status.Phase),
+		Requests:  make(map[string]string, len(requests)),
+		Nodes:     make([]NodeFit, 0, len(nodes)),
+	}
+	for name, quantity := range requests {
+		explanation.Requests[string(name)] = quantity.String()
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse {
+			explanation.SchedulerMessage = condition.Message
+		}
+	}
+
+	// Other pods only: the pod itself must not count against its own node
+	others := make([]corev1.Pod, 0, len(pods))
+	for _, p := range pods {
+		if p.Namespace != pod.Namespace || p.Name != pod.Name {
+			others = append(others, p)
+		}
+	}
+	byNode := podsByNode(others)
+
+	// Anti-affinity namespace selectors match against namespace labels
+	namespaceLabels := make(map[string]labels.Set, len(namespaces))
+	for _, namespace := range namespaces {
+		namespaceLabels[namespace.Name] = namespaceLabelSet(namespace.Name, namespace.Labels)
+	}
+
+	for i := range nodes {
+		node := &nodes[i]
+		fit := NodeFit{Node: node.Name, Fits: true}
+		results := []PredicateResult{
+			checkUnschedulable(pod, node),
+			checkResources(requests, node, byNode[node.Name]),
+			checkNodeSelector(pod, node),
+			checkNodeAffinity(pod, node),
+			checkTaints(pod, node),
+			checkTopologySpread(pod, node, nodes, byNode),
+			checkAntiAffinity(pod, node, nodes, byNode, namespaceLabels),
+		}
+		for _, result := range results {
+			if !result.Passed {
+				fit.Fits = false
+			}
+		}
+		fit.Predicates = results
+		if fit.Fits {
+			explanation.FeasibleNodes++
+		}
+		explanation.Nodes = append(explanation.Nodes, fit)
+	}
+
+	sort.SliceStable(explanation.Nodes, func(i, j int) bool {
+		if explanation.Nodes[i].Fits != explanation.Nodes[j].Fits {
+			return explanation.Nodes[i].Fits
+		}
+		return explanation.Nodes[i].Node < explanation.Nodes[j].Node
+	})
+	return explanation
+}
+
+func passed(predicate string) PredicateResult {
+	return PredicateResult{Predicate: predicate, Passed: true}
+}
+
+func failed(predicate, format string, args ...interface{}) PredicateResult {
+	return PredicateResult{Predicate: predicate, Reason: fmt.Sprintf(format, args...)}
+}
+
+func checkUnschedulable(pod *corev1.Pod, node *corev1.Node) PredicateResult {
+	if !node.Spec.Unschedulable {
+		return passed(PredicateNodeUnschedulable)
+	}
+	taint := corev1.Taint{Key: corev1.TaintNodeUnschedulable, Effect: corev1.TaintEffectNoSchedule}
+	for _, toleration := range pod.Spec.Tolerations {
+		if toleration.ToleratesTaint(&taint) {
+			return passed(PredicateNodeUnschedulable)
+		}
+	}
+	return failed(PredicateNodeUnschedulable, "node is cordoned")
+}
+
+func checkResources(requests corev1.ResourceList, node *corev1.Node, pods []*corev1.Pod) PredicateResult {
+	requested := nodeRequested(pods)
+	reasons := make([]string, 0)
+
+	running := 0
+	for _, pod := range pods {
+		if !podIsTerminal(pod) {
+			running++
+		}
+	}
+	if allocatable := node.Status.Allocatable.Pods(); !allocatable.IsZero() && int64(running) >= allocatable.Value() {
+		reasons = append(reasons, fmt.Sprintf("Too many pods (%d of %d)", running, allocatable.Value()))
+	}
+
+	names := make([]string, 0, len(requests))
+	for name := range requests {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		request := requests[corev1.ResourceName(name)]
+		if request.IsZero() {
+			continue
+		}
+		allocatable := node.Status.Allocatable[corev1.ResourceName(name)]
+		used := requested[corev1.ResourceName(name)]
+		free := allocatable.DeepCopy()
+		free.Sub(used)
+		if request.Cmp(free) > 0 {
+			if free.Sign() < 0 {
+				free = resource.Quantity{}
+			}
+			reasons = append(reasons, fmt.Sprintf("Insufficient %s (requested %s, free %s of %s)", name, request.String(), free.String(), allocatable.String()))
+		}
+	}
+
+	if len(reasons) > 0 {
+		return failed(PredicateNodeResourcesFit, "%s", strings.Join(reasons, "; "))
+	}
+	return passed(PredicateNodeResourcesFit)
+}
+
+func checkNodeSelector(pod *corev1.Pod, node *corev1.Node) PredicateResult {
+	missing := make([]string, 0)
+	for _, key := range sortedKeys(pod.Spec.NodeSelector) {
+		if value, ok := node.Labels[key]; !ok || value != pod.Spec.NodeSelector[key] {
+			missing = append(missing, key+"="+pod.Spec.NodeSelector[key])
+		}
+	}
+	if len(missing) > 0 {
+		return failed(PredicateNodeSelector, "node does not have labels %s", strings.Join(missing, ", "))
+	}
+	return passed(PredicateNodeSelector)
+}
+
+func checkNodeAffinity(pod *corev1.Pod, node *corev1.Node) PredicateResult {
+	affinity := pod.Spec.Affinity
+	if affinity == nil || affinity.NodeAffinity == nil || affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return passed(PredicateNodeAffinity)
+	}
+
+	// Terms are ORed; the requirements inside a term are ANDed
+	terms := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	for _, term := range terms {
+		if nodeMatchesTerm(node, term) {
+			return passed(PredicateNodeAffinity)
+		}
+	}
+	return failed(PredicateNodeAffinity, "node matches none of the %d required node affinity terms", len(terms))
+}
+
+func nodeMatchesTerm(node *corev1.Node, term corev1.NodeSelectorTerm) bool {
+	if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+		return false
+	}
+	labelSelector, err := nodeSelectorRequirements(term.MatchExpressions)
+	if err != nil || !labelSelector.Matches(labels.Set(node.Labels)) {
+		return false
+	}
+	fieldSelector, err := nodeSelectorRequirements(term.MatchFields)
+	if err != nil {
+		return false
+	}
+	return fieldSelector.Matches(labels.Set{"metadata.name": node.Name})
+}
+
+// nodeSelectorRequirements converts node selector requirements into a
+// label selector
+func nodeSelectorRequirements(requirements []corev1.NodeSelectorRequirement) (labels.Selector, error) {
+	operators := map[corev1.NodeSelectorOperator]selection.Operator{
+		corev1.NodeSelectorOpIn:           selection.In,
+		corev1.NodeSelectorOpNotIn:        selection.NotIn,
+		corev1.NodeSelectorOpExists:       selection.Exists,
+		corev1.NodeSelectorOpDoesNotExist: selection.DoesNotExist,
+		corev1.NodeSelectorOpGt:           selection.GreaterThan,
+		corev1.NodeSelectorOpLt:           selection.LessThan,
+	}
+	selector := labels.NewSelector()
+	for _, requirement := range requirements {
+		op, ok := operators[requirement.Operator]
+		if !ok {
+			return nil, fmt.Errorf("unknown operator %q", requirement.Operator)
+		}
+		r, err := labels.NewRequirement(requirement.Key, op, requirement.Values)
+		if err != nil {
+			return nil, err
+		}
+		selector = selector.Add(*r)
+	}
+	return selector, nil
+}
+
+func checkTaints(pod *corev1.Pod, node *corev1.Node) PredicateResult {
+	untolerated := make([]string, 0)
+	for i := range node.Spec.Taints {
+		taint := &node.Spec.Taints[i]
+		if taint.Effect != corev1.TaintEffectNoSchedule && taint.Effect != corev1.TaintEffectNoExecute {
+			continue
+		}
+		tolerated := false
+		for _, toleration := range pod.Spec.Tolerations {
+			if toleration.ToleratesTaint(taint) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			untolerated = append(untolerated, taint.ToString())
+		}
+	}
+	if len(untolerated) > 0 {
+		return failed(PredicateTaintToleration, "untolerated taints %s", strings.Join(untolerated, ", "))
+	}
+	return passed(PredicateTaintToleration)
+}
+
+// checkTopologySpread evaluates DoNotSchedule constraints: placing the pod
+// on node must keep the skew between topology domains within maxSkew
+func checkTopologySpread(pod *corev1.Pod, node *corev1.Node, nodes []corev1.Node, byNode map[string][]*corev1.Pod) PredicateResult {
+	for _, constraint := range pod.Spec.TopologySpreadConstraints {
+		if constraint.WhenUnsatisfiable != corev1.DoNotSchedule {
+			continue
+		}
+		domain, ok := node.Labels[constraint.TopologyKey]
+		if !ok {
+			return failed(PredicateTopologySpread, "node has no %s label", constraint.TopologyKey)
+		}
+		selector, err := metav1.LabelSelectorAsSelector(constraint.LabelSelector)
+		if err != nil {
+			return failed(PredicateTopologySpread, "invalid label selector: %v", err)
+		}
+
+		// Count matching pods per domain across nodes eligible for the pod
+		counts := make(map[string]int)
+		for i := range nodes {
+			candidate := &nodes[i]
+			value, ok := candidate.Labels[constraint.TopologyKey]
+			if !ok || !checkNodeSelector(pod, candidate).Passed || !checkNodeAffinity(pod, candidate).Passed {
+				continue
+			}
+			if _, seen := counts[value]; !seen {
+				counts[value] = 0
+			}
+			for _, other := range byNode[candidate.Name] {
+				if other.Namespace == pod.Namespace && !podIsTerminal(other) && selector.Matches(labels.Set(other.Labels)) {
+					counts[value]++
+				}
+			}
+		}
+
+		minCount := -1
+		for _, count := range counts {
+			if minCount < 0 || count < minCount {
+				minCount = count
+			}
+		}
+		if minCount < 0 {
+			minCount = 0
+		}
+		if skew := counts[domain] + 1 - minCount; skew > int(constraint.MaxSkew) {
+			return failed(PredicateTopologySpread, "placing here makes skew %d on %s=%s (maxSkew %d)", skew, constraint.TopologyKey, domain, constraint.MaxSkew)
+		}
+	}
+	return passed(PredicateTopologySpread)
+}
+
+// checkAntiAffinity evaluates required anti-affinity in both directions:
+// the pod's own terms against pods already running in the node's topology
+// domain, and the terms of those pods against the pod, as the scheduler
+// does. A term applies to the namespaces it lists plus those whose labels
+// match its namespace selector, or to its owner's namespace when it has
+// neither.
+func checkAntiAffinity(pod *corev1.Pod, node *corev1.Node, nodes []corev1.Node, byNode map[string][]*corev1.Pod, namespaceLabels map[string]labels.Set) PredicateResult {
+	if affinity := pod.Spec.Affinity; affinity != nil && affinity.PodAntiAffinity != nil {
+		for _, term := range affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+			domain, ok := node.Labels[term.TopologyKey]
+			if !ok {
+				continue
+			}
+			for i := range nodes {
+				candidate := &nodes[i]
+				if candidate.Labels[term.TopologyKey] != domain {
+					continue
+				}
+				for _, other := range byNode[candidate.Name] {
+					if podIsTerminal(other) {
+						continue
+					}
+					matches, err := antiAffinityTermMatches(term, pod, other, namespaceLabels)
+					if err != nil {
+						return failed(PredicatePodAntiAffinity, "%v", err)
+					}
+					if matches {
+						return failed(PredicatePodAntiAffinity, "conflicts with pod %s/%s on %s=%s", other.Namespace, other.Name, term.TopologyKey, domain)
+					}
+				}
+			}
+		}
+	}
+
+	// Existing pods whose anti-affinity rejects the pod in their domain
+	for i := range nodes {
+		candidate := &nodes[i]
+		for _, other := range byNode[candidate.Name] {
+			if podIsTerminal(other) || other.Spec.Affinity == nil || other.Spec.Affinity.PodAntiAffinity == nil {
+				continue
+			}
+			for _, term := range other.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+				domain, ok := node.Labels[term.TopologyKey]
+				if !ok || candidate.Labels[term.TopologyKey] != domain {
+					continue
+				}
+				// An invalid term on another pod cannot match anything
+				if matches, err := antiAffinityTermMatches(term, other, pod, namespaceLabels); err == nil && matches {
+					return failed(PredicatePodAntiAffinity, "pod %s/%s on %s=%s has anti-affinity against this pod", other.Namespace, other.Name, term.TopologyKey, domain)
+				}
+			}
+		}
+	}
+	return passed(PredicatePodAntiAffinity)
+}
+
+// antiAffinityTermMatches reports whether target is selected by a term
+// belonging to owner
+func antiAffinityTermMatches(term corev1.PodAffinityTerm, owner, target *corev1.Pod, namespaceLabels map[string]labels.Set) (bool, error) {
+	selector, err := metav1.LabelSelectorAsSelector(term.LabelSelector)
+	if err != nil {
+		return false, fmt.Errorf("invalid label selector: %v", err)
+	}
+	if !selector.Matches(labels.Set(target.Labels)) {
+		return false, nil
+	}
+
+	namespaces := term.Namespaces
+	if len(namespaces) == 0 && term.NamespaceSelector == nil {
+		namespaces = []string{owner.Namespace}
+	}
+	if containsString(namespaces, target.Namespace) {
+		return true, nil
+	}
+	if term.NamespaceSelector == nil {
+		return false, nil
+	}
+	namespaceSelector, err := metav1.LabelSelectorAsSelector(term.NamespaceSelector)
+	if err != nil {
+		return false, fmt.Errorf("invalid namespace selector: %v", err)
+	}
+	return namespaceSelector.Matches(namespaceLabelSet(target.Namespace, namespaceLabels[target.Namespace])), nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
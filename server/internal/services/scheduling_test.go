@@ -0,0 +1,217 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func schedulingNode(name, zone string, cpu string) corev1.Node {
+	return corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{
+			"kubernetes.io/hostname":      name,
+			"topology.kubernetes.io/zone": zone,
+		}},
+		Status: corev1.NodeStatus{Allocatable: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpu),
+			corev1.ResourceMemory: resource.MustParse("4Gi"),
+			corev1.ResourcePods:   resource.MustParse("110"),
+		}},
+	}
+}
+
+func requestingPod(name, node, cpu string, labels map[string]string) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels},
+		Spec: corev1.PodSpec{
+			NodeName: node,
+			Containers: []corev1.Container{{Name: "app", Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+			}}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+func TestExplainScheduling_PerNodePredicates(t *testing.T) {
+	full := schedulingNode("full", "a", "1")
+	tainted := schedulingNode("tainted", "b", "4")
+	tainted.Spec.Taints = []corev1.Taint{{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule}}
+	wrongDisk := schedulingNode("wrong-disk", "c", "4")
+	cordoned := schedulingNode("cordoned", "d", "4")
+	cordoned.Labels["disk"] = "ssd"
+	cordoned.Spec.Unschedulable = true
+	neighbour := schedulingNode("neighbour", "e", "4")
+	neighbour.Labels["disk"] = "ssd"
+	good := schedulingNode("good", "f", "4")
+	good.Labels["disk"] = "ssd"
+	full.Labels["disk"] = "ssd"
+	tainted.Labels["disk"] = "ssd"
+
+	pending := requestingPod("web-new", "", "500m", map[string]string{"app": "web"})
+	pending.Status.Phase = corev1.PodPending
+	pending.Spec.NodeSelector = map[string]string{"disk": "ssd"}
+	pending.Spec.Affinity = &corev1.Affinity{PodAntiAffinity: &corev1.PodAntiAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{
+			LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			TopologyKey:   "kubernetes.io/hostname",
+		}},
+	}}
+
+	pods := []corev1.Pod{
+		pending,
+		requestingPod("batch", "full", "800m", map[string]string{"app": "batch"}),
+		requestingPod("web-old", "neighbour", "100m", map[string]string{"app": "web"}),
+	}
+	nodes := []corev1.Node{full, tainted, wrongDisk, cordoned, neighbour, good}
+
+	explanation := explainScheduling(&pending, nodes, pods, nil)
+	if explanation.FeasibleNodes != 1 || explanation.Nodes[0].Node != "good" || !explanation.Nodes[0].Fits {
+		t.Fatalf("expected only good to fit, got %+v", explanation.Nodes)
+	}
+
+	want := map[string]string{
+		"full":       PredicateNodeResourcesFit,
+		"tainted":    PredicateTaintToleration,
+		"wrong-disk": PredicateNodeSelector,
+		"cordoned":   PredicateNodeUnschedulable,
+		"neighbour":  PredicatePodAntiAffinity,
+	}
+	for _, fit := range explanation.Nodes[1:] {
+		failedPredicates := make([]string, 0)
+		for _, result := range fit.Predicates {
+			if !result.Passed {
+				failedPredicates = append(failedPredicates, result.Predicate)
+			}
+		}
+		if len(failedPredicates) != 1 || failedPredicates[0] != want[fit.Node] {
+			t.Fatalf("node %s: expected only %s to fail, got %v", fit.Node, want[fit.Node], failedPredicates)
+		}
+	}
+	if explanation.Nodes[1].Node != "cordoned" {
+		t.Fatalf("expected failing nodes sorted by name, got %s first", explanation.Nodes[1].Node)
+	}
+}
+
+func TestExplainScheduling_TopologySpreadAndAffinity(t *testing.T) {
+	nodeA := schedulingNode("a1", "a", "4")
+	nodeB := schedulingNode("b1", "b", "4")
+
+	pod := requestingPod("web-3", "", "100m", map[string]string{"app": "web"})
+	pod.Spec.TopologySpreadConstraints = []corev1.TopologySpreadConstraint{{
+		MaxSkew:           1,
+		TopologyKey:       "topology.kubernetes.io/zone",
+		WhenUnsatisfiable: corev1.DoNotSchedule,
+		LabelSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+	}}
+	pods := []corev1.Pod{
+		requestingPod("web-1", "a1", "100m", map[string]string{"app": "web"}),
+		requestingPod("web-2", "a1", "100m", map[string]string{"app": "web"}),
+		requestingPod("web-x", "b1", "100m", map[string]string{"app": "web"}),
+	}
+
+	explanation := explainScheduling(&pod, []corev1.Node{nodeA, nodeB}, pods, nil)
+	if explanation.FeasibleNodes != 1 || explanation.Nodes[0].Node != "b1" {
+		t.Fatalf("expected only b1 to satisfy the spread, got %+v", explanation.Nodes)
+	}
+	if reason := explanation.Nodes[1].Predicates[5].Reason; !strings.Contains(reason, "skew 2") {
+		t.Fatalf("expected skew reason, got %q", reason)
+	}
+
+	pod.Spec.TopologySpreadConstraints = nil
+	pod.Spec.Affinity = &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+			MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "topology.kubernetes.io/zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"a"}}},
+		}}},
+	}}
+	explanation = explainScheduling(&pod, []corev1.Node{nodeA, nodeB}, pods, nil)
+	if explanation.FeasibleNodes != 1 || explanation.Nodes[0].Node != "a1" {
+		t.Fatalf("expected only a1 to match node affinity, got %+v", explanation.Nodes)
+	}
+}
+
+func TestPodRequests_InitContainersAndOverhead(t *testing.T) {
+	pod := &corev1.Pod{Spec: corev1.PodSpec{
+		InitContainers: []corev1.Container{{Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}}}},
+		Containers: []corev1.Container{
+			{Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("200m")}}},
+			{Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("300m")}}},
+		},
+		Overhead: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+	}}
+	cpu := PodRequests(pod)[corev1.ResourceCPU]
+	if cpu.MilliValue() != 1100 {
+		t.Fatalf("expected 1100m, got %s", cpu.String())
+	}
+
+	// Pod metrics report the same effective request
+	totals := podResources(pod)
+	if totals.CPURequest.MilliValue() != 1100 {
+		t.Fatalf("expected podResources to count init containers and overhead, got %s", totals.CPURequest.String())
+	}
+	if !totals.CPULimit.IsZero() {
+		t.Fatalf("expected no CPU limit, got %s", totals.CPULimit.String())
+	}
+}
+
+func TestExplainScheduling_AntiAffinityNamespaceSelector(t *testing.T) {
+	nodeA := schedulingNode("a1", "a", "4")
+	nodeB := schedulingNode("b1", "b", "4")
+
+	pod := requestingPod("web-new", "", "100m", map[string]string{"app": "web"})
+	pod.Spec.Affinity = &corev1.Affinity{PodAntiAffinity: &corev1.PodAntiAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{
+			LabelSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "web"}},
+			TopologyKey:       "kubernetes.io/hostname",
+		}},
+	}}
+	selected := requestingPod("web-1", "a1", "100m", map[string]string{"app": "web"})
+	selected.Namespace = "web-prod"
+	other := requestingPod("web-2", "b1", "100m", map[string]string{"app": "web"})
+	other.Namespace = "batch"
+	namespaces := []corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "web-prod", Labels: map[string]string{"team": "web"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "batch", Labels: map[string]string{"team": "batch"}}},
+	}
+
+	explanation := explainScheduling(&pod, []corev1.Node{nodeA, nodeB}, []corev1.Pod{selected, other}, namespaces)
+	if explanation.FeasibleNodes != 1 || explanation.Nodes[0].Node != "b1" {
+		t.Fatalf("expected only b1, whose pod is outside the selected namespaces, got %+v", explanation.Nodes)
+	}
+	if reason := explanation.Nodes[1].Predicates[6].Reason; !strings.Contains(reason, "web-prod/web-1") {
+		t.Fatalf("expected a conflict with web-prod/web-1, got %q", reason)
+	}
+}
+
+func TestExplainScheduling_ExistingPodAntiAffinity(t *testing.T) {
+	nodeA := schedulingNode("a1", "a", "4")
+	nodeB := schedulingNode("b1", "b", "4")
+
+	// The incoming pod has no affinity of its own
+	pod := requestingPod("web-new", "", "100m", map[string]string{"app": "web"})
+	cache := requestingPod("cache-0", "a1", "100m", map[string]string{"app": "cache"})
+	cache.Spec.Affinity = &corev1.Affinity{PodAntiAffinity: &corev1.PodAntiAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{
+			LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			TopologyKey:   "topology.kubernetes.io/zone",
+		}},
+	}}
+
+	explanation := explainScheduling(&pod, []corev1.Node{nodeA, nodeB}, []corev1.Pod{cache}, nil)
+	if explanation.FeasibleNodes != 1 || explanation.Nodes[0].Node != "b1" {
+		t.Fatalf("expected only b1, outside cache-0's zone, got %+v", explanation.Nodes)
+	}
+	if reason := explanation.Nodes[1].Predicates[6].Reason; !strings.Contains(reason, "default/cache-0") {
+		t.Fatalf("expected cache-0's anti-affinity as the reason, got %q", reason)
+	}
+
+	// The term only covers its owner's namespace
+	pod.Namespace = "other"
+	if explanation := explainScheduling(&pod, []corev1.Node{nodeA, nodeB}, []corev1.Pod{cache}, nil); explanation.FeasibleNodes != 2 {
+		t.Fatalf("expected both nodes for a pod in another namespace, got %+v", explanation.Nodes)
+	}
+}
@@ -0,0 +1,384 @@
+// internal/services/security.go
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mugayoshi/k8s-visualizer/server/internal/models"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Security check IDs
+const (
+	SecurityPrivileged       = "privileged"
+	SecurityHostNetwork      = "host-network"
+	SecurityHostPID          = "host-pid"
+	SecurityHostIPC          = "host-ipc"
+	SecurityHostPath         = "host-path"
+	SecurityRunAsRoot        = "run-as-root"
+	SecurityWritableRootFS   = "writable-root-filesystem"
+	SecurityAddedCaps        = "added-capabilities"
+	SecurityServiceAcctToken = "service-account-token"
+)
+
+// Pod Security Admission levels and namespace labels
+const (
+	PSAPrivileged = "privileged"
+	PSABaseline   = "baseline"
+	PSARestricted = "restricted"
+
+	psaLabelPrefix = "pod-security.kubernetes.io/"
+)
+
+// securityPenalty is how many points a finding takes off its pod's score
+var securityPenalty = map[string]int{
+	models.SeverityCritical: 40,
+	models.SeverityWarning:  15,
+	models.SeverityInfo:     5,
+}
+
+// baselineCapabilities may be added under the baseline PSA level
+var baselineCapabilities = map[corev1.Capability]bool{
+	"AUDIT_WRITE": true, "CHOWN": true, "DAC_OVERRIDE": true, "FOWNER": true,
+	"FSETID": true, "KILL": true, "MKNOD": true, "NET_BIND_SERVICE": true,
+	"SETFCAP": true, "SETGID": true, "SETPCAP": true, "SETUID": true, "SYS_CHROOT": true,
+}
+
+// SecurityFinding is one risky setting on a pod or container
+type SecurityFinding struct {
+	Check     string `json:"check"`
+	Severity  string `json:"severity"`
+	Namespace string `json:"namespace"`
+	Pod       string `json:"pod"`
+	Container string `json:"container,omitempty"`
+	Message   string `json:"message"`
+}
+
+// PSAViolation lists why a pod does not meet its namespace's PSA level
+type PSAViolation struct {
+	Namespace string   `json:"namespace"`
+	Pod       string   `json:"pod"`
+	Level     string   `json:"level"`
+	Mode      string   `json:"mode"` // Label the level came from: enforce, warn or audit
+	Reasons   []string `json:"reasons"`
+}
+
+// NamespaceSecurity is the posture of one namespace. Score is the average
+// pod score, where each pod starts at 100 and loses points per finding.
+type NamespaceSecurity struct {
+	Namespace     string `json:"namespace"`
+	Score         int    `json:"score"`
+	Pods          int    `json:"pods"`
+	Critical      int    `json:"critical"`
+	Warning       int    `json:"warning"`
+	Info          int    `json:"info"`
+	PSALevel      string `json:"psa_level,omitempty"`
+	PSAMode       string `json:"psa_mode,omitempty"`
+	PSAViolations int    `json:"psa_violations"`
+}
+
+// SecurityReport is the security posture of the scanned pods
+type SecurityReport struct {
+	Namespaces    []NamespaceSecurity `json:"namespaces"`
+	Findings      []SecurityFinding   `json:"findings"`
+	PSAViolations []PSAViolation      `json:"psa_violations"`
+	Count         int                 `json:"count"`
+}
+
+// BuildSecurityReport scans pod specs for risky settings, checks them against
+// their namespace's Pod Security Admission labels and scores each namespace
+func BuildSecurityReport(pods []corev1.Pod, namespaces []corev1.Namespace) *SecurityReport {
+	report := &SecurityReport{
+		Namespaces:    make([]NamespaceSecurity, 0),
+		Findings:      make([]SecurityFinding, 0),
+		PSAViolations: make([]PSAViolation, 0),
+	}
+
+	nsLabels := make(map[string]map[string]string, len(namespaces))
+	for _, ns := range namespaces {
+		nsLabels[ns.Name] = ns.Labels
+	}
+
+	summaries := make(map[string]*NamespaceSecurity)
+	scoreTotals := make(map[string]int)
+	for i := range pods {
+		pod := &pods[i]
+		if podIsTerminal(pod) {
+			continue
+		}
+
+		summary, ok := summaries[pod.Namespace]
+		if !ok {
+			summary = &NamespaceSecurity{Namespace: pod.Namespace}
+			summary.PSALevel, summary.PSAMode = psaLevel(nsLabels[pod.Namespace])
+			summaries[pod.Namespace] = summary
+		}
+		summary.Pods++
+
+		score := 100
+		for _, finding := range ScanPodSecurity(pod) {
+			report.Findings = append(report.Findings, finding)
+			score -= securityPenalty[finding.Severity]
+			switch finding.Severity {
+			case models.SeverityCritical:
+				summary.Critical++
+			case models.SeverityWarning:
+				summary.Warning++
+			default:
+				summary.Info++
+			}
+		}
+		if score < 0 {
+			score = 0
+		}
+		scoreTotals[pod.Namespace] += score
+
+		if reasons := CheckPodSecurityLevel(pod, summary.PSALevel); len(reasons) > 0 {
+			report.PSAViolations = append(report.PSAViolations, PSAViolation{
+				Namespace: pod.Namespace,
+				Pod:       pod.Name,
+				Level:     summary.PSALevel,
+				Mode:      summary.PSAMode,
+				Reasons:   reasons,
+			})
+			summary.PSAViolations++
+		}
+	}
+
+	for namespace, summary := range summaries {
+		summary.Score = (scoreTotals[namespace] + summary.Pods/2) / summary.Pods
+		report.Namespaces = append(report.Namespaces, *summary)
+	}
+	// Worst namespaces first
+	sort.Slice(report.Namespaces, func(i, j int) bool {
+		a, b := report.Namespaces[i], report.Namespaces[j]
+		if a.Score != b.Score {
+			return a.Score < b.Score
+		}
+		return a.Namespace < b.Namespace
+	})
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		a, b := report.Findings[i], report.Findings[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Pod < b.Pod
+	})
+	report.Count = len(report.Findings)
+	return report
+}
+
+// ScanPodSecurity reports privileged and host-level access, root users,
+// writable root filesystems, added capabilities and mounted tokens
+func ScanPodSecurity(pod *corev1.Pod) []SecurityFinding {
+	var findings []SecurityFinding
+	add := func(check, severity, container, message string) {
+		findings = append(findings, SecurityFinding{
+			Check:     check,
+			Severity:  severity,
+			Namespace: pod.Namespace,
+			Pod:       pod.Name,
+			Container: container,
+			Message:   message,
+		})
+	}
+
+	if pod.Spec.HostNetwork {
+		add(SecurityHostNetwork, models.SeverityWarning, "", "pod shares the node's network namespace")
+	}
+	if pod.Spec.HostPID {
+		add(SecurityHostPID, models.SeverityCritical, "", "pod shares the node's process namespace")
+	}
+	if pod.Spec.HostIPC {
+		add(SecurityHostIPC, models.SeverityWarning, "", "pod shares the node's IPC namespace")
+	}
+	for _, volume := range pod.Spec.Volumes {
+		if volume.HostPath != nil {
+			add(SecurityHostPath, models.SeverityWarning, "", fmt.Sprintf("volume %s mounts host path %s", volume.Name, volume.HostPath.Path))
+		}
+	}
+	if automountsToken(pod) {
+		add(SecurityServiceAcctToken, models.SeverityInfo, "", fmt.Sprintf("service account %s token is mounted automatically", serviceAccountName(pod)))
+	}
+
+	for _, container := range podContainers(pod) {
+		sc := container.SecurityContext
+		if sc != nil && sc.Privileged != nil && *sc.Privileged {
+			add(SecurityPrivileged, models.SeverityCritical, container.Name, "container runs privileged")
+		}
+
+		runAsUser, runAsNonRoot := effectiveUser(pod, container)
+		switch {
+		case runAsUser != nil && *runAsUser == 0:
+			add(SecurityRunAsRoot, models.SeverityCritical, container.Name, "container runs as UID 0")
+		case runAsUser == nil && (runAsNonRoot == nil || !*runAsNonRoot):
+			add(SecurityRunAsRoot, models.SeverityWarning, container.Name, "runAsNonRoot is not set, so the image may run as root")
+		}
+
+		if sc == nil || sc.ReadOnlyRootFilesystem == nil || !*sc.ReadOnlyRootFilesystem {
+			add(SecurityWritableRootFS, models.SeverityWarning, container.Name, "root filesystem is writable")
+		}
+		if sc != nil && sc.Capabilities != nil && len(sc.Capabilities.Add) > 0 {
+			caps := make([]string, 0, len(sc.Capabilities.Add))
+			for _, capability := range sc.Capabilities.Add {
+				caps = append(caps, string(capability))
+			}
+			add(SecurityAddedCaps, models.SeverityWarning, container.Name, "adds capabilities "+strings.Join(caps, ", "))
+		}
+	}
+	return findings
+}
+
+// CheckPodSecurityLevel returns the reasons a pod violates the given Pod
+// Security Standards level. It covers the controls that pod specs commonly
+// trip; the privileged level allows everything.
+func CheckPodSecurityLevel(pod *corev1.Pod, level string) []string {
+	if level != PSABaseline && level != PSARestricted {
+		return nil
+	}
+
+	var reasons []string
+	if pod.Spec.HostNetwork || pod.Spec.HostPID || pod.Spec.HostIPC {
+		reasons = append(reasons, "host namespaces are not allowed")
+	}
+	for _, volume := range pod.Spec.Volumes {
+		if volume.HostPath != nil {
+			reasons = append(reasons, fmt.Sprintf("hostPath volume %s is not allowed", volume.Name))
+		}
+	}
+
+	for _, container := range podContainers(pod) {
+		sc := container.SecurityContext
+		if sc != nil && sc.Privileged != nil && *sc.Privileged {
+			reasons = append(reasons, fmt.Sprintf("container %s is privileged", container.Name))
+		}
+		for _, port := range container.Ports {
+			if port.HostPort != 0 {
+				reasons = append(reasons, fmt.Sprintf("container %s uses host port %d", container.Name, port.HostPort))
+			}
+		}
+		if sc != nil && sc.Capabilities != nil {
+			for _, capability := range sc.Capabilities.Add {
+				if !baselineCapabilities[capability] || (level == PSARestricted && capability != "NET_BIND_SERVICE") {
+					reasons = append(reasons, fmt.Sprintf("container %s adds capability %s", container.Name, capability))
+				}
+			}
+		}
+		if level != PSARestricted {
+			continue
+		}
+
+		if sc == nil || sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation {
+			reasons = append(reasons, fmt.Sprintf("container %s must set allowPrivilegeEscalation=false", container.Name))
+		}
+		if sc == nil || sc.Capabilities == nil || !containsCapability(sc.Capabilities.Drop, "ALL") {
+			reasons = append(reasons, fmt.Sprintf("container %s must drop ALL capabilities", container.Name))
+		}
+		if runAsUser, runAsNonRoot := effectiveUser(pod, container); (runAsUser != nil && *runAsUser == 0) || runAsNonRoot == nil || !*runAsNonRoot {
+			reasons = append(reasons, fmt.Sprintf("container %s must set runAsNonRoot=true", container.Name))
+		}
+		if !seccompConfined(pod, container) {
+			reasons = append(reasons, fmt.Sprintf("container %s must set a RuntimeDefault or Localhost seccomp profile", container.Name))
+		}
+	}
+	return reasons
+}
+
+// psaLevel returns the strictest mode's level from namespace labels,
+// preferring enforce over warn over audit
+func psaLevel(labels map[string]string) (string, string) {
+	for _, mode := range []string{"enforce", "warn", "audit"} {
+		if level, ok := labels[psaLabelPrefix+mode]; ok {
+			return level, mode
+		}
+	}
+	return "", ""
+}
+
+// podContainers returns init and regular containers
+func podContainers(pod *corev1.Pod) []corev1.Container {
+	containers := make([]corev1.Container, 0, len(pod.Spec.InitContainers)+len(pod.Spec.Containers))
+	containers = append(containers, pod.Spec.InitContainers...)
+	return append(containers, pod.Spec.Containers...)
+}
+
+// effectiveUser applies the container security context over the pod's
+func effectiveUser(pod *corev1.Pod, container corev1.Container) (*int64, *bool) {
+	var runAsUser *int64
+	var runAsNonRoot *bool
+	if psc := pod.Spec.SecurityContext; psc != nil {
+		runAsUser, runAsNonRoot = psc.RunAsUser, psc.RunAsNonRoot
+	}
+	if sc := container.SecurityContext; sc != nil {
+		if sc.RunAsUser != nil {
+			runAsUser = sc.RunAsUser
+		}
+		if sc.RunAsNonRoot != nil {
+			runAsNonRoot = sc.RunAsNonRoot
+		}
+	}
+	return runAsUser, runAsNonRoot
+}
+
+func seccompConfined(pod *corev1.Pod, container corev1.Container) bool {
+	var profile *corev1.SeccompProfile
+	if psc := pod.Spec.SecurityContext; psc != nil {
+		profile = psc.SeccompProfile
+	}
+	if sc := container.SecurityContext; sc != nil && sc.SeccompProfile != nil {
+		profile = sc.SeccompProfile
+	}
+	return profile != nil && (profile.Type == corev1.SeccompProfileTypeRuntimeDefault || profile.Type == corev1.SeccompProfileTypeLocalhost)
+}
+
+// automountsToken reports whether a service account token is mounted. The
+// service account's own automount setting is not visible from the pod, so
+// only the pod field is honored.
+func automountsToken(pod *corev1.Pod) bool {
+	if pod.Spec.AutomountServiceAccountToken != nil {
+		return *pod.Spec.AutomountServiceAccountToken
+	}
+	return true
+}
+
+func serviceAccountName(pod *corev1.Pod) string {
+	if pod.Spec.ServiceAccountName != "" {
+		return pod.Spec.ServiceAccountName
+	}
+	return "default"
+}
+
+func containsCapability(caps []corev1.Capability, want corev1.Capability) bool {
+	for _, capability := range caps {
+		if capability == want {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteSecurityCSV writes one row per finding
+func WriteSecurityCSV(w io.Writer, report *SecurityReport) error {
+	scores := make(map[string]int, len(report.Namespaces))
+	for _, ns := range report.Namespaces {
+		scores[ns.Namespace] = ns.Score
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"namespace", "namespace_score", "pod", "container", "check", "severity", "message"}); err != nil {
+		return err
+	}
+	for _, f := range report.Findings {
+		row := []string{f.Namespace, strconv.Itoa(scores[f.Namespace]), f.Pod, f.Container, f.Check, f.Severity, f.Message}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
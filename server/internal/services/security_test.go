@@ -0,0 +1,160 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func securityChecks(findings []SecurityFinding) map[string]string {
+	checks := make(map[string]string)
+	for _, f := range findings {
+		checks[f.Check] = f.Severity
+	}
+	return checks
+}
+
+func hardenedPod(namespace, name string) corev1.Pod {
+	yes, no := true, false
+	uid := int64(1000)
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: corev1.PodSpec{
+			AutomountServiceAccountToken: &no,
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsNonRoot:   &yes,
+				RunAsUser:      &uid,
+				SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+			},
+			Containers: []corev1.Container{{
+				Name: "app",
+				SecurityContext: &corev1.SecurityContext{
+					AllowPrivilegeEscalation: &no,
+					ReadOnlyRootFilesystem:   &yes,
+					Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+				},
+			}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+func TestScanPodSecurity(t *testing.T) {
+	pod := hardenedPod("default", "good")
+	if findings := ScanPodSecurity(&pod); len(findings) != 0 {
+		t.Fatalf("expected hardened pod to pass, got %+v", findings)
+	}
+
+	yes := true
+	root := int64(0)
+	risky := hardenedPod("default", "risky")
+	risky.Spec.HostNetwork = true
+	risky.Spec.HostPID = true
+	risky.Spec.AutomountServiceAccountToken = nil
+	risky.Spec.Volumes = []corev1.Volume{{Name: "docker", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/var/run/docker.sock"}}}}
+	sc := risky.Spec.Containers[0].SecurityContext
+	sc.Privileged = &yes
+	sc.RunAsUser = &root
+	sc.ReadOnlyRootFilesystem = nil
+	sc.Capabilities.Add = []corev1.Capability{"SYS_ADMIN"}
+
+	checks := securityChecks(ScanPodSecurity(&risky))
+	want := map[string]string{
+		SecurityPrivileged:       "critical",
+		SecurityHostNetwork:      "warning",
+		SecurityHostPID:          "critical",
+		SecurityHostPath:         "warning",
+		SecurityRunAsRoot:        "critical",
+		SecurityWritableRootFS:   "warning",
+		SecurityAddedCaps:        "warning",
+		SecurityServiceAcctToken: "info",
+	}
+	for check, severity := range want {
+		if checks[check] != severity {
+			t.Errorf("expected %s with severity %s, got %v", check, severity, checks)
+		}
+	}
+
+	// No user settings at all: may run as root
+	bare := corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}}}
+	if checks := securityChecks(ScanPodSecurity(&bare)); checks[SecurityRunAsRoot] != "warning" {
+		t.Errorf("expected run-as-root warning for unset user, got %v", checks)
+	}
+}
+
+func TestCheckPodSecurityLevel(t *testing.T) {
+	pod := hardenedPod("default", "good")
+	if reasons := CheckPodSecurityLevel(&pod, PSARestricted); len(reasons) != 0 {
+		t.Errorf("hardened pod should meet restricted, got %v", reasons)
+	}
+
+	plain := corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{
+		Name:  "app",
+		Ports: []corev1.ContainerPort{{ContainerPort: 80}},
+	}}}}
+	if reasons := CheckPodSecurityLevel(&plain, PSABaseline); len(reasons) != 0 {
+		t.Errorf("plain pod should meet baseline, got %v", reasons)
+	}
+	if reasons := CheckPodSecurityLevel(&plain, PSARestricted); len(reasons) != 4 {
+		t.Errorf("expected 4 restricted violations, got %v", reasons)
+	}
+
+	plain.Spec.HostNetwork = true
+	plain.Spec.Containers[0].Ports[0].HostPort = 8080
+	reasons := CheckPodSecurityLevel(&plain, PSABaseline)
+	if len(reasons) != 2 || !strings.Contains(reasons[1], "host port 8080") {
+		t.Errorf("unexpected baseline violations %v", reasons)
+	}
+	if reasons := CheckPodSecurityLevel(&plain, PSAPrivileged); len(reasons) != 0 {
+		t.Errorf("privileged level should allow everything, got %v", reasons)
+	}
+}
+
+func TestBuildSecurityReport(t *testing.T) {
+	good := hardenedPod("secure", "good")
+	yes := true
+	privileged := hardenedPod("legacy", "privileged")
+	privileged.Spec.Containers[0].SecurityContext.Privileged = &yes
+	done := hardenedPod("legacy", "done")
+	done.Spec.HostPID = true
+	done.Status.Phase = corev1.PodSucceeded
+
+	namespaces := []corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "secure", Labels: map[string]string{"pod-security.kubernetes.io/enforce": "restricted"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "legacy", Labels: map[string]string{"pod-security.kubernetes.io/warn": "baseline"}}},
+	}
+
+	report := BuildSecurityReport([]corev1.Pod{good, privileged, done}, namespaces)
+	if len(report.Namespaces) != 2 {
+		t.Fatalf("expected 2 namespaces, got %+v", report.Namespaces)
+	}
+	legacy, secure := report.Namespaces[0], report.Namespaces[1]
+	if legacy.Namespace != "legacy" || legacy.Score != 60 || legacy.Pods != 1 || legacy.Critical != 1 {
+		t.Errorf("unexpected legacy summary %+v", legacy)
+	}
+	if legacy.PSALevel != PSABaseline || legacy.PSAMode != "warn" || legacy.PSAViolations != 1 {
+		t.Errorf("unexpected legacy PSA summary %+v", legacy)
+	}
+	if secure.Score != 100 || secure.PSAViolations != 0 {
+		t.Errorf("unexpected secure summary %+v", secure)
+	}
+	if report.Count != 1 || len(report.PSAViolations) != 1 || report.PSAViolations[0].Pod != "privileged" {
+		t.Errorf("unexpected findings %+v / %+v", report.Findings, report.PSAViolations)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSecurityCSV(&buf, report); err != nil {
+		t.Fatalf("WriteSecurityCSV failed: %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if len(rows) != 2 || rows[1][0] != "legacy" || rows[1][1] != "60" || rows[1][4] != SecurityPrivileged {
+		t.Errorf("unexpected CSV rows %v", rows)
+	}
+}
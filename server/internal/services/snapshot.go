@@ -0,0 +1,84 @@
+// internal/services/snapshot.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ClusterSnapshot is a point-in-time copy of the cluster objects the
+// visualizer shows. It serializes to a self-contained JSON document.
+type ClusterSnapshot struct {
+	Time        time.Time           `json:"time"`
+	Namespaces  []corev1.Namespace  `json:"namespaces"`
+	Nodes       []corev1.Node       `json:"nodes"`
+	Pods        []corev1.Pod        `json:"pods"`
+	Deployments []appsv1.Deployment `json:"deployments"`
+	Events      []corev1.Event      `json:"events"`
+}
+
+// CaptureSnapshot lists namespaces, nodes, pods, deployments and events
+// across all namespaces
+func CaptureSnapshot(ctx context.Context, clientset kubernetes.Interface) (*ClusterSnapshot, error) {
+	namespaces, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	pods, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	deployments, err := clientset.AppsV1().Deployments("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+
+	events, err := clientset.CoreV1().Events("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+
+	snapshot := &ClusterSnapshot{
+		Time:        time.Now().UTC(),
+		Namespaces:  namespaces.Items,
+		Nodes:       nodes.Items,
+		Pods:        pods.Items,
+		Deployments: deployments.Items,
+		Events:      events.Items,
+	}
+	snapshot.stripManagedFields()
+	return snapshot, nil
+}
+
+// stripManagedFields drops server-side apply bookkeeping, which is large
+// and not useful when looking at past state
+func (s *ClusterSnapshot) stripManagedFields() {
+	for i := range s.Namespaces {
+		s.Namespaces[i].ManagedFields = nil
+	}
+	for i := range s.Nodes {
+		s.Nodes[i].ManagedFields = nil
+	}
+	for i := range s.Pods {
+		s.Pods[i].ManagedFields = nil
+	}
+	for i := range s.Deployments {
+		s.Deployments[i].ManagedFields = nil
+	}
+	for i := range s.Events {
+		s.Events[i].ManagedFields = nil
+	}
+}
@@ -0,0 +1,374 @@
+// internal/services/storage.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// defaultStorageClassAnnotation marks the cluster's default StorageClass
+const defaultStorageClassAnnotation = "storageclass.kubernetes.io/is-default-class"
+
+// StorageEvent is an event recorded against a claim while it provisions
+type StorageEvent struct {
+	Time    time.Time `json:"time"`
+	Type    string    `json:"type"`
+	Reason  string    `json:"reason"`
+	Message string    `json:"message"`
+}
+
+// ClaimInfo is a PersistentVolumeClaim with the pods mounting it. Events
+// are only collected for pending claims.
+type ClaimInfo struct {
+	Name         string         `json:"name"`
+	Namespace    string         `json:"namespace"`
+	Status       string         `json:"status"`
+	Volume       string         `json:"volume,omitempty"`
+	StorageClass string         `json:"storage_class,omitempty"`
+	Requested    string         `json:"requested"`
+	Capacity     string         `json:"capacity,omitempty"`
+	AccessModes  []string       `json:"access_modes"`
+	VolumeMode   string         `json:"volume_mode,omitempty"`
+	Pods         []string       `json:"pods"`
+	Pending      bool           `json:"pending"`
+	Events       []StorageEvent `json:"events,omitempty"`
+}
+
+// VolumeInfo is a PersistentVolume with its claim and the pods mounting it.
+// NodeAffinity lists the required node selector terms, which pin local
+// volumes to a node.
+type VolumeInfo struct {
+	Name          string   `json:"name"`
+	Status        string   `json:"status"`
+	Capacity      string   `json:"capacity"`
+	AccessModes   []string `json:"access_modes"`
+	ReclaimPolicy string   `json:"reclaim_policy"`
+	StorageClass  string   `json:"storage_class,omitempty"`
+	Claim         string   `json:"claim,omitempty"`
+	Source        string   `json:"source"`
+	Local         bool     `json:"local"`
+	NodeAffinity  []string `json:"node_affinity,omitempty"`
+	Pods          []string `json:"pods"`
+}
+
+// StorageClassInfo is a StorageClass with the volumes and claims using it
+type StorageClassInfo struct {
+	Name                 string            `json:"name"`
+	Provisioner          string            `json:"provisioner"`
+	ReclaimPolicy        string            `json:"reclaim_policy"`
+	VolumeBindingMode    string            `json:"volume_binding_mode"`
+	AllowVolumeExpansion bool              `json:"allow_volume_expansion"`
+	Default              bool              `json:"default"`
+	Parameters           map[string]string `json:"parameters,omitempty"`
+	Volumes              int               `json:"volumes"`
+	Claims               int               `json:"claims"`
+}
+
+// ListPersistentVolumeClaims lists claims in namespace (all when empty) with
+// the pods mounting them, and the events of pending claims
+func ListPersistentVolumeClaims(ctx context.Context, clientset kubernetes.Interface, namespace string) ([]ClaimInfo, error) {
+	claims, err := clientset.CoreV1().PersistentVolumeClaims(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list persistent volume claims: %w", err)
+	}
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	var events []corev1.Event
+	for _, claim := range claims.Items {
+		if claim.Status.Phase == corev1.ClaimPending {
+			list, err := clientset.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
+				FieldSelector: "involvedObject.kind=PersistentVolumeClaim",
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to list events: %w", err)
+			}
+			events = list.Items
+			break
+		}
+	}
+
+	return BuildClaimInfos(claims.Items, pods.Items, events), nil
+}
+
+// BuildClaimInfos resolves claims against the pods mounting them and
+// attaches events to pending claims
+func BuildClaimInfos(claims []corev1.PersistentVolumeClaim, pods []corev1.Pod, events []corev1.Event) []ClaimInfo {
+	mounts := claimPods(pods)
+	infos := make([]ClaimInfo, 0, len(claims))
+	for _, claim := range claims {
+		info := ClaimInfo{
+			Name:        claim.Name,
+			Namespace:   claim.Namespace,
+			Status:      string(claim.Status.Phase),
+			Volume:      claim.Spec.VolumeName,
+			Requested:   claim.Spec.Resources.Requests.Storage().String(),
+			AccessModes: accessModes(claim.Spec.AccessModes),
+			Pods:        mounts[claim.Namespace+"/"+claim.Name],
+			Pending:     claim.Status.Phase == corev1.ClaimPending,
+		}
+		if info.Pods == nil {
+			info.Pods = make([]string, 0)
+		}
+		if claim.Spec.StorageClassName != nil {
+			info.StorageClass = *claim.Spec.StorageClassName
+		}
+		if claim.Spec.VolumeMode != nil {
+			info.VolumeMode = string(*claim.Spec.VolumeMode)
+		}
+		if capacity, ok := claim.Status.Capacity[corev1.ResourceStorage]; ok {
+			info.Capacity = capacity.String()
+		}
+		if info.Pending {
+			info.Events = claimEvents(&claim, events)
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Namespace != infos[j].Namespace {
+			return infos[i].Namespace < infos[j].Namespace
+		}
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
+
+// claimEvents returns the claim's events, oldest first
+func claimEvents(claim *corev1.PersistentVolumeClaim, events []corev1.Event) []StorageEvent {
+	result := make([]StorageEvent, 0)
+	for i := range events {
+		event := &events[i]
+		if event.InvolvedObject.Kind != "PersistentVolumeClaim" || event.InvolvedObject.Namespace != claim.Namespace || event.InvolvedObject.Name != claim.Name {
+			continue
+		}
+		message := event.Message
+		if event.Count > 1 {
+			message = fmt.Sprintf("%s (x%d)", message, event.Count)
+		}
+		result = append(result, StorageEvent{Time: eventTime(event), Type: event.Type, Reason: event.Reason, Message: message})
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Time.Before(result[j].Time) })
+	return result
+}
+
+// ListPersistentVolumes lists volumes with their claims and the pods
+// mounting them
+func ListPersistentVolumes(ctx context.Context, clientset kubernetes.Interface) ([]VolumeInfo, error) {
+	volumes, err := clientset.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list persistent volumes: %w", err)
+	}
+	pods, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	return BuildVolumeInfos(volumes.Items, pods.Items), nil
+}
+
+// BuildVolumeInfos resolves volumes against their claims' pods
+func BuildVolumeInfos(volumes []corev1.PersistentVolume, pods []corev1.Pod) []VolumeInfo {
+	mounts := claimPods(pods)
+	infos := make([]VolumeInfo, 0, len(volumes))
+	for _, volume := range volumes {
+		info := VolumeInfo{
+			Name:          volume.Name,
+			Status:        string(volume.Status.Phase),
+			Capacity:      volume.Spec.Capacity.Storage().String(),
+			AccessModes:   accessModes(volume.Spec.AccessModes),
+			ReclaimPolicy: string(volume.Spec.PersistentVolumeReclaimPolicy),
+			StorageClass:  volume.Spec.StorageClassName,
+			Source:        volumeSource(&volume.Spec.PersistentVolumeSource),
+			Local:         volume.Spec.Local != nil || volume.Spec.HostPath != nil,
+			Pods:          make([]string, 0),
+		}
+		if ref := volume.Spec.ClaimRef; ref != nil {
+			info.Claim = ref.Namespace + "/" + ref.Name
+			for _, pod := range mounts[info.Claim] {
+				info.Pods = append(info.Pods, ref.Namespace+"/"+pod)
+			}
+		}
+		if volume.Spec.NodeAffinity != nil && volume.Spec.NodeAffinity.Required != nil {
+			for _, term := range volume.Spec.NodeAffinity.Required.NodeSelectorTerms {
+				info.NodeAffinity = append(info.NodeAffinity, formatNodeSelectorTerm(term))
+			}
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// ListStorageClasses lists StorageClasses with how many volumes and claims
+// use each
+func ListStorageClasses(ctx context.Context, clientset kubernetes.Interface) ([]StorageClassInfo, error) {
+	classes, err := clientset.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list storage classes: %w", err)
+	}
+	volumes, err := clientset.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list persistent volumes: %w", err)
+	}
+	claims, err := clientset.CoreV1().PersistentVolumeClaims("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list persistent volume claims: %w", err)
+	}
+	return BuildStorageClassInfos(classes.Items, volumes.Items, claims.Items), nil
+}
+
+// BuildStorageClassInfos counts volumes and claims per class. Claims without
+// a class count against the default class, which is what they provision from.
+func BuildStorageClassInfos(classes []storagev1.StorageClass, volumes []corev1.PersistentVolume, claims []corev1.PersistentVolumeClaim) []StorageClassInfo {
+	infos := make([]StorageClassInfo, 0, len(classes))
+	index := make(map[string]int, len(classes))
+	defaultClass := ""
+	for _, class := range classes {
+		info := StorageClassInfo{
+			Name:                 class.Name,
+			Provisioner:          class.Provisioner,
+			ReclaimPolicy:        string(corev1.PersistentVolumeReclaimDelete),
+			VolumeBindingMode:    string(storagev1.VolumeBindingImmediate),
+			AllowVolumeExpansion: class.AllowVolumeExpansion != nil && *class.AllowVolumeExpansion,
+			Default:              class.Annotations[defaultStorageClassAnnotation] == "true",
+			Parameters:           class.Parameters,
+		}
+		if class.ReclaimPolicy != nil {
+			info.ReclaimPolicy = string(*class.ReclaimPolicy)
+		}
+		if class.VolumeBindingMode != nil {
+			info.VolumeBindingMode = string(*class.VolumeBindingMode)
+		}
+		if info.Default {
+			defaultClass = class.Name
+		}
+		index[class.Name] = len(infos)
+		infos = append(infos, info)
+	}
+
+	for _, volume := range volumes {
+		if i, ok := index[volume.Spec.StorageClassName]; ok {
+			infos[i].Volumes++
+		}
+	}
+	for _, claim := range claims {
+		class := defaultClass
+		if claim.Spec.StorageClassName != nil {
+			class = *claim.Spec.StorageClassName
+		}
+		if i, ok := index[class]; ok {
+			infos[i].Claims++
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// claimPods maps namespace/claim to the names of non-terminal pods
+// mounting it
+func claimPods(pods []corev1.Pod) map[string][]string {
+	mounts := make(map[string][]string)
+	for i := range pods {
+		pod := &pods[i]
+		if podIsTerminal(pod) {
+			continue
+		}
+		for _, volume := range pod.Spec.Volumes {
+			claim := ""
+			switch {
+			case volume.PersistentVolumeClaim != nil:
+				claim = volume.PersistentVolumeClaim.ClaimName
+			case volume.Ephemeral != nil:
+				// Generic ephemeral volumes create a claim named pod-volume
+				claim = pod.Name + "-" + volume.Name
+			default:
+				continue
+			}
+			key := pod.Namespace + "/" + claim
+			mounts[key] = append(mounts[key], pod.Name)
+		}
+	}
+	for _, names := range mounts {
+		sort.Strings(names)
+	}
+	return mounts
+}
+
+// namespaceStorageRequests sums the storage requested by claims per
+// namespace
+func namespaceStorageRequests(claims []corev1.PersistentVolumeClaim) map[string]*resource.Quantity {
+	totals := make(map[string]*resource.Quantity)
+	for _, claim := range claims {
+		total, ok := totals[claim.Namespace]
+		if !ok {
+			total = resource.NewQuantity(0, resource.BinarySI)
+			totals[claim.Namespace] = total
+		}
+		total.Add(*claim.Spec.Resources.Requests.Storage())
+	}
+	return totals
+}
+
+func accessModes(modes []corev1.PersistentVolumeAccessMode) []string {
+	result := make([]string, 0, len(modes))
+	for _, mode := range modes {
+		result = append(result, string(mode))
+	}
+	return result
+}
+
+// volumeSource names the plugin backing a volume, the driver for CSI
+func volumeSource(source *corev1.PersistentVolumeSource) string {
+	switch {
+	case source.CSI != nil:
+		return "csi:" + source.CSI.Driver
+	case source.Local != nil:
+		return "local:" + source.Local.Path
+	case source.HostPath != nil:
+		return "hostPath:" + source.HostPath.Path
+	case source.NFS != nil:
+		return "nfs:" + source.NFS.Server + ":" + source.NFS.Path
+	case source.AWSElasticBlockStore != nil:
+		return "awsElasticBlockStore"
+	case source.GCEPersistentDisk != nil:
+		return "gcePersistentDisk"
+	case source.AzureDisk != nil:
+		return "azureDisk"
+	case source.AzureFile != nil:
+		return "azureFile"
+	case source.ISCSI != nil:
+		return "iscsi"
+	case source.FC != nil:
+		return "fc"
+	case source.RBD != nil:
+		return "rbd"
+	case source.CephFS != nil:
+		return "cephfs"
+	}
+	return "other"
+}
+
+// formatNodeSelectorTerm renders a term as "key In [a b], key Exists"
+func formatNodeSelectorTerm(term corev1.NodeSelectorTerm) string {
+	parts := make([]string, 0, len(term.MatchExpressions)+len(term.MatchFields))
+	for _, requirements := range [][]corev1.NodeSelectorRequirement{term.MatchExpressions, term.MatchFields} {
+		for _, requirement := range requirements {
+			part := requirement.Key + " " + string(requirement.Operator)
+			if len(requirement.Values) > 0 {
+				part += " [" + strings.Join(requirement.Values, " ") + "]"
+			}
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestStorageViews(t *testing.T) {
+	fast, local := "fast", "local"
+	claim := func(name, class string, size string, phase corev1.PersistentVolumeClaimPhase, volume string) *corev1.PersistentVolumeClaim {
+		c := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop"},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				Resources:   corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)}},
+				VolumeName:  volume,
+			},
+			Status: corev1.PersistentVolumeClaimStatus{Phase: phase},
+		}
+		if class != "" {
+			c.Spec.StorageClassName = &class
+		}
+		if phase == corev1.ClaimBound {
+			c.Status.Capacity = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)}
+		}
+		return c
+	}
+	pod := func(name, claimName string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop"},
+			Spec: corev1.PodSpec{Volumes: []corev1.Volume{{
+				Name:         "data",
+				VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claimName}},
+			}}},
+			Status: corev1.PodStatus{Phase: phase},
+		}
+	}
+	event := func(reason, message string, at time.Time) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "cache." + reason, Namespace: "shop"},
+			InvolvedObject: corev1.ObjectReference{Kind: "PersistentVolumeClaim", Namespace: "shop", Name: "cache"},
+			Type:           corev1.EventTypeWarning,
+			Reason:         reason,
+			Message:        message,
+			LastTimestamp:  metav1.NewTime(at),
+		}
+	}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	clientset := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}},
+		claim("data", "local", "10Gi", corev1.ClaimBound, "pv-local"),
+		claim("cache", "fast", "5Gi", corev1.ClaimPending, ""),
+		claim("scratch", "", "1Gi", corev1.ClaimBound, "pv-default"),
+		pod("db-0", "data", corev1.PodRunning),
+		pod("db-old", "data", corev1.PodSucceeded),
+		pod("cache-0", "cache", corev1.PodPending),
+		event("ProvisioningFailed", "quota exceeded", now.Add(time.Minute)),
+		event("ExternalProvisioning", "waiting for provisioner", now),
+		&corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv-local"},
+			Spec: corev1.PersistentVolumeSpec{
+				Capacity:                      corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")},
+				AccessModes:                   []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimRetain,
+				StorageClassName:              "local",
+				ClaimRef:                      &corev1.ObjectReference{Namespace: "shop", Name: "data"},
+				PersistentVolumeSource:        corev1.PersistentVolumeSource{Local: &corev1.LocalVolumeSource{Path: "/mnt/disks/ssd1"}},
+				NodeAffinity: &corev1.VolumeNodeAffinity{Required: &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+					MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "kubernetes.io/hostname", Operator: corev1.NodeSelectorOpIn, Values: []string{"node-1"}}},
+				}}}},
+			},
+			Status: corev1.PersistentVolumeStatus{Phase: corev1.VolumeBound},
+		},
+		&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: fast, Annotations: map[string]string{defaultStorageClassAnnotation: "true"}}, Provisioner: "ebs.csi.aws.com"},
+		&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: local}, Provisioner: "kubernetes.io/no-provisioner"},
+	)
+	ctx := context.Background()
+
+	claims, err := ListPersistentVolumeClaims(ctx, clientset, "shop")
+	if err != nil {
+		t.Fatalf("ListPersistentVolumeClaims failed: %v", err)
+	}
+	if len(claims) != 3 || claims[0].Name != "cache" || claims[1].Name != "data" {
+		t.Fatalf("unexpected claims %+v", claims)
+	}
+	cache, data := claims[0], claims[1]
+	if !cache.Pending || len(cache.Events) != 2 || cache.Events[0].Reason != "ExternalProvisioning" || cache.Capacity != "" {
+		t.Errorf("unexpected pending claim %+v", cache)
+	}
+	if data.Pending || data.Events != nil || data.Capacity != "10Gi" || len(data.Pods) != 1 || data.Pods[0] != "db-0" {
+		t.Errorf("unexpected bound claim %+v", data)
+	}
+
+	volumes, err := ListPersistentVolumes(ctx, clientset)
+	if err != nil {
+		t.Fatalf("ListPersistentVolumes failed: %v", err)
+	}
+	if len(volumes) != 1 || !volumes[0].Local || volumes[0].Source != "local:/mnt/disks/ssd1" || volumes[0].ReclaimPolicy != "Retain" {
+		t.Fatalf("unexpected volumes %+v", volumes)
+	}
+	if len(volumes[0].NodeAffinity) != 1 || volumes[0].NodeAffinity[0] != "kubernetes.io/hostname In [node-1]" || volumes[0].Pods[0] != "shop/db-0" {
+		t.Errorf("unexpected volume affinity or pods %+v", volumes[0])
+	}
+
+	classes, err := ListStorageClasses(ctx, clientset)
+	if err != nil {
+		t.Fatalf("ListStorageClasses failed: %v", err)
+	}
+	if len(classes) != 2 || !classes[0].Default || classes[0].Claims != 2 || classes[1].Volumes != 1 || classes[1].ReclaimPolicy != "Delete" {
+		t.Errorf("unexpected storage classes %+v", classes)
+	}
+
+	client := &K8sClient{clientset: clientset}
+	metrics, err := client.GetClusterMetrics(ctx)
+	if err != nil {
+		t.Fatalf("GetClusterMetrics failed: %v", err)
+	}
+	if len(metrics.NamespaceMetrics) != 1 || metrics.NamespaceMetrics[0].StorageRequests != "16Gi" || metrics.NamespaceMetrics[0].ClaimCount != 3 {
+		t.Errorf("unexpected namespace metrics %+v", metrics.NamespaceMetrics)
+	}
+	namespace, err := client.GetNamespaceMetrics(ctx, "shop")
+	if err != nil || namespace.StorageRequests != "16Gi" {
+		t.Errorf("unexpected namespace metrics %+v, %v", namespace, err)
+	}
+}
+
+func TestMetrics_ClaimListErrorLeavesStorageEmpty(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}},
+		&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "shop"}},
+	)
+	clientset.PrependReactor("list", "persistentvolumeclaims", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("forbidden")
+	})
+	ctx := context.Background()
+	client := &K8sClient{clientset: clientset}
+
+	metrics, err := client.GetClusterMetrics(ctx)
+	if err != nil {
+		t.Fatalf("GetClusterMetrics failed: %v", err)
+	}
+	if len(metrics.NamespaceMetrics) != 1 || metrics.NamespaceMetrics[0].StorageRequests != "" || metrics.NamespaceMetrics[0].ClaimCount != 0 {
+		t.Errorf("unexpected namespace metrics %+v", metrics.NamespaceMetrics)
+	}
+	namespace, err := client.GetNamespaceMetrics(ctx, "shop")
+	if err != nil || namespace.StorageRequests != "" || namespace.ClaimCount != 0 {
+		t.Errorf("unexpected namespace metrics %+v, %v", namespace, err)
+	}
+}
@@ -0,0 +1,458 @@
+// internal/services/timeline.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ErrUnsupportedKind is returned for kinds the timeline does not track
+var ErrUnsupportedKind = errors.New("unsupported kind, expected pod, deployment or node")
+
+// Timeline entry sources
+const (
+	TimelineSourceEvent    = "event"    // Kubernetes Event for the object
+	TimelineSourceWatch    = "watch"    // Transition observed on the watch stream
+	TimelineSourceRevision = "revision" // New ReplicaSet revision of a deployment
+)
+
+// TimelineEntry is one point in an object's history
+type TimelineEntry struct {
+	Time    time.Time `json:"time"`
+	Source  string    `json:"source"`
+	Type    string    `json:"type"` // "Normal" or "Warning"
+	Reason  string    `json:"reason"`
+	Message string    `json:"message"`
+}
+
+// objectHistory is the bounded list of watch-observed entries for one object
+type objectHistory struct {
+	entries []TimelineEntry
+	updated time.Time
+}
+
+// Timeline records transitions of pods, deployments and nodes from
+// informers and keeps a bounded history per object
+type Timeline struct {
+	clientset  kubernetes.Interface
+	factory    informers.SharedInformerFactory
+	maxEntries int
+	maxObjects int
+	now        func() time.Time
+
+	mu      sync.RWMutex
+	objects map[string]*objectHistory
+}
+
+// NewTimeline creates a timeline keeping at most maxEntries per object and
+// maxObjects objects. Its informers come from factory, which may be shared
+// with other watchers; the owner of factory shuts it down. Call Run to start
+// watching.
+func NewTimeline(clientset kubernetes.Interface, factory informers.SharedInformerFactory, maxEntries, maxObjects int) *Timeline {
+	t := &Timeline{
+		clientset:  clientset,
+		factory:    factory,
+		maxEntries: maxEntries,
+		maxObjects: maxObjects,
+		now:        time.Now,
+		objects:    make(map[string]*objectHistory),
+	}
+
+	t.factory.Core().V1().Pods().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if pod, ok := obj.(*corev1.Pod); ok {
+				t.recordCreated("Pod", &pod.ObjectMeta)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldPod, ok1 := oldObj.(*corev1.Pod)
+			newPod, ok2 := newObj.(*corev1.Pod)
+			if ok1 && ok2 {
+				t.record("Pod", newPod.Namespace, newPod.Name, podTransitions(oldPod, newPod, t.now())...)
+			}
+		},
+		DeleteFunc: func(obj interface{}) { t.recordDeleted("Pod", obj) },
+	})
+	t.factory.Apps().V1().Deployments().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if deployment, ok := obj.(*appsv1.Deployment); ok {
+				t.recordCreated("Deployment", &deployment.ObjectMeta)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldDeployment, ok1 := oldObj.(*appsv1.Deployment)
+			newDeployment, ok2 := newObj.(*appsv1.Deployment)
+			if ok1 && ok2 {
+				t.record("Deployment", newDeployment.Namespace, newDeployment.Name, deploymentTransitions(oldDeployment, newDeployment, t.now())...)
+			}
+		},
+		DeleteFunc: func(obj interface{}) { t.recordDeleted("Deployment", obj) },
+	})
+	t.factory.Apps().V1().ReplicaSets().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if rs, ok := obj.(*appsv1.ReplicaSet); ok {
+				t.recordRevision(nil, rs)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldRS, ok1 := oldObj.(*appsv1.ReplicaSet)
+			newRS, ok2 := newObj.(*appsv1.ReplicaSet)
+			if ok1 && ok2 {
+				t.recordRevision(oldRS, newRS)
+			}
+		},
+	})
+	t.factory.Core().V1().Nodes().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if node, ok := obj.(*corev1.Node); ok {
+				t.recordCreated("Node", &node.ObjectMeta)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldNode, ok1 := oldObj.(*corev1.Node)
+			newNode, ok2 := newObj.(*corev1.Node)
+			if ok1 && ok2 {
+				t.record("Node", "", newNode.Name, nodeTransitions(oldNode, newNode, t.now())...)
+			}
+		},
+		DeleteFunc: func(obj interface{}) { t.recordDeleted("Node", obj) },
+	})
+
+	return t
+}
+
+// Run starts the informers and blocks until ctx is cancelled
+func (t *Timeline) Run(ctx context.Context) {
+	t.factory.Start(ctx.Done())
+	t.factory.WaitForCacheSync(ctx.Done())
+	<-ctx.Done()
+}
+
+// CacheSizes returns functions reporting the size of each informer cache
+func (t *Timeline) CacheSizes() map[string]func() int {
+	stores := map[string]cache.Store{
+		"pods":        t.factory.Core().V1().Pods().Informer().GetStore(),
+		"deployments": t.factory.Apps().V1().Deployments().Informer().GetStore(),
+		"replicasets": t.factory.Apps().V1().ReplicaSets().Informer().GetStore(),
+		"nodes":       t.factory.Core().V1().Nodes().Informer().GetStore(),
+	}
+	sizes := make(map[string]func() int, len(stores))
+	for name, store := range stores {
+		store := store
+		sizes[name] = func() int { return len(store.ListKeys()) }
+	}
+	return sizes
+}
+
+// NormalizeTimelineKind maps "pod", "pods" or "Pod" to the Kind name
+func NormalizeTimelineKind(kind string) (string, error) {
+	switch strings.TrimSuffix(strings.ToLower(kind), "s") {
+	case "pod":
+		return "Pod", nil
+	case "deployment":
+		return "Deployment", nil
+	case "node":
+		return "Node", nil
+	}
+	return "", ErrUnsupportedKind
+}
+
+// Get returns the object's recorded history merged with its Kubernetes
+// events, oldest first. Nodes are cluster scoped and take an empty namespace.
+func (t *Timeline) Get(ctx context.Context, kind, namespace, name string) ([]TimelineEntry, error) {
+	kind, err := NormalizeTimelineKind(kind)
+	if err != nil {
+		return nil, err
+	}
+	if kind == "Node" {
+		namespace = ""
+	}
+
+	t.mu.RLock()
+	entries := make([]TimelineEntry, 0)
+	if history, ok := t.objects[timelineKey(kind, namespace, name)]; ok {
+		entries = append(entries, history.entries...)
+	}
+	t.mu.RUnlock()
+
+	events, err := t.clientset.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: fmt.Sprintf("involvedObject.kind=%s,involvedObject.name=%s", kind, name),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	for _, event := range events.Items {
+		if event.InvolvedObject.Kind != kind || event.InvolvedObject.Name != name {
+			continue
+		}
+		message := event.Message
+		if event.Count > 1 {
+			message = fmt.Sprintf("%s (x%d)", message, event.Count)
+		}
+		entries = append(entries, TimelineEntry{
+			Time:    eventTime(&event),
+			Source:  TimelineSourceEvent,
+			Type:    event.Type,
+			Reason:  event.Reason,
+			Message: message,
+		})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+	return entries, nil
+}
+
+func timelineKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+// record appends entries to an object's history, trimming it to maxEntries
+// and evicting the least recently updated object when over maxObjects
+func (t *Timeline) record(kind, namespace, name string, entries ...TimelineEntry) {
+	if len(entries) == 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := timelineKey(kind, namespace, name)
+	history, ok := t.objects[key]
+	if !ok {
+		if len(t.objects) >= t.maxObjects {
+			t.evictOldest()
+		}
+		history = &objectHistory{}
+		t.objects[key] = history
+	}
+
+	history.entries = append(history.entries, entries...)
+	if over := len(history.entries) - t.maxEntries; over > 0 {
+		history.entries = append([]TimelineEntry(nil), history.entries[over:]...)
+	}
+	history.updated = t.now()
+}
+
+func (t *Timeline) evictOldest() {
+	var oldestKey string
+	var oldest time.Time
+	for key, history := range t.objects {
+		if oldestKey == "" || history.updated.Before(oldest) {
+			oldestKey, oldest = key, history.updated
+		}
+	}
+	delete(t.objects, oldestKey)
+}
+
+func (t *Timeline) recordCreated(kind string, meta *metav1.ObjectMeta) {
+	t.record(kind, meta.Namespace, meta.Name, TimelineEntry{
+		Time:    meta.CreationTimestamp.Time,
+		Source:  TimelineSourceWatch,
+		Type:    corev1.EventTypeNormal,
+		Reason:  "Created",
+		Message: fmt.Sprintf("%s created", kind),
+	})
+}
+
+func (t *Timeline) recordDeleted(kind string, obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	accessor, ok := obj.(metav1.Object)
+	if !ok {
+		return
+	}
+	t.record(kind, accessor.GetNamespace(), accessor.GetName(), TimelineEntry{
+		Time:    t.now(),
+		Source:  TimelineSourceWatch,
+		Type:    corev1.EventTypeNormal,
+		Reason:  "Deleted",
+		Message: fmt.Sprintf("%s deleted", kind),
+	})
+}
+
+// recordRevision adds an entry to the owning deployment when a ReplicaSet
+// appears with, or changes to, a new revision
+func (t *Timeline) recordRevision(oldRS, newRS *appsv1.ReplicaSet) {
+	revision := newRS.Annotations["deployment.kubernetes.io/revision"]
+	if revision == "" || (oldRS != nil && oldRS.Annotations["deployment.kubernetes.io/revision"] == revision) {
+		return
+	}
+	owner := metav1.GetControllerOf(newRS)
+	if owner == nil || owner.Kind != "Deployment" {
+		return
+	}
+
+	when := t.now()
+	if oldRS == nil {
+		when = newRS.CreationTimestamp.Time
+	}
+	images := make([]string, 0, len(newRS.Spec.Template.Spec.Containers))
+	for _, container := range newRS.Spec.Template.Spec.Containers {
+		images = append(images, container.Image)
+	}
+	t.record("Deployment", newRS.Namespace, owner.Name, TimelineEntry{
+		Time:    when,
+		Source:  TimelineSourceRevision,
+		Type:    corev1.EventTypeNormal,
+		Reason:  "NewRevision",
+		Message: fmt.Sprintf("Revision %s (%s) with images %s", revision, newRS.Name, strings.Join(images, ", ")),
+	})
+}
+
+// podTransitions describes the phase, scheduling, readiness, restart and
+// image changes between two versions of a pod, stamped with now
+func podTransitions(oldPod, newPod *corev1.Pod, now time.Time) []TimelineEntry {
+	entries := make([]TimelineEntry, 0)
+	add := func(eventType, reason, message string) {
+		entries = append(entries, TimelineEntry{Time: now, Source: TimelineSourceWatch, Type: eventType, Reason: reason, Message: message})
+	}
+
+	if oldPod.Spec.NodeName == "" && newPod.Spec.NodeName != "" {
+		add(corev1.EventTypeNormal, "Scheduled", "Assigned to node "+newPod.Spec.NodeName)
+	}
+	if oldPod.Status.Phase != newPod.Status.Phase {
+		message := fmt.Sprintf("Phase %s -> %s", orNone(string(oldPod.Status.Phase)), newPod.Status.Phase)
+		eventType := corev1.EventTypeNormal
+		if newPod.Status.Phase == corev1.PodFailed {
+			eventType = corev1.EventTypeWarning
+		}
+		if newPod.Status.Reason != "" {
+			message += ": " + newPod.Status.Reason
+		}
+		add(eventType, "PhaseChanged", message)
+	}
+	if wasReady, ready := isPodReady(oldPod), isPodReady(newPod); wasReady != ready {
+		if ready {
+			add(corev1.EventTypeNormal, "Ready", "Pod became ready")
+		} else {
+			add(corev1.EventTypeWarning, "NotReady", "Pod is no longer ready")
+		}
+	}
+
+	oldImages := make(map[string]string, len(oldPod.Spec.Containers))
+	for _, container := range oldPod.Spec.Containers {
+		oldImages[container.Name] = container.Image
+	}
+	for _, container := range newPod.Spec.Containers {
+		if before, ok := oldImages[container.Name]; ok && before != container.Image {
+			add(corev1.EventTypeNormal, "ImageChanged", fmt.Sprintf("Container %s: %s -> %s", container.Name, before, container.Image))
+		}
+	}
+
+	oldStatuses := make(map[string]corev1.ContainerStatus, len(oldPod.Status.ContainerStatuses))
+	for _, status := range oldPod.Status.ContainerStatuses {
+		oldStatuses[status.Name] = status
+	}
+	for _, status := range newPod.Status.ContainerStatuses {
+		before := oldStatuses[status.Name]
+		if status.RestartCount > before.RestartCount {
+			message := fmt.Sprintf("Container %s restarted (restarts: %d)", status.Name, status.RestartCount)
+			if last := status.LastTerminationState.Terminated; last != nil {
+				message += fmt.Sprintf(", last exit %d %s", last.ExitCode, last.Reason)
+			}
+			add(corev1.EventTypeWarning, "ContainerRestarted", message)
+		}
+		if waiting := status.State.Waiting; waiting != nil && waiting.Reason != "" &&
+			(before.State.Waiting == nil || before.State.Waiting.Reason != waiting.Reason) {
+			eventType := corev1.EventTypeNormal
+			if waiting.Reason != "ContainerCreating" && waiting.Reason != "PodInitializing" {
+				eventType = corev1.EventTypeWarning
+			}
+			add(eventType, "ContainerWaiting", fmt.Sprintf("Container %s waiting: %s", status.Name, waiting.Reason))
+		}
+	}
+	return entries
+}
+
+// deploymentTransitions describes scaling, image and availability changes,
+// stamped with now
+func deploymentTransitions(oldDeployment, newDeployment *appsv1.Deployment, now time.Time) []TimelineEntry {
+	entries := make([]TimelineEntry, 0)
+	add := func(eventType, reason, message string) {
+		entries = append(entries, TimelineEntry{Time: now, Source: TimelineSourceWatch, Type: eventType, Reason: reason, Message: message})
+	}
+
+	if !int32PtrEqual(oldDeployment.Spec.Replicas, newDeployment.Spec.Replicas) {
+		add(corev1.EventTypeNormal, "Scaled", fmt.Sprintf("Replicas %s -> %s", formatReplicas(oldDeployment.Spec.Replicas), formatReplicas(newDeployment.Spec.Replicas)))
+	}
+	before, after := deploymentImages(oldDeployment), deploymentImages(newDeployment)
+	for _, name := range sortedKeys(after) {
+		if before[name] != "" && before[name] != after[name] {
+			add(corev1.EventTypeNormal, "ImageChanged", fmt.Sprintf("Container %s: %s -> %s", name, before[name], after[name]))
+		}
+	}
+	if oldDeployment.Status.ReadyReplicas != newDeployment.Status.ReadyReplicas {
+		eventType := corev1.EventTypeNormal
+		if newDeployment.Status.ReadyReplicas < oldDeployment.Status.ReadyReplicas {
+			eventType = corev1.EventTypeWarning
+		}
+		add(eventType, "ReadyReplicasChanged", fmt.Sprintf("Ready replicas %d -> %d", oldDeployment.Status.ReadyReplicas, newDeployment.Status.ReadyReplicas))
+	}
+	return entries
+}
+
+// nodeTransitions describes readiness, pressure and cordon changes,
+// stamped with now
+func nodeTransitions(oldNode, newNode *corev1.Node, now time.Time) []TimelineEntry {
+	entries := make([]TimelineEntry, 0)
+	add := func(eventType, reason, message string) {
+		entries = append(entries, TimelineEntry{Time: now, Source: TimelineSourceWatch, Type: eventType, Reason: reason, Message: message})
+	}
+
+	if before, after := nodeReadyStatus(oldNode), nodeReadyStatus(newNode); before != after {
+		if after == "Ready" {
+			add(corev1.EventTypeNormal, "NodeReady", "Node became Ready")
+		} else {
+			add(corev1.EventTypeWarning, "NodeNotReady", "Node became NotReady")
+		}
+	}
+	if oldNode.Spec.Unschedulable != newNode.Spec.Unschedulable {
+		if newNode.Spec.Unschedulable {
+			add(corev1.EventTypeNormal, "Cordoned", "Node marked unschedulable")
+		} else {
+			add(corev1.EventTypeNormal, "Uncordoned", "Node marked schedulable")
+		}
+	}
+
+	oldConditions := make(map[corev1.NodeConditionType]corev1.ConditionStatus, len(oldNode.Status.Conditions))
+	for _, condition := range oldNode.Status.Conditions {
+		oldConditions[condition.Type] = condition.Status
+	}
+	for _, condition := range newNode.Status.Conditions {
+		if condition.Type == corev1.NodeReady || oldConditions[condition.Type] == condition.Status {
+			continue
+		}
+		if condition.Status == corev1.ConditionTrue {
+			add(corev1.EventTypeWarning, string(condition.Type), fmt.Sprintf("%s started", condition.Type))
+		} else if oldConditions[condition.Type] == corev1.ConditionTrue {
+			add(corev1.EventTypeNormal, string(condition.Type), fmt.Sprintf("%s resolved", condition.Type))
+		}
+	}
+	return entries
+}
+
+// eventTime picks the most specific timestamp an event carries
+func eventTime(event *corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	case !event.FirstTimestamp.IsZero():
+		return event.FirstTimestamp.Time
+	}
+	return event.CreationTimestamp.Time
+}
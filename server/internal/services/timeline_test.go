@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPodTransitions(t *testing.T) {
+	oldPod := &corev1.Pod{
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "web", Image: "nginx:1.24"}}},
+		Status: corev1.PodStatus{
+			Phase:             corev1.PodRunning,
+			Conditions:        []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "web", RestartCount: 1}},
+		},
+	}
+	newPod := oldPod.DeepCopy()
+	newPod.Spec.Containers[0].Image = "nginx:1.25"
+	newPod.Status.Conditions[0].Status = corev1.ConditionFalse
+	newPod.Status.ContainerStatuses[0].RestartCount = 2
+	newPod.Status.ContainerStatuses[0].LastTerminationState.Terminated = &corev1.ContainerStateTerminated{ExitCode: 137, Reason: "OOMKilled"}
+	newPod.Status.ContainerStatuses[0].State.Waiting = &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	reasons := make(map[string]string)
+	for _, entry := range podTransitions(oldPod, newPod, now) {
+		reasons[entry.Reason] = entry.Message
+		if !entry.Time.Equal(now) {
+			t.Fatalf("expected %s stamped %v, got %v", entry.Reason, now, entry.Time)
+		}
+	}
+	for _, reason := range []string{"NotReady", "ImageChanged", "ContainerRestarted", "ContainerWaiting"} {
+		if _, ok := reasons[reason]; !ok {
+			t.Fatalf("expected %s entry, got %v", reason, reasons)
+		}
+	}
+	if reasons["ContainerRestarted"] != "Container web restarted (restarts: 2), last exit 137 OOMKilled" {
+		t.Fatalf("unexpected restart message: %q", reasons["ContainerRestarted"])
+	}
+	if _, ok := reasons["PhaseChanged"]; ok {
+		t.Fatalf("phase did not change but got an entry")
+	}
+}
+
+func TestTimeline_BoundsHistory(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	timeline := NewTimeline(clientset, informers.NewSharedInformerFactory(clientset, 0), 3, 2)
+	for i := 0; i < 5; i++ {
+		timeline.record("Pod", "default", "web", TimelineEntry{Time: time.Unix(int64(i), 0), Reason: "PhaseChanged"})
+	}
+	entries, err := timeline.Get(context.Background(), "pods", "default", "web")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if len(entries) != 3 || entries[0].Time.Unix() != 2 {
+		t.Fatalf("expected the last 3 entries, got %+v", entries)
+	}
+
+	timeline.record("Pod", "default", "api", TimelineEntry{Reason: "Created"})
+	timeline.record("Pod", "default", "worker", TimelineEntry{Reason: "Created"})
+	if len(timeline.objects) != 2 {
+		t.Fatalf("expected object count bounded to 2, got %d", len(timeline.objects))
+	}
+	if _, ok := timeline.objects[timelineKey("Pod", "default", "web")]; ok {
+		t.Fatalf("expected least recently updated object to be evicted")
+	}
+
+	if _, err := timeline.Get(context.Background(), "services", "default", "web"); err != ErrUnsupportedKind {
+		t.Fatalf("expected ErrUnsupportedKind, got %v", err)
+	}
+}
+
+func TestTimeline_MergesWatchRevisionsAndEvents(t *testing.T) {
+	created := metav1.NewTime(time.Now().Add(-time.Hour))
+	replicas := int32(2)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", CreationTimestamp: created},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	}
+	controller := true
+	replicaSet := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "web-abc", Namespace: "default", CreationTimestamp: metav1.NewTime(created.Add(time.Minute)),
+			Annotations:     map[string]string{"deployment.kubernetes.io/revision": "2"},
+			OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: "web", Controller: &controller}},
+		},
+		Spec: appsv1.ReplicaSetSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "web", Image: "nginx:1.25"}}}}},
+	}
+	event := &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "web.1", Namespace: "default"},
+		InvolvedObject: corev1.ObjectReference{Kind: "Deployment", Namespace: "default", Name: "web"},
+		Reason:         "ScalingReplicaSet",
+		Message:        "Scaled up replica set web-abc to 2",
+		Type:           corev1.EventTypeNormal,
+		LastTimestamp:  metav1.NewTime(created.Add(2 * time.Minute)),
+	}
+	clientset := fake.NewSimpleClientset(deployment, replicaSet, event)
+	timeline := NewTimeline(clientset, informers.NewSharedInformerFactory(clientset, 0), 100, 100)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go timeline.Run(ctx)
+
+	// Wait for the initial sync before changing anything
+	waitForEntries := func(n int) []TimelineEntry {
+		var entries []TimelineEntry
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			entries, _ = timeline.Get(ctx, "deployment", "default", "web")
+			if len(entries) >= n {
+				break
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		return entries
+	}
+	waitForEntries(3)
+
+	scaled := deployment.DeepCopy()
+	three := int32(3)
+	scaled.Spec.Replicas = &three
+	if _, err := clientset.AppsV1().Deployments("default").Update(ctx, scaled, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	entries := waitForEntries(4)
+
+	want := []string{"Created", "NewRevision", "ScalingReplicaSet", "Scaled"}
+	if len(entries) != len(want) {
+		t.Fatalf("expected %d entries, got %+v", len(want), entries)
+	}
+	for i, reason := range want {
+		if entries[i].Reason != reason {
+			t.Fatalf("entry %d: expected %s, got %s", i, reason, entries[i].Reason)
+		}
+	}
+}
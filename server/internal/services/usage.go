@@ -0,0 +1,83 @@
+// internal/services/usage.go
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ErrMetricsAPIUnavailable is returned when the clientset cannot reach
+// metrics.k8s.io, e.g. in replay or demo mode
+var ErrMetricsAPIUnavailable = errors.New("metrics.k8s.io API is not available")
+
+// ContainerUsage is one container's usage as reported by metrics-server
+type ContainerUsage struct {
+	Namespace   string
+	Pod         string
+	Container   string
+	CPUMilli    int64
+	MemoryBytes int64
+}
+
+// podMetricsList mirrors the parts of metrics.k8s.io/v1beta1 PodMetricsList
+// we read, so the metrics client library is not needed
+type podMetricsList struct {
+	Items []struct {
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		Containers []struct {
+			Name  string            `json:"name"`
+			Usage map[string]string `json:"usage"`
+		} `json:"containers"`
+	} `json:"items"`
+}
+
+// FetchContainerUsage reads current usage of every container from metrics-server
+func FetchContainerUsage(ctx context.Context, clientset kubernetes.Interface) ([]ContainerUsage, error) {
+	restClient := clientset.Discovery().RESTClient()
+	if restClient == nil {
+		return nil, ErrMetricsAPIUnavailable
+	}
+
+	raw, err := restClient.Get().AbsPath("/apis/metrics.k8s.io/v1beta1/pods").Do(ctx).Raw()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query metrics-server: %w", err)
+	}
+	return parsePodMetrics(raw)
+}
+
+func parsePodMetrics(raw []byte) ([]ContainerUsage, error) {
+	var list podMetricsList
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse pod metrics: %w", err)
+	}
+
+	usage := make([]ContainerUsage, 0)
+	for _, item := range list.Items {
+		for _, container := range item.Containers {
+			cpu, err := resource.ParseQuantity(container.Usage["cpu"])
+			if err != nil {
+				continue
+			}
+			memory, err := resource.ParseQuantity(container.Usage["memory"])
+			if err != nil {
+				continue
+			}
+			usage = append(usage, ContainerUsage{
+				Namespace:   item.Metadata.Namespace,
+				Pod:         item.Metadata.Name,
+				Container:   container.Name,
+				CPUMilli:    cpu.MilliValue(),
+				MemoryBytes: memory.Value(),
+			})
+		}
+	}
+	return usage, nil
+}
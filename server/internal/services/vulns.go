@@ -0,0 +1,457 @@
+// internal/services/vulns.go
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Image version match kinds
+const (
+	VulnMatchVersion = "version" // Tag version falls in an affected range or list
+	VulnMatchDigest  = "digest"  // Digest is listed as affected
+)
+
+// imageEcosystems are OSV ecosystems whose package names are image
+// repositories. Advisories for language or distro packages cannot be
+// matched without the image's package list and are skipped.
+var imageEcosystems = map[string]bool{"oci": true, "docker": true, "container": true}
+
+// tagVersion extracts a version from tags like v1.25.3 or 1.25.3-alpine
+var tagVersion = regexp.MustCompile(`^v?(\d+(?:\.\d+)*)`)
+
+// osvEntry is the subset of the OSV schema used for matching
+type osvEntry struct {
+	ID       string   `json:"id"`
+	Summary  string   `json:"summary"`
+	Aliases  []string `json:"aliases"`
+	Affected []struct {
+		Package struct {
+			Ecosystem string `json:"ecosystem"`
+			Name      string `json:"name"`
+			Purl      string `json:"purl"`
+		} `json:"package"`
+		Ranges []struct {
+			Type   string `json:"type"`
+			Events []struct {
+				Introduced   string `json:"introduced"`
+				Fixed        string `json:"fixed"`
+				LastAffected string `json:"last_affected"`
+			} `json:"events"`
+		} `json:"ranges"`
+		Versions []string `json:"versions"`
+	} `json:"affected"`
+	DatabaseSpecific struct {
+		Severity string `json:"severity"`
+	} `json:"database_specific"`
+}
+
+// VulnDB is an offline advisory database indexed by image package name
+type VulnDB struct {
+	entries  []osvEntry
+	packages map[string][]int // Lowercase package name to entry indexes
+}
+
+// LoadVulnDB reads an OSV export: a JSON array of advisories, a single
+// advisory, or newline-delimited advisories. No network access is needed.
+func LoadVulnDB(path string) (*VulnDB, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vulnerability database: %w", err)
+	}
+
+	var entries []osvEntry
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(trimmed, &entries)
+	} else {
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		for decoder.More() {
+			var entry osvEntry
+			if err = decoder.Decode(&entry); err != nil {
+				break
+			}
+			entries = append(entries, entry)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse vulnerability database: %w", err)
+	}
+	return newVulnDB(entries), nil
+}
+
+func newVulnDB(entries []osvEntry) *VulnDB {
+	db := &VulnDB{entries: entries, packages: make(map[string][]int)}
+	for i, entry := range entries {
+		seen := make(map[string]bool)
+		for _, affected := range entry.Affected {
+			name := imagePackageName(affected.Package.Ecosystem, affected.Package.Name, affected.Package.Purl)
+			if name != "" && !seen[name] {
+				seen[name] = true
+				db.packages[name] = append(db.packages[name], i)
+			}
+		}
+	}
+	return db
+}
+
+// Size is the number of advisories loaded
+func (db *VulnDB) Size() int {
+	return len(db.entries)
+}
+
+// imagePackageName returns the lowercase image name an affected package
+// refers to, or "" if it is not an image package
+func imagePackageName(ecosystem, name, purl string) string {
+	for _, prefix := range []string{"pkg:oci/", "pkg:docker/"} {
+		if strings.HasPrefix(purl, prefix) {
+			name = strings.TrimPrefix(purl, prefix)
+			if i := strings.IndexAny(name, "@?#"); i >= 0 {
+				name = name[:i]
+			}
+			return strings.ToLower(name)
+		}
+	}
+	if imageEcosystems[strings.ToLower(ecosystem)] {
+		return strings.ToLower(name)
+	}
+	return ""
+}
+
+// VulnMatch is an advisory affecting an image
+type VulnMatch struct {
+	ID        string   `json:"id"`
+	Aliases   []string `json:"aliases,omitempty"`
+	Summary   string   `json:"summary"`
+	Severity  string   `json:"severity"`
+	Image     string   `json:"image"`
+	Package   string   `json:"package"`
+	Version   string   `json:"version,omitempty"`
+	FixedIn   string   `json:"fixed_in,omitempty"`
+	MatchedBy string   `json:"matched_by"`
+}
+
+// WorkloadVulns are the advisories affecting a workload's images
+type WorkloadVulns struct {
+	Namespace       string         `json:"namespace"`
+	WorkloadKind    string         `json:"workload_kind"`
+	Workload        string         `json:"workload"`
+	Images          []string       `json:"images"`
+	Vulnerabilities []VulnMatch    `json:"vulnerabilities"`
+	BySeverity      map[string]int `json:"by_severity"`
+}
+
+// NamespaceVulns rolls up advisories for a namespace. Advisories shared by
+// several workloads are counted once.
+type NamespaceVulns struct {
+	Namespace         string         `json:"namespace"`
+	AffectedWorkloads int            `json:"affected_workloads"`
+	Vulnerabilities   int            `json:"vulnerabilities"`
+	BySeverity        map[string]int `json:"by_severity"`
+}
+
+// UnmatchedImage is a running image that could not be checked
+type UnmatchedImage struct {
+	Image  string `json:"image"`
+	Reason string `json:"reason"`
+}
+
+// VulnReport matches running images against the advisory database
+type VulnReport struct {
+	Workloads       []WorkloadVulns  `json:"workloads"`
+	Namespaces      []NamespaceVulns `json:"namespaces"`
+	Unmatched       []UnmatchedImage `json:"unmatched"`
+	DatabaseEntries int              `json:"database_entries"`
+	Count           int              `json:"count"` // Affected workloads
+}
+
+// MatchImages checks every image in the inventory against the database and
+// groups the results by workload and namespace
+func (db *VulnDB) MatchImages(inventory *ImageInventory) *VulnReport {
+	report := &VulnReport{
+		Workloads:       make([]WorkloadVulns, 0),
+		Namespaces:      make([]NamespaceVulns, 0),
+		Unmatched:       make([]UnmatchedImage, 0),
+		DatabaseEntries: len(db.entries),
+	}
+
+	type workloadKey struct{ namespace, kind, name string }
+	workloads := make(map[workloadKey]*WorkloadVulns)
+	seen := make(map[workloadKey]map[string]bool) // Advisory ID and image already added
+
+	for _, repo := range inventory.Repositories {
+		for _, version := range repo.Versions {
+			ref := ParseImageRef(version.Image)
+			matches, reason := db.matchImage(ref, version)
+			if reason != "" {
+				report.Unmatched = append(report.Unmatched, UnmatchedImage{Image: version.Image, Reason: reason})
+			}
+			if len(matches) == 0 {
+				continue
+			}
+
+			for _, p := range version.Pods {
+				key := workloadKey{p.Namespace, p.WorkloadKind, p.Workload}
+				workload, ok := workloads[key]
+				if !ok {
+					workload = &WorkloadVulns{
+						Namespace:    p.Namespace,
+						WorkloadKind: p.WorkloadKind,
+						Workload:     p.Workload,
+						BySeverity:   make(map[string]int),
+					}
+					workloads[key] = workload
+					seen[key] = make(map[string]bool)
+				}
+				if !seen[key]["image:"+version.Image] {
+					seen[key]["image:"+version.Image] = true
+					workload.Images = append(workload.Images, version.Image)
+				}
+				for _, match := range matches {
+					if id := match.ID + "|" + match.Image; !seen[key][id] {
+						seen[key][id] = true
+						workload.Vulnerabilities = append(workload.Vulnerabilities, match)
+						workload.BySeverity[match.Severity]++
+					}
+				}
+			}
+		}
+	}
+
+	namespaces := make(map[string]*NamespaceVulns)
+	namespaceIDs := make(map[string]map[string]bool)
+	for _, workload := range workloads {
+		sort.Strings(workload.Images)
+		sort.Slice(workload.Vulnerabilities, func(i, j int) bool {
+			a, b := workload.Vulnerabilities[i], workload.Vulnerabilities[j]
+			if advisorySeverityRank(a.Severity) != advisorySeverityRank(b.Severity) {
+				return advisorySeverityRank(a.Severity) < advisorySeverityRank(b.Severity)
+			}
+			return a.ID < b.ID
+		})
+		report.Workloads = append(report.Workloads, *workload)
+
+		ns, ok := namespaces[workload.Namespace]
+		if !ok {
+			ns = &NamespaceVulns{Namespace: workload.Namespace, BySeverity: make(map[string]int)}
+			namespaces[workload.Namespace] = ns
+			namespaceIDs[workload.Namespace] = make(map[string]bool)
+		}
+		ns.AffectedWorkloads++
+		for _, match := range workload.Vulnerabilities {
+			if !namespaceIDs[workload.Namespace][match.ID] {
+				namespaceIDs[workload.Namespace][match.ID] = true
+				ns.Vulnerabilities++
+				ns.BySeverity[match.Severity]++
+			}
+		}
+	}
+	for _, ns := range namespaces {
+		report.Namespaces = append(report.Namespaces, *ns)
+	}
+
+	sort.Slice(report.Workloads, func(i, j int) bool {
+		a, b := report.Workloads[i], report.Workloads[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.WorkloadKind != b.WorkloadKind {
+			return a.WorkloadKind < b.WorkloadKind
+		}
+		return a.Workload < b.Workload
+	})
+	sort.Slice(report.Namespaces, func(i, j int) bool { return report.Namespaces[i].Namespace < report.Namespaces[j].Namespace })
+	sort.Slice(report.Unmatched, func(i, j int) bool { return report.Unmatched[i].Image < report.Unmatched[j].Image })
+	report.Count = len(report.Workloads)
+	return report
+}
+
+// advisoryNames are the package names an advisory may use for ref: the full
+// repository, plus the shorter Docker Hub forms. A bare name such as nginx
+// only refers to the Docker Hub official image, so an image from another
+// registry or namespace never matches it.
+func advisoryNames(ref ImageRef) []string {
+	names := []string{ref.FullRepository()}
+	if ref.Registry == defaultRegistry {
+		names = append(names, ref.Repository)
+		if name, ok := strings.CutPrefix(ref.Repository, "library/"); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// matchImage returns the advisories affecting an image. The reason is set
+// when the image's version cannot be checked; digest matches still apply.
+func (db *VulnDB) matchImage(ref ImageRef, version ImageVersion) ([]VulnMatch, string) {
+	candidates := make(map[int]bool)
+	var pkg string
+	for _, name := range advisoryNames(ref) {
+		for _, i := range db.packages[strings.ToLower(name)] {
+			candidates[i] = true
+			if pkg == "" {
+				pkg = name
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, ""
+	}
+
+	digests := make(map[string]bool)
+	for _, digest := range version.Digests {
+		digests[digest] = true
+	}
+	if ref.Digest != "" {
+		digests[ref.Digest] = true
+	}
+
+	tagged := ""
+	reason := ""
+	if m := tagVersion.FindStringSubmatch(ref.Tag); m != nil {
+		tagged = m[1]
+	} else {
+		reason = fmt.Sprintf("tag %q is not a version; only digests were matched", ref.Tag)
+	}
+
+	indexes := make([]int, 0, len(candidates))
+	for i := range candidates {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	var matches []VulnMatch
+	for _, i := range indexes {
+		entry := &db.entries[i]
+		matchedBy, fixed := entryAffects(entry, tagged, digests)
+		if matchedBy == "" {
+			continue
+		}
+		severity := strings.ToLower(entry.DatabaseSpecific.Severity)
+		if severity == "" {
+			severity = "unknown"
+		}
+		matches = append(matches, VulnMatch{
+			ID:        entry.ID,
+			Aliases:   entry.Aliases,
+			Summary:   entry.Summary,
+			Severity:  severity,
+			Image:     version.Image,
+			Package:   pkg,
+			Version:   tagged,
+			FixedIn:   fixed,
+			MatchedBy: matchedBy,
+		})
+	}
+	return matches, reason
+}
+
+// entryAffects reports how an advisory matches the version or digests and
+// the first fixed version, if any
+func entryAffects(entry *osvEntry, version string, digests map[string]bool) (string, string) {
+	for _, affected := range entry.Affected {
+		for _, v := range affected.Versions {
+			if digests[v] {
+				return VulnMatchDigest, ""
+			}
+			if version != "" && v == version {
+				return VulnMatchVersion, ""
+			}
+		}
+		if version == "" {
+			continue
+		}
+		for _, r := range affected.Ranges {
+			if r.Type == "GIT" {
+				continue
+			}
+			// Each introduced event opens a range closed by the next
+			// fixed or last_affected event
+			for j, event := range r.Events {
+				if event.Introduced == "" {
+					continue
+				}
+				if event.Introduced != "0" && compareVersions(version, event.Introduced) < 0 {
+					continue
+				}
+				fixed, lastAffected := "", ""
+				for _, next := range r.Events[j+1:] {
+					if next.Introduced != "" {
+						break
+					}
+					if next.Fixed != "" {
+						fixed = next.Fixed
+						break
+					}
+					if next.LastAffected != "" {
+						lastAffected = next.LastAffected
+						break
+					}
+				}
+				if fixed != "" && compareVersions(version, fixed) >= 0 {
+					continue
+				}
+				if lastAffected != "" && compareVersions(version, lastAffected) > 0 {
+					continue
+				}
+				return VulnMatchVersion, fixed
+			}
+		}
+	}
+	return "", ""
+}
+
+// compareVersions compares dotted versions numerically, treating missing
+// components as 0 and falling back to string order for non-numeric parts
+func compareVersions(a, b string) int {
+	as := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bs := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		x, y := "0", "0"
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+		xn, errX := strconv.Atoi(x)
+		yn, errY := strconv.Atoi(y)
+		switch {
+		case errX == nil && errY == nil:
+			if xn != yn {
+				if xn < yn {
+					return -1
+				}
+				return 1
+			}
+		case x != y:
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// advisorySeverityRank orders OSV severities from most to least severe
+func advisorySeverityRank(severity string) int {
+	switch severity {
+	case "critical":
+		return 0
+	case "high":
+		return 1
+	case "moderate", "medium":
+		return 2
+	case "low":
+		return 3
+	default:
+		return 4
+	}
+}
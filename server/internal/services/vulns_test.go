@@ -0,0 +1,153 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const testAdvisories = `[
+  {"id": "OSV-1", "summary": "nginx resolver overflow", "aliases": ["CVE-2021-23017"],
+   "affected": [{"package": {"ecosystem": "OCI", "name": "nginx"},
+                 "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "1.21.0"}]}]}],
+   "database_specific": {"severity": "HIGH"}},
+  {"id": "OSV-2", "summary": "bad build pushed",
+   "affected": [{"package": {"purl": "pkg:docker/library/nginx"}, "versions": ["sha256:bad"]}],
+   "database_specific": {"severity": "CRITICAL"}},
+  {"id": "OSV-3", "summary": "python redis client issue",
+   "affected": [{"package": {"ecosystem": "PyPI", "name": "redis"},
+                 "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}]}]}]},
+  {"id": "OSV-4", "summary": "redis range with last_affected",
+   "affected": [{"package": {"ecosystem": "Docker", "name": "redis"},
+                 "ranges": [{"type": "SEMVER", "events": [{"introduced": "7.0.0"}, {"last_affected": "7.0.5"}]}]}]}
+]`
+
+func TestLoadVulnDB(t *testing.T) {
+	dir := t.TempDir()
+	array := filepath.Join(dir, "db.json")
+	if err := os.WriteFile(array, []byte(testAdvisories), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	db, err := LoadVulnDB(array)
+	if err != nil {
+		t.Fatalf("LoadVulnDB failed: %v", err)
+	}
+	if db.Size() != 4 {
+		t.Errorf("expected 4 advisories, got %d", db.Size())
+	}
+	if len(db.packages["redis"]) != 1 || len(db.packages["library/nginx"]) != 1 {
+		t.Errorf("unexpected package index %v", db.packages)
+	}
+
+	lines := filepath.Join(dir, "db.jsonl")
+	data := `{"id": "A", "affected": [{"package": {"ecosystem": "OCI", "name": "x"}}]}
+{"id": "B"}`
+	if err := os.WriteFile(lines, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if db, err := LoadVulnDB(lines); err != nil || db.Size() != 2 {
+		t.Errorf("expected 2 newline-delimited advisories, got %v, %v", db, err)
+	}
+}
+
+func TestMatchImages(t *testing.T) {
+	db, err := LoadVulnDB(writeTestFile(t, testAdvisories))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	controller := true
+	pod := func(namespace, name, owner, image, imageID string) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       namespace,
+				OwnerReferences: []metav1.OwnerReference{{Kind: "StatefulSet", Name: owner, Controller: &controller}},
+			},
+			Spec:   corev1.PodSpec{Containers: []corev1.Container{{Name: "main", Image: image}}},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning, ContainerStatuses: []corev1.ContainerStatus{{Name: "main", ImageID: imageID}}},
+		}
+	}
+	inventory := BuildImageInventory([]corev1.Pod{
+		pod("web", "old-0", "old", "nginx:1.20.1-alpine", ""),
+		pod("web", "old-1", "old", "nginx:1.20.1-alpine", ""),
+		pod("web", "new-0", "new", "nginx:1.25", ""),
+		pod("web", "edge-0", "edge", "nginx:mainline", "docker-pullable://nginx@sha256:bad"),
+		pod("cache", "redis-0", "redis", "redis:7.0.3", ""),
+		pod("cache", "redis8-0", "redis8", "redis:7.2.0", ""),
+		pod("apps", "proxy-0", "proxy", "ghcr.io/acme/nginx:1.20.1", ""),
+		pod("apps", "cache-0", "cache", "bitnami/redis:7.0.3", ""),
+	})
+
+	report := db.MatchImages(inventory)
+	if report.DatabaseEntries != 4 || report.Count != 3 {
+		t.Fatalf("expected 3 affected workloads, got %+v", report.Workloads)
+	}
+
+	byName := make(map[string]WorkloadVulns)
+	for _, w := range report.Workloads {
+		byName[w.Workload] = w
+	}
+	old := byName["old"]
+	if len(old.Vulnerabilities) != 1 || old.Vulnerabilities[0].ID != "OSV-1" || old.Vulnerabilities[0].FixedIn != "1.21.0" || old.Vulnerabilities[0].Version != "1.20.1" {
+		t.Errorf("unexpected matches for old: %+v", old.Vulnerabilities)
+	}
+	edge := byName["edge"]
+	if len(edge.Vulnerabilities) != 1 || edge.Vulnerabilities[0].MatchedBy != VulnMatchDigest || edge.Vulnerabilities[0].Severity != "critical" {
+		t.Errorf("unexpected matches for edge: %+v", edge.Vulnerabilities)
+	}
+	redis := byName["redis"]
+	if len(redis.Vulnerabilities) != 1 || redis.Vulnerabilities[0].ID != "OSV-4" || redis.Vulnerabilities[0].Severity != "unknown" {
+		t.Errorf("unexpected matches for redis: %+v", redis.Vulnerabilities)
+	}
+	if _, ok := byName["new"]; ok {
+		t.Error("nginx 1.25 should not match")
+	}
+	if _, ok := byName["redis8"]; ok {
+		t.Error("redis 7.2.0 is past last_affected")
+	}
+	// Advisories for the official nginx and redis images do not apply to
+	// images of the same name from other registries or namespaces
+	if _, ok := byName["proxy"]; ok {
+		t.Error("ghcr.io/acme/nginx should not match nginx advisories")
+	}
+	if _, ok := byName["cache"]; ok {
+		t.Error("bitnami/redis should not match redis advisories")
+	}
+
+	if len(report.Namespaces) != 2 || report.Namespaces[1].Namespace != "web" || report.Namespaces[1].AffectedWorkloads != 2 {
+		t.Errorf("unexpected namespaces %+v", report.Namespaces)
+	}
+	if len(report.Unmatched) != 1 || report.Unmatched[0].Image != "nginx:mainline" {
+		t.Errorf("unexpected unmatched images %+v", report.Unmatched)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.20.1", "1.21.0", -1},
+		{"1.21", "1.21.0", 0},
+		{"v2.0", "1.9.9", 1},
+		{"1.10", "1.9", 1},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func writeTestFile(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "db.json")
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,285 @@
+// internal/snapshots/store.go
+package snapshots
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/mugayoshi/k8s-visualizer/server/internal/services"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	metaBucket = []byte("meta")
+	dataBucket = []byte("data")
+
+	// ErrNotFound is returned when a snapshot does not exist
+	ErrNotFound = errors.New("snapshot not found")
+)
+
+// IDs are the capture time in idFormat plus a "-" and a zero-padded
+// sequence number, so they sort lexically in time order and two snapshots
+// taken in the same millisecond still get distinct keys
+const idFormat = "20060102T150405.000Z"
+
+// idTimeUpperBound follows every ID with the given time prefix, since
+// sequence digits sort before '~'
+const idTimeUpperBound = "-~"
+
+// Meta describes a stored snapshot without its contents
+type Meta struct {
+	ID          string    `json:"id"`
+	Time        time.Time `json:"time"`
+	Trigger     string    `json:"trigger"` // "scheduled" or "manual"
+	Namespaces  int       `json:"namespaces"`
+	Nodes       int       `json:"nodes"`
+	Pods        int       `json:"pods"`
+	Deployments int       `json:"deployments"`
+	Events      int       `json:"events"`
+	SizeBytes   int       `json:"size_bytes"`
+}
+
+// Retention limits how many snapshots are kept. Zero values disable a limit.
+type Retention struct {
+	MaxCount int
+	MaxAge   time.Duration
+}
+
+// Store keeps gzipped snapshots in a local bbolt database
+type Store struct {
+	db        *bolt.DB
+	retention Retention
+}
+
+// Open opens or creates the database at path
+func Open(path string, retention Retention) (*Store, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot store: %w", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(metaBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(dataBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize snapshot store: %w", err)
+	}
+	return &Store{db: db, retention: retention}, nil
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Save stores a snapshot and applies the retention policy
+func (s *Store) Save(snapshot *services.ClusterSnapshot, trigger string) (*Meta, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(snapshot); err != nil {
+		return nil, fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress snapshot: %w", err)
+	}
+
+	meta := &Meta{
+		Time:        snapshot.Time,
+		Trigger:     trigger,
+		Namespaces:  len(snapshot.Namespaces),
+		Nodes:       len(snapshot.Nodes),
+		Pods:        len(snapshot.Pods),
+		Deployments: len(snapshot.Deployments),
+		Events:      len(snapshot.Events),
+		SizeBytes:   buf.Len(),
+	}
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		seq, err := tx.Bucket(metaBucket).NextSequence()
+		if err != nil {
+			return err
+		}
+		meta.ID = fmt.Sprintf("%s-%010d", snapshot.Time.UTC().Format(idFormat), seq)
+		metaJSON, err := json.Marshal(meta)
+		if err != nil {
+			return fmt.Errorf("failed to encode snapshot metadata: %w", err)
+		}
+		if err := tx.Bucket(metaBucket).Put([]byte(meta.ID), metaJSON); err != nil {
+			return err
+		}
+		if err := tx.Bucket(dataBucket).Put([]byte(meta.ID), buf.Bytes()); err != nil {
+			return err
+		}
+		return s.applyRetention(tx, time.Now())
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save snapshot: %w", err)
+	}
+	return meta, nil
+}
+
+// applyRetention deletes the oldest snapshots beyond the configured limits
+func (s *Store) applyRetention(tx *bolt.Tx, now time.Time) error {
+	ids := make([]string, 0)
+	metas := tx.Bucket(metaBucket)
+	if err := metas.ForEach(func(k, _ []byte) error {
+		ids = append(ids, string(k))
+		return nil
+	}); err != nil {
+		return err
+	}
+	// Keys are already in time order, oldest first
+
+	expired := make([]string, 0)
+	if s.retention.MaxCount > 0 && len(ids) > s.retention.MaxCount {
+		expired = append(expired, ids[:len(ids)-s.retention.MaxCount]...)
+		ids = ids[len(ids)-s.retention.MaxCount:]
+	}
+	if s.retention.MaxAge > 0 {
+		cutoff := now.Add(-s.retention.MaxAge).UTC().Format(idFormat)
+		for _, id := range ids {
+			if id < cutoff {
+				expired = append(expired, id)
+			}
+		}
+	}
+
+	for _, id := range expired {
+		if err := metas.Delete([]byte(id)); err != nil {
+			return err
+		}
+		if err := tx.Bucket(dataBucket).Delete([]byte(id)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List returns snapshot metadata, newest first
+func (s *Store) List() ([]Meta, error) {
+	result := make([]Meta, 0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(metaBucket).ForEach(func(_, v []byte) error {
+			var meta Meta
+			if err := json.Unmarshal(v, &meta); err != nil {
+				return err
+			}
+			result = append(result, meta)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID > result[j].ID })
+	return result, nil
+}
+
+// At returns the metadata of the newest snapshot taken at or before t
+func (s *Store) At(t time.Time) (*Meta, error) {
+	var meta *Meta
+	err := s.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(metaBucket).Cursor()
+		target := []byte(t.UTC().Format(idFormat) + idTimeUpperBound)
+
+		// Seek lands on the first key after t; step back to the one before it
+		cursor.Seek(target)
+		k, v := cursor.Prev()
+		if k == nil {
+			return ErrNotFound
+		}
+		meta = &Meta{}
+		return json.Unmarshal(v, meta)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// Get returns a full snapshot by ID
+func (s *Store) Get(id string) (*services.ClusterSnapshot, error) {
+	var data []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(dataBucket).Get([]byte(id))
+		if v == nil {
+			return ErrNotFound
+		}
+		// Values are only valid inside the transaction
+		data = append([]byte(nil), v...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress snapshot: %w", err)
+	}
+	defer zr.Close()
+
+	var snapshot services.ClusterSnapshot
+	if err := json.NewDecoder(zr).Decode(&snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	return &snapshot, nil
+}
+
+// Delete removes a snapshot and returns its metadata
+func (s *Store) Delete(id string) (*Meta, error) {
+	var meta Meta
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		v := tx.Bucket(metaBucket).Get([]byte(id))
+		if v == nil {
+			return ErrNotFound
+		}
+		if err := json.Unmarshal(v, &meta); err != nil {
+			return err
+		}
+		if err := tx.Bucket(metaBucket).Delete([]byte(id)); err != nil {
+			return err
+		}
+		return tx.Bucket(dataBucket).Delete([]byte(id))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+// Schedule takes a snapshot every interval until ctx is cancelled
+func (s *Store) Schedule(ctx context.Context, interval time.Duration, capture func(context.Context) (*services.ClusterSnapshot, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			snapshot, err := capture(ctx)
+			if err != nil {
+				log.Printf("Failed to capture scheduled snapshot: %v", err)
+				continue
+			}
+			meta, err := s.Save(snapshot, "scheduled")
+			if err != nil {
+				log.Printf("Failed to save scheduled snapshot: %v", err)
+				continue
+			}
+			log.Printf("Saved snapshot %s (%d pods, %d bytes)", meta.ID, meta.Pods, meta.SizeBytes)
+		}
+	}
+}
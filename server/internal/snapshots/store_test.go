@@ -0,0 +1,121 @@
+package snapshots
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mugayoshi/k8s-visualizer/server/internal/services"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func openTestStore(t *testing.T, retention Retention) *Store {
+	t.Helper()
+	store, err := Open(filepath.Join(t.TempDir(), "snapshots.db"), retention)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestStore_SaveAndGetRoundTrip(t *testing.T) {
+	cs := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}},
+	)
+	snapshot, err := services.CaptureSnapshot(context.Background(), cs)
+	if err != nil {
+		t.Fatalf("CaptureSnapshot failed: %v", err)
+	}
+
+	store := openTestStore(t, Retention{})
+	meta, err := store.Save(snapshot, "manual")
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if meta.Pods != 1 || meta.Namespaces != 1 || meta.Trigger != "manual" {
+		t.Fatalf("unexpected meta: %+v", meta)
+	}
+
+	got, err := store.Get(meta.ID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if len(got.Pods) != 1 || got.Pods[0].Name != "web" {
+		t.Fatalf("unexpected snapshot contents: %+v", got.Pods)
+	}
+
+	if _, err := store.Get("missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestStore_AtAndRetention(t *testing.T) {
+	store := openTestStore(t, Retention{MaxCount: 3})
+	base := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+
+	for i := 0; i < 5; i++ {
+		snapshot := &services.ClusterSnapshot{Time: base.Add(time.Duration(i) * 10 * time.Minute)}
+		if _, err := store.Save(snapshot, "scheduled"); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+
+	metas, err := store.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(metas) != 3 || !metas[0].Time.Equal(base.Add(40*time.Minute)) {
+		t.Fatalf("expected 3 newest snapshots, got %+v", metas)
+	}
+
+	meta, err := store.At(base.Add(35 * time.Minute))
+	if err != nil || !meta.Time.Equal(base.Add(30*time.Minute)) {
+		t.Fatalf("expected snapshot at +30m, got %+v, %v", meta, err)
+	}
+	meta, err = store.At(base.Add(40 * time.Minute))
+	if err != nil || !meta.Time.Equal(base.Add(40*time.Minute)) {
+		t.Fatalf("expected exact match at +40m, got %+v, %v", meta, err)
+	}
+	meta, err = store.At(base.Add(2 * time.Hour))
+	if err != nil || !meta.Time.Equal(base.Add(40*time.Minute)) {
+		t.Fatalf("expected newest snapshot for future time, got %+v, %v", meta, err)
+	}
+	if _, err := store.At(base); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound before retained range, got %v", err)
+	}
+}
+
+func TestStore_SaveSameTimeKeepsBoth(t *testing.T) {
+	store := openTestStore(t, Retention{})
+	at := time.Now().UTC().Truncate(time.Millisecond)
+
+	first, err := store.Save(&services.ClusterSnapshot{Time: at}, "scheduled")
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	second, err := store.Save(&services.ClusterSnapshot{Time: at}, "manual")
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if first.ID == second.ID {
+		t.Fatalf("snapshots taken at the same time share ID %s", first.ID)
+	}
+
+	metas, err := store.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(metas) != 2 || metas[0].ID != second.ID {
+		t.Fatalf("expected both snapshots, newest first, got %+v", metas)
+	}
+	meta, err := store.At(at)
+	if err != nil || meta.ID != second.ID {
+		t.Fatalf("expected the later save at %s, got %+v, %v", at, meta, err)
+	}
+}
@@ -0,0 +1,184 @@
+// internal/telemetry/prometheus.go
+package telemetry
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "k8s_visualizer"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	// WebSocketConnections is the number of currently open WebSocket connections
+	WebSocketConnections = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "websocket_connections_open",
+		Help:      "Currently open WebSocket connections.",
+	})
+
+	// WebSocketMessagesSent counts messages queued to clients by message type
+	WebSocketMessagesSent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "websocket_messages_sent_total",
+		Help:      "WebSocket messages queued to clients by message type.",
+	}, []string{"type"})
+
+	// WebSocketMessagesDropped counts messages dropped because a client's send buffer was full
+	WebSocketMessagesDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "websocket_messages_dropped_total",
+		Help:      "WebSocket messages dropped because the client send buffer was full.",
+	}, []string{"type"})
+
+	// WebSocketConnectionMessages observes per-connection totals when a connection closes
+	WebSocketConnectionMessages = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "websocket_connection_messages",
+		Help:      "Messages sent or dropped over the lifetime of a single WebSocket connection.",
+		Buckets:   prometheus.ExponentialBuckets(1, 4, 10),
+	}, []string{"result"})
+
+	// PodWatchRestarts counts restarts of the pod watch used by WebSocket clients
+	PodWatchRestarts = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pod_watch_restarts_total",
+		Help:      "Restarts of the pod watch after its result channel closed.",
+	})
+
+	k8sRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "kubernetes_api_request_duration_seconds",
+		Help:      "Kubernetes API call latency by verb and resource.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"verb", "resource", "code"})
+)
+
+// Handler serves the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// HTTPMetrics records request counts and latency per matched route
+func HTTPMetrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			// Unmatched paths are collapsed so scanners can't explode label cardinality
+			route = "unmatched"
+		}
+		method := c.Request.Method
+		httpRequests.WithLabelValues(route, method, strconv.Itoa(c.Writer.Status())).Inc()
+		httpDuration.WithLabelValues(route, method).Observe(time.Since(start).Seconds())
+	}
+}
+
+// RegisterCacheSize exposes the current size of an informer cache
+func RegisterCacheSize(informer string, size func() int) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace:   namespace,
+		Name:        "informer_cache_objects",
+		Help:        "Objects held in an informer cache.",
+		ConstLabels: prometheus.Labels{"informer": informer},
+	}, func() float64 { return float64(size()) })
+}
+
+// InstrumentRoundTripper wraps a client-go transport to observe the latency
+// of every Kubernetes API call. It is meant for rest.Config.Wrap.
+func InstrumentRoundTripper(rt http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		start := time.Now()
+		resp, err := rt.RoundTrip(req)
+
+		code := "error"
+		if err == nil {
+			code = strconv.Itoa(resp.StatusCode)
+		}
+		verb, resource := classifyRequest(req)
+		k8sRequestDuration.WithLabelValues(verb, resource, code).Observe(time.Since(start).Seconds())
+		return resp, err
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// classifyRequest maps an API server request to a Kubernetes verb and resource.
+// Paths look like /api/v1/namespaces/{ns}/pods/{name}/log or
+// /apis/{group}/{version}/{resource}/{name}.
+func classifyRequest(req *http.Request) (string, string) {
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	switch {
+	case len(parts) >= 2 && parts[0] == "api":
+		parts = parts[2:]
+	case len(parts) >= 3 && parts[0] == "apis":
+		parts = parts[3:]
+	default:
+		return strings.ToLower(req.Method), "other"
+	}
+	if len(parts) >= 2 && parts[0] == "namespaces" && len(parts) > 2 {
+		parts = parts[2:]
+	}
+	if len(parts) == 0 {
+		return strings.ToLower(req.Method), "discovery"
+	}
+
+	resource := parts[0]
+	hasName := len(parts) >= 2
+	if len(parts) >= 3 {
+		resource = resource + "/" + parts[2]
+	}
+
+	var verb string
+	switch req.Method {
+	case http.MethodGet:
+		switch {
+		case req.URL.Query().Get("watch") == "true":
+			verb = "watch"
+		case hasName:
+			verb = "get"
+		default:
+			verb = "list"
+		}
+	case http.MethodPost:
+		verb = "create"
+	case http.MethodPut:
+		verb = "update"
+	case http.MethodPatch:
+		verb = "patch"
+	case http.MethodDelete:
+		if hasName {
+			verb = "delete"
+		} else {
+			verb = "deletecollection"
+		}
+	default:
+		verb = strings.ToLower(req.Method)
+	}
+	return verb, resource
+}
@@ -0,0 +1,52 @@
+package telemetry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestClassifyRequest(t *testing.T) {
+	cases := []struct {
+		method, url    string
+		verb, resource string
+	}{
+		{http.MethodGet, "/api/v1/pods", "list", "pods"},
+		{http.MethodGet, "/api/v1/namespaces", "list", "namespaces"},
+		{http.MethodGet, "/api/v1/namespaces/default", "get", "namespaces"},
+		{http.MethodGet, "/api/v1/namespaces/default/pods?watch=true", "watch", "pods"},
+		{http.MethodGet, "/api/v1/namespaces/default/pods/web/log", "get", "pods/log"},
+		{http.MethodGet, "/apis/apps/v1/namespaces/default/deployments/web", "get", "deployments"},
+		{http.MethodDelete, "/api/v1/namespaces/default/pods", "deletecollection", "pods"},
+		{http.MethodPatch, "/api/v1/nodes/node-1", "patch", "nodes"},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.url, nil)
+		verb, resource := classifyRequest(req)
+		if verb != tc.verb || resource != tc.resource {
+			t.Errorf("%s %s: got (%s, %s), want (%s, %s)", tc.method, tc.url, verb, resource, tc.verb, tc.resource)
+		}
+	}
+}
+
+func TestHandler_ExposesHTTPMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(HTTPMetrics())
+	r.GET("/metrics", gin.WrapH(Handler()))
+	r.GET("/api/nodes", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/nodes", nil))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `k8s_visualizer_http_requests_total{method="GET",route="/api/nodes",status="200"} 1`) {
+		t.Fatalf("expected request counter in output")
+	}
+}
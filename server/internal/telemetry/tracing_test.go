@@ -0,0 +1,22 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mugayoshi/k8s-visualizer/server/internal/pkg/config"
+)
+
+func TestSetupTracing_DisabledAndUnknownExporter(t *testing.T) {
+	shutdown, err := SetupTracing(context.Background(), config.TracingConfig{})
+	if err != nil {
+		t.Fatalf("expected disabled tracing to succeed, got %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown failed: %v", err)
+	}
+
+	if _, err := SetupTracing(context.Background(), config.TracingConfig{Exporter: "zipkin"}); err == nil {
+		t.Fatalf("expected error for unknown exporter")
+	}
+}